@@ -390,6 +390,7 @@ const (
 	DatabaseDriverH2         DatabaseDriver = "h2"
 	DatabaseDriverVertica    DatabaseDriver = "vertica"
 	DatabaseDriverClickhouse DatabaseDriver = "clickhouse"
+	DatabaseDriverSchemaFile DatabaseDriver = "schemafile"
 )
 
 func DataBaseKeywords(driver DatabaseDriver) []string {
@@ -41,6 +41,64 @@ func (c *Completer) functionCandidates(lower bool, keywords []string) []lsp.Comp
 	return candidates
 }
 
+// RoutineCandidates returns the stored functions and procedures of the
+// default schema, overloads are merged into one candidate.
+func (c *Completer) RoutineCandidates() []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	overloads := map[string][]*database.RoutineDesc{}
+	names := []string{}
+	for _, routine := range c.DBCache.SortedRoutines() {
+		if _, ok := overloads[routine.Name]; !ok {
+			names = append(names, routine.Name)
+		}
+		overloads[routine.Name] = append(overloads[routine.Name], routine)
+	}
+	for _, name := range names {
+		routines := overloads[name]
+		candidate := lsp.CompletionItem{
+			Label:  name,
+			Kind:   lsp.FunctionCompletion,
+			Detail: routines[0].Signature(),
+			Documentation: lsp.MarkupContent{
+				Kind:  lsp.Markdown,
+				Value: database.RoutineDoc(routines),
+			},
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// IndexCandidates returns the indexes of the referenced tables, or all
+// indexes of the default schema when no table is referenced.
+func (c *Completer) IndexCandidates(targetTables []*parseutil.TableInfo) []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	for _, index := range c.DBCache.SortedIndexes() {
+		if len(targetTables) > 0 {
+			referenced := false
+			for _, table := range targetTables {
+				if strings.EqualFold(table.Name, index.Table) {
+					referenced = true
+				}
+			}
+			if !referenced {
+				continue
+			}
+		}
+		kind := "index"
+		if index.Unique {
+			kind = "unique index"
+		}
+		candidate := lsp.CompletionItem{
+			Label:  index.Name,
+			Kind:   lsp.PropertyCompletion,
+			Detail: fmt.Sprintf("%s on \"%s\" (%s)", kind, index.Table, strings.Join(index.Columns, ", ")),
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
 func (c *Completer) columnCandidates(targetTables []*parseutil.TableInfo, parent *completionParent) []lsp.CompletionItem {
 	candidates := []lsp.CompletionItem{}
 
@@ -52,13 +110,13 @@ func (c *Completer) columnCandidates(targetTables []*parseutil.TableInfo, parent
 				if !ok {
 					continue
 				}
-				candidates = append(candidates, generateColumnCandidates(table.Name, columns)...)
+				candidates = append(candidates, generateColumnCandidates(table.Name, columns, c.DBCache)...)
 			} else if table.Name != "" {
 				columns, ok := c.DBCache.ColumnDescs(table.Name)
 				if !ok {
 					continue
 				}
-				candidates = append(candidates, generateColumnCandidates(table.Name, columns)...)
+				candidates = append(candidates, generateColumnCandidates(table.Name, columns, c.DBCache)...)
 			}
 		}
 	case ParentTypeSchema:
@@ -72,7 +130,7 @@ func (c *Completer) columnCandidates(targetTables []*parseutil.TableInfo, parent
 			if !ok {
 				continue
 			}
-			candidates = append(candidates, generateColumnCandidates(table.Name, columns)...)
+			candidates = append(candidates, generateColumnCandidates(table.Name, columns, c.DBCache)...)
 		}
 	case ParentTypeSubQuery:
 		// pass
@@ -80,13 +138,17 @@ func (c *Completer) columnCandidates(targetTables []*parseutil.TableInfo, parent
 	return candidates
 }
 
-func generateColumnCandidates(tableName string, columns []*database.ColumnDesc) []lsp.CompletionItem {
+func generateColumnCandidates(tableName string, columns []*database.ColumnDesc, dbCache *database.DBCache) []lsp.CompletionItem {
 	candidates := []lsp.CompletionItem{}
 	for _, column := range columns {
+		detail := columnDetail(tableName)
+		if indexes := dbCache.ColumnIndexes(column); len(indexes) > 0 {
+			detail += " (indexed)"
+		}
 		candidate := lsp.CompletionItem{
 			Label:  column.Name,
 			Kind:   lsp.FieldCompletion,
-			Detail: columnDetail(tableName),
+			Detail: detail,
 			Documentation: lsp.MarkupContent{
 				Kind:  lsp.Markdown,
 				Value: database.ColumnDoc(tableName, column),
@@ -156,6 +218,54 @@ func (c *Completer) TableCandidates(parent *completionParent, targetTables []*pa
 	return candidates
 }
 
+func (c *Completer) ViewCandidates(parent *completionParent, targetTables []*parseutil.TableInfo) []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+
+	switch parent.Type {
+	case ParentTypeNone:
+		views := []*database.ViewDesc{}
+		for _, view := range c.DBCache.SortedViews() {
+			isExclude := false
+			for _, targetTable := range targetTables {
+				if view.Name == targetTable.Name {
+					isExclude = true
+				}
+			}
+			if isExclude {
+				continue
+			}
+			views = append(views, view)
+		}
+		candidates = append(candidates, generateViewCandidates(views, c.DBCache)...)
+	case ParentTypeSchema:
+		views, ok := c.DBCache.SortedViewsByDBName(parent.Name)
+		if ok {
+			candidates = append(candidates, generateViewCandidates(views, c.DBCache)...)
+		}
+	case ParentTypeTable:
+		// pass
+	case ParentTypeSubQuery:
+		// pass
+	}
+	return candidates
+}
+
+func (c *Completer) SequenceCandidates(parent *completionParent) []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	if parent.Type != ParentTypeNone {
+		return candidates
+	}
+	for _, seq := range c.DBCache.SortedSequences() {
+		candidate := lsp.CompletionItem{
+			Label:  seq,
+			Kind:   lsp.ValueCompletion,
+			Detail: "sequence",
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
 func (c *Completer) joinCandidates(lastTable *parseutil.TableInfo,
 	targetTables, allTables []*parseutil.TableInfo,
 	joinOn, lowercaseKeywords bool) []lsp.CompletionItem {
@@ -329,9 +439,10 @@ func generateTableCandidates(tables []string, dbCache *database.DBCache) []lsp.C
 		}
 		cols, ok := dbCache.ColumnDescs(tableName)
 		if ok {
+			indexes, _ := dbCache.TableIndexes(tableName)
 			candidate.Documentation = lsp.MarkupContent{
 				Kind:  lsp.Markdown,
-				Value: database.TableDoc(tableName, cols),
+				Value: database.TableDoc(tableName, tableComment(dbCache, cols), cols, indexes),
 			}
 		}
 		candidates = append(candidates, candidate)
@@ -339,6 +450,28 @@ func generateTableCandidates(tables []string, dbCache *database.DBCache) []lsp.C
 	return candidates
 }
 
+func generateViewCandidates(views []*database.ViewDesc, dbCache *database.DBCache) []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	for _, view := range views {
+		kind := lsp.InterfaceCompletion
+		if view.Materialized {
+			kind = lsp.StructCompletion
+		}
+		cols, _ := dbCache.ColumnDatabase(view.Schema, view.Name)
+		candidate := lsp.CompletionItem{
+			Label:  view.Name,
+			Kind:   kind,
+			Detail: view.Kind(),
+			Documentation: lsp.MarkupContent{
+				Kind:  lsp.Markdown,
+				Value: database.ViewDoc(view, cols),
+			},
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
 func generateTableCandidatesByInfos(tables []*parseutil.TableInfo, dbCache *database.DBCache) []lsp.CompletionItem {
 	candidates := []lsp.CompletionItem{}
 	for _, table := range tables {
@@ -355,9 +488,10 @@ func generateTableCandidatesByInfos(tables []*parseutil.TableInfo, dbCache *data
 		}
 		cols, ok := dbCache.ColumnDescs(table.Name)
 		if ok {
+			indexes, _ := dbCache.TableIndexes(table.Name)
 			candidate.Documentation = lsp.MarkupContent{
 				Kind:  lsp.Markdown,
-				Value: database.TableDoc(table.Name, cols),
+				Value: database.TableDoc(table.Name, tableComment(dbCache, cols), cols, indexes),
 			}
 		}
 		candidates = append(candidates, candidate)
@@ -446,3 +580,10 @@ func (c *Completer) SchemaCandidates() []lsp.CompletionItem {
 	}
 	return candidates
 }
+
+func tableComment(dbCache *database.DBCache, cols []*database.ColumnDesc) string {
+	if len(cols) == 0 {
+		return ""
+	}
+	return dbCache.TableComment(cols[0].Schema, cols[0].Table)
+}
@@ -34,6 +34,8 @@ const (
 	CompletionTypeSchema
 	CompletionTypeJoin
 	CompletionTypeJoinOn
+	CompletionTypeSequence
+	CompletionTypeIndex
 )
 
 func (ct completionType) String() string {
@@ -64,6 +66,10 @@ func (ct completionType) String() string {
 		return "Join clause"
 	case CompletionTypeJoinOn:
 		return "Join On condition"
+	case CompletionTypeSequence:
+		return "Sequence"
+	case CompletionTypeIndex:
+		return "Index"
 	default:
 		return ""
 	}
@@ -105,6 +111,12 @@ func (c *Completer) Complete(text string, params lsp.CompletionParams, lowercase
 	if err != nil {
 		return nil, err
 	}
+	if indexNamePattern.MatchString(getBeforeCursorText(text, params.Position.Line+1, params.Position.Character)) {
+		ctx = &CompletionContext{
+			types:  []completionType{CompletionTypeIndex},
+			parent: noneParent,
+		}
+	}
 
 	definedTables, err := parseutil.ExtractTable(parsed, pos)
 	if err != nil {
@@ -146,6 +158,31 @@ func (c *Completer) Complete(text string, params lsp.CompletionParams, lowercase
 			}
 			items = append(items, candidates...)
 		}
+		if completionTypeIs(ctx.types, CompletionTypeView) {
+			excl := definedTables
+			if completionTypeIs(ctx.types, CompletionTypeJoin) {
+				excl = nil
+			}
+			candidates := c.ViewCandidates(ctx.parent, excl)
+			if withBackQuote {
+				candidates = toQuotedCandidates(candidates)
+			}
+			items = append(items, candidates...)
+		}
+		if completionTypeIs(ctx.types, CompletionTypeSequence) {
+			candidates := c.SequenceCandidates(ctx.parent)
+			if withBackQuote {
+				candidates = toQuotedCandidates(candidates)
+			}
+			items = append(items, candidates...)
+		}
+		if completionTypeIs(ctx.types, CompletionTypeIndex) {
+			candidates := c.IndexCandidates(definedTables)
+			if withBackQuote {
+				candidates = toQuotedCandidates(candidates)
+			}
+			items = append(items, candidates...)
+		}
 		if completionTypeIs(ctx.types, CompletionTypeSchema) {
 			candidates := c.SchemaCandidates()
 			if withBackQuote {
@@ -192,6 +229,9 @@ func (c *Completer) Complete(text string, params lsp.CompletionParams, lowercase
 	if completionTypeIs(ctx.types, CompletionTypeFunction) {
 		drivers := dialect.DataBaseFunctions(c.Driver)
 		items = append(items, c.functionCandidates(lowercaseKeywords, drivers)...)
+		if c.DBCache != nil {
+			items = append(items, c.RoutineCandidates()...)
+		}
 	}
 
 	items = filterCandidates(items, lastWord)
@@ -215,7 +255,7 @@ func getSortTextPrefix(kind lsp.CompletionItemKind) string {
 		return "00"
 	case lsp.FieldCompletion:
 		return "0"
-	case lsp.ClassCompletion:
+	case lsp.ClassCompletion, lsp.InterfaceCompletion, lsp.StructCompletion:
 		return "1"
 	case lsp.ModuleCompletion:
 		return "2"
@@ -230,13 +270,11 @@ func getSortTextPrefix(kind lsp.CompletionItemKind) string {
 		lsp.EventCompletion,
 		lsp.FileCompletion,
 		lsp.FolderCompletion,
-		lsp.InterfaceCompletion,
 		lsp.KeywordCompletion,
 		lsp.MethodCompletion,
 		lsp.OperatorCompletion,
 		lsp.PropertyCompletion,
 		lsp.ReferenceCompletion,
-		lsp.StructCompletion,
 		lsp.TextCompletion,
 		lsp.TypeParameterCompletion,
 		lsp.UnitCompletion,
@@ -301,6 +339,7 @@ func getCompletionTypes(nw *parseutil.NodeWalker) *CompletionContext {
 				CompletionTypeSubQuery,
 				CompletionTypeView,
 				CompletionTypeFunction,
+				CompletionTypeSequence,
 			}
 			p = noneParent
 		}
@@ -328,6 +367,7 @@ func getCompletionTypes(nw *parseutil.NodeWalker) *CompletionContext {
 				CompletionTypeSubQueryColumn,
 				CompletionTypeSubQuery,
 				CompletionTypeFunction,
+				CompletionTypeSequence,
 			}
 		}
 	case syntaxPos == parseutil.TableReference:
@@ -374,6 +414,7 @@ func getCompletionTypes(nw *parseutil.NodeWalker) *CompletionContext {
 				CompletionTypeSubQueryColumn,
 				CompletionTypeSubQuery,
 				CompletionTypeFunction,
+				CompletionTypeSequence,
 			}
 		}
 	case syntaxPos == parseutil.JoinClause:
@@ -431,6 +472,10 @@ func getLine(text string, line int) string {
 	return ""
 }
 
+// indexNamePattern matches the text before an index name, such as
+// "DROP INDEX " or the index hint "USE INDEX (".
+var indexNamePattern = regexp.MustCompile("(?is)(?:\\bDROP\\s+INDEX(?:\\s+CONCURRENTLY)?(?:\\s+IF\\s+EXISTS)?\\s+|\\b(?:USE|FORCE|IGNORE)\\s+INDEX(?:\\s+FOR\\s+(?:JOIN|ORDER\\s+BY|GROUP\\s+BY))?\\s*\\([^()]*?)[\\w`]*$")
+
 func getLastWord(text string, line, char int) string {
 	t := getBeforeCursorText(text, line, char)
 	s := getLine(t, line)
@@ -1,9 +1,13 @@
 package completer
 
 import (
+	"context"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/yaamai/sqls/internal/database"
 	"github.com/yaamai/sqls/internal/lsp"
 )
 
@@ -200,3 +204,261 @@ func TestGenerateAlias(t *testing.T) {
 		})
 	}
 }
+
+func TestCompleteViewsAndSequences(t *testing.T) {
+	repo := database.NewMockDBRepository(nil).(*database.MockDBRepository)
+	repo.MockSchemaViews = func(ctx context.Context) (map[string][]*database.ViewDesc, error) {
+		return map[string][]*database.ViewDesc{
+			"world": {
+				{Schema: "world", Name: "city_view", Definition: "SELECT * FROM city"},
+				{Schema: "world", Name: "city_summary", Materialized: true},
+			},
+		}, nil
+	}
+	repo.MockSchemaSequences = func(ctx context.Context) (map[string][]string, error) {
+		return map[string][]string{"world": {"city_seq"}}, nil
+	}
+	dbCache, err := database.NewDBCacheUpdater(repo).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCompleter(dbCache)
+
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]lsp.CompletionItemKind
+	}{
+		{
+			name:  "views after from",
+			input: "SELECT * FROM city_",
+			want: map[string]lsp.CompletionItemKind{
+				"city_view":    lsp.InterfaceCompletion,
+				"city_summary": lsp.StructCompletion,
+			},
+		},
+		{
+			name:  "sequences in select",
+			input: "SELECT city_",
+			want: map[string]lsp.CompletionItemKind{
+				"city_view":    lsp.InterfaceCompletion,
+				"city_summary": lsp.StructCompletion,
+				"city_seq":     lsp.ValueCompletion,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := lsp.CompletionParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					Position: lsp.Position{Line: 0, Character: len(tt.input)},
+				},
+			}
+			items, err := c.Complete(tt.input, params, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]lsp.CompletionItemKind{}
+			for _, item := range items {
+				if item.Kind == lsp.InterfaceCompletion || item.Kind == lsp.StructCompletion || item.Kind == lsp.ValueCompletion {
+					got[item.Label] = item.Kind
+				}
+			}
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCompleteRoutines(t *testing.T) {
+	repo := database.NewMockDBRepository(nil).(*database.MockDBRepository)
+	repo.MockSchemaRoutines = func(ctx context.Context) (map[string][]*database.RoutineDesc, error) {
+		return map[string][]*database.RoutineDesc{
+			"world": {
+				{Schema: "world", Name: "city_population", Kind: "FUNCTION", ReturnType: "int", Params: []*database.RoutineParam{{Name: "city_id", Type: "int"}}},
+				{Schema: "world", Name: "city_population", Kind: "FUNCTION", ReturnType: "int", Params: []*database.RoutineParam{}},
+			},
+		}, nil
+	}
+	dbCache, err := database.NewDBCacheUpdater(repo).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := "SELECT city_pop"
+	params := lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			Position: lsp.Position{Line: 0, Character: len(input)},
+		},
+	}
+	items, err := NewCompleter(dbCache).Complete(input, params, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("want one candidate, got %v", items)
+	}
+	if items[0].Label != "city_population" || items[0].Kind != lsp.FunctionCompletion || items[0].Detail != "city_population(city_id int) RETURNS int" {
+		t.Errorf("unexpected candidate %+v", items[0])
+	}
+}
+
+func TestCompleteIndexes(t *testing.T) {
+	repo := database.NewMockDBRepository(nil).(*database.MockDBRepository)
+	repo.MockDescribeIndexesBySchema = func(ctx context.Context, schemaName string) ([]*database.IndexDesc, error) {
+		return []*database.IndexDesc{
+			{Schema: "world", Table: "city", Name: "idx_city_countrycode", Columns: []string{"CountryCode"}, Type: "BTREE"},
+			{Schema: "world", Table: "country", Name: "idx_country_name", Columns: []string{"Name"}, Unique: true, Type: "BTREE"},
+		}, nil
+	}
+	dbCache, err := database.NewDBCacheUpdater(repo).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCompleter(dbCache)
+
+	complete := func(t *testing.T, input string) []lsp.CompletionItem {
+		params := lsp.CompletionParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				Position: lsp.Position{Line: 0, Character: len(input)},
+			},
+		}
+		items, err := c.Complete(input, params, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return items
+	}
+	labels := func(items []lsp.CompletionItem) []string {
+		got := []string{}
+		for _, item := range items {
+			got = append(got, item.Label)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"drop index", "DROP INDEX ", []string{"idx_city_countrycode", "idx_country_name"}},
+		{"drop index prefix", "DROP INDEX IF EXISTS idx_ci", []string{"idx_city_countrycode"}},
+		{"use index", "SELECT * FROM city USE INDEX (", []string{"idx_city_countrycode"}},
+		{"force index second", "SELECT * FROM country FORCE INDEX FOR JOIN (PRIMARY, ", []string{"idx_country_name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := labels(complete(t, tt.input)); !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("indexed column detail", func(t *testing.T) {
+		found := false
+		for _, item := range complete(t, "SELECT CountryCode FROM city WHERE city.Country") {
+			if item.Label == "CountryCode" {
+				found = true
+				if item.Detail != `column from "city" (indexed)` {
+					t.Errorf("unexpected detail %q", item.Detail)
+				}
+			}
+		}
+		if !found {
+			t.Error("column candidate not found")
+		}
+	})
+}
+
+func TestCompleteComments(t *testing.T) {
+	repo := database.NewMockDBRepository(nil).(*database.MockDBRepository)
+	repo.MockDescribeDatabaseTableBySchema = func(ctx context.Context, schemaName string) ([]*database.ColumnDesc, error) {
+		return []*database.ColumnDesc{
+			{ColumnBase: database.ColumnBase{Schema: "world", Table: "city", Name: "ID"}, Type: "int(11)", Key: "PRI"},
+			{ColumnBase: database.ColumnBase{Schema: "world", Table: "city", Name: "Name"}, Type: "char(35)"},
+		}, nil
+	}
+	repo.MockDescribeCommentsBySchema = func(ctx context.Context, schemaName string) ([]*database.CommentDesc, error) {
+		return []*database.CommentDesc{
+			{Schema: "world", Table: "city", Comment: "cities of the world"},
+			{Schema: "world", Table: "city", Column: "Name", Comment: "local name"},
+		}, nil
+	}
+	dbCache, err := database.NewDBCacheUpdater(repo).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCompleter(dbCache)
+
+	documentation := func(t *testing.T, input, label string) string {
+		params := lsp.CompletionParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				Position: lsp.Position{Line: 0, Character: len(input)},
+			},
+		}
+		items, err := c.Complete(input, params, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range items {
+			if item.Label == label {
+				return item.Documentation.Value
+			}
+		}
+		t.Fatalf("candidate %q not found", label)
+		return ""
+	}
+
+	if doc := documentation(t, "SELECT * FROM ci", "city"); !strings.Contains(doc, "cities of the world") {
+		t.Errorf("table comment not found in %q", doc)
+	}
+	if doc := documentation(t, "SELECT * FROM city WHERE city.Na", "Name"); !strings.Contains(doc, "local name") {
+		t.Errorf("column comment not found in %q", doc)
+	}
+}
+
+func TestCompleteConcurrentReCache(t *testing.T) {
+	repo := database.NewMockDBRepository(nil)
+	worker := database.NewWorker()
+	worker.Start()
+	defer worker.Stop()
+	ctx := context.Background()
+	if err := worker.ReCache(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				input := "SELECT ci.Name FROM city ci WHERE ci."
+				params := lsp.CompletionParams{
+					TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+						Position: lsp.Position{Line: 0, Character: len(input)},
+					},
+				}
+				items, err := NewCompleter(worker.Cache()).Complete(input, params, false)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if len(items) == 0 {
+					t.Error("no candidates")
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := worker.ReCache(ctx, repo); err != nil {
+			t.Fatal(err)
+		}
+		if err := worker.LoadSchemas(ctx, "sys"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/yaamai/sqls/internal/database"
 	"gopkg.in/yaml.v2"
@@ -20,17 +21,48 @@ var (
 )
 
 type Config struct {
-	LowercaseKeywords bool                 `json:"lowercaseKeywords" yaml:"lowercaseKeywords"`
-	Connections       []*database.DBConfig `json:"connections" yaml:"connections"`
+	LowercaseKeywords bool                   `json:"lowercaseKeywords" yaml:"lowercaseKeywords"`
+	Connections       []*database.DBConfig   `json:"connections" yaml:"connections"`
+	ResultFormat      *database.ResultFormat `json:"resultFormat" yaml:"resultFormat"`
+	MaxLoadedSchemas  int                    `json:"maxLoadedSchemas" yaml:"maxLoadedSchemas"`
+	// OfflineSchema is used for completion when no connection is configured
+	OfflineSchema *database.OfflineSchemaConfig `json:"offlineSchema" yaml:"offlineSchema"`
 }
 
 func (c *Config) Validate() error {
+	if c.MaxLoadedSchemas < 0 {
+		return errors.New("invalid: maxLoadedSchemas")
+	}
+	if c.ResultFormat != nil {
+		if err := c.ResultFormat.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.OfflineSchema != nil {
+		if err := c.OfflineSchema.Validate(); err != nil {
+			return err
+		}
+	}
 	if len(c.Connections) > 0 {
 		return c.Connections[0].Validate()
 	}
 	return nil
 }
 
+// Connection finds the connection config by the alias or the 1-origin index.
+func (c *Config) Connection(aliasOrIndex string) (*database.DBConfig, error) {
+	for _, conn := range c.Connections {
+		if conn.Alias == aliasOrIndex {
+			return conn, nil
+		}
+	}
+	index, err := strconv.Atoi(aliasOrIndex)
+	if err != nil || index <= 0 || index > len(c.Connections) {
+		return nil, fmt.Errorf("not found database connection config, %q", aliasOrIndex)
+	}
+	return c.Connections[index-1], nil
+}
+
 func NewConfig() *Config {
 	cfg := &Config{}
 	cfg.LowercaseKeywords = false
@@ -45,6 +77,16 @@ func GetDefaultConfig() (*Config, error) {
 	return cfg, nil
 }
 
+// ReadDefaultConfig reads the per-user config file without validation, the
+// connections may be completed by a project config merged over it.
+func ReadDefaultConfig() (*Config, error) {
+	cfg := NewConfig()
+	if err := cfg.read(YamlConfigPath); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func GetConfig(fp string) (*Config, error) {
 	cfg := NewConfig()
 	expandPath, err := expand(fp)
@@ -58,6 +100,16 @@ func GetConfig(fp string) (*Config, error) {
 }
 
 func (c *Config) Load(fp string) error {
+	if err := c.read(fp); err != nil {
+		return err
+	}
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("failed validation, %w", err)
+	}
+	return nil
+}
+
+func (c *Config) read(fp string) error {
 	if !IsFileExist(fp) {
 		return ErrNotFoundConfig
 	}
@@ -76,9 +128,8 @@ func (c *Config) Load(fp string) error {
 	if err = yaml.Unmarshal(b, c); err != nil {
 		return fmt.Errorf("failed unmarshal yaml, %w, %s", err, string(b))
 	}
-
-	if err := c.Validate(); err != nil {
-		return fmt.Errorf("failed validation, %w", err)
+	if err := ExpandEnv(c); err != nil {
+		return fmt.Errorf("cannot expand config, %w", err)
 	}
 	return nil
 }
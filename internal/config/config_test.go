@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/yaamai/sqls/internal/database"
 )
 
@@ -208,3 +209,137 @@ func TestGetConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestProjectConfig(t *testing.T) {
+	root := t.TempDir()
+	project := `lowercaseKeywords: true
+connections:
+  - alias: app
+    driver: mysql
+    proto: tcp
+    user: app
+    host: db.example.com
+    port: 3306
+    dbName: app
+    params:
+      parseTime: "true"
+  - alias: local
+    driver: sqlite3
+    dataSourceName: app.db
+`
+	fp := filepath.Join(root, ProjectConfigFileName)
+	if err := os.WriteFile(fp, []byte(project), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "db", "migrations")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := FindProjectConfig(dir)
+	if !ok || got != fp {
+		t.Fatalf("FindProjectConfig() = %q, %v, want %q", got, ok, fp)
+	}
+	p, err := LoadProjectConfig(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &Config{
+		Connections: []*database.DBConfig{
+			{Alias: "personal", Driver: "sqlite3", DataSourceName: "personal.db"},
+			{Alias: "app", Driver: "mysql", Proto: "tcp", User: "me", Passwd: "secret", Host: "127.0.0.1", Port: 13306, Params: map[string]string{"tls": "skip-verify"}},
+		},
+		ResultFormat: &database.ResultFormat{NullString: "NULL"},
+	}
+	want := &Config{
+		LowercaseKeywords: true,
+		Connections: []*database.DBConfig{
+			{Alias: "app", Driver: "mysql", Proto: "tcp", User: "app", Passwd: "secret", Host: "db.example.com", Port: 3306, DBName: "app", Params: map[string]string{"parseTime": "true", "tls": "skip-verify"}},
+			{Alias: "local", Driver: "sqlite3", DataSourceName: "app.db"},
+			{Alias: "personal", Driver: "sqlite3", DataSourceName: "personal.db"},
+		},
+		ResultFormat: &database.ResultFormat{NullString: "NULL"},
+	}
+	merged, err := p.Merge(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, merged, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("unmatched merged config (- want, + got):\n%s", diff)
+	}
+	if user.Connections[1].User != "me" {
+		t.Error("the user config is modified")
+	}
+
+	// the project config works without a user config
+	merged, err = p.Merge(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged.Connections) != 2 || merged.Connections[0].Passwd != "" {
+		t.Errorf("unexpected merged config %+v", merged)
+	}
+
+	if _, ok := FindProjectConfig(t.TempDir()); ok {
+		t.Error("expected no project config")
+	}
+	invalid := filepath.Join(t.TempDir(), ProjectConfigFileName)
+	if err := os.WriteFile(invalid, []byte("connections: {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProjectConfig(invalid); err == nil {
+		t.Error("expected an error for the invalid project config")
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("SQLS_TEST_USER", "app")
+	t.Setenv("SQLS_TEST_PASSWD", "p@ss$word")
+	cfg := &Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver: "mysql",
+				User:   "${SQLS_TEST_USER}",
+				Passwd: "${SQLS_TEST_PASSWD}",
+				Host:   "db-$${SQLS_TEST_USER}",
+				Params: map[string]string{"user": "x${SQLS_TEST_USER}x"},
+				SSHCfg: &database.SSHConfig{PassPhrase: "${SQLS_TEST_PASSWD}"},
+			},
+		},
+		OfflineSchema: &database.OfflineSchemaConfig{Paths: []string{"${SQLS_TEST_USER}/schema.sql"}},
+	}
+	if err := ExpandEnv(cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver: "mysql",
+				User:   "app",
+				Passwd: "p@ss$word",
+				Host:   "db-${SQLS_TEST_USER}",
+				Params: map[string]string{"user": "xappx"},
+				SSHCfg: &database.SSHConfig{PassPhrase: "p@ss$word"},
+			},
+		},
+		OfflineSchema: &database.OfflineSchemaConfig{Paths: []string{"app/schema.sql"}},
+	}
+	if diff := cmp.Diff(want, cfg); diff != "" {
+		t.Errorf("unmatched expanded config (- want, + got):\n%s", diff)
+	}
+
+	raw := map[interface{}]interface{}{
+		"connections": []interface{}{map[interface{}]interface{}{"user": "${SQLS_TEST_USER}", "port": 3306}},
+	}
+	if err := ExpandEnv(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := raw["connections"].([]interface{})[0].(map[interface{}]interface{})["user"]; got != "app" {
+		t.Errorf("unexpected expanded yaml %v", got)
+	}
+
+	if err := ExpandEnv(&Config{Connections: []*database.DBConfig{{Passwd: "${SQLS_TEST_UNSET}"}}}); err == nil {
+		t.Error("expected an error for the unset variable")
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// ExpandEnv replaces ${NAME} in the strings of v with the environment
+// variable NAME, v is a pointer to a config or to parsed YAML. $${NAME} is
+// kept as ${NAME}. An error is returned for an unset variable, so that an
+// empty secret is not used silently.
+func ExpandEnv(v interface{}) error {
+	return expandEnvValue(reflect.ValueOf(v))
+}
+
+func expandEnvValue(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+		s, err := expandEnv(v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return expandEnvValue(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() || !v.CanSet() {
+			return nil
+		}
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		if err := expandEnvValue(elem); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if err := expandEnvValue(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := expandEnvValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// the map values are not addressable, they are expanded in copies
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			if err := expandEnvValue(elem); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, elem)
+		}
+	}
+	return nil
+}
+
+func expandEnv(s string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1])
+			b.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		name := s[i+2 : i+2+end]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable is not set, %s", name)
+		}
+		b.WriteString(s[:i])
+		b.WriteString(value)
+		s = s[i+3+end:]
+	}
+}
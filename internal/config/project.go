@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ProjectConfigFileName is the name of the config file shipped with a
+// repository, it is found in the workspace or its parent directories.
+const ProjectConfigFileName = ".sqls.yml"
+
+// ProjectConfig is a project config file. It is kept as parsed YAML to tell
+// the keys written in the file from the zero values, only the written keys
+// are merged over the user config.
+type ProjectConfig struct {
+	Path string
+	raw  map[interface{}]interface{}
+}
+
+// FindProjectConfig returns the path of the project config file in dir or in
+// the nearest parent directory of dir.
+func FindProjectConfig(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		fp := filepath.Join(dir, ProjectConfigFileName)
+		if info, err := os.Stat(fp); err == nil && !info.IsDir() {
+			return fp, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// LoadProjectConfig reads the project config file. It is not validated
+// alone, the connections may leave the secrets to the user config.
+func LoadProjectConfig(fp string) (*ProjectConfig, error) {
+	b, err := os.ReadFile(fp)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read project config, %w", err)
+	}
+	raw := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("failed unmarshal yaml, %w, %s", err, fp)
+	}
+	// check the types of the values before merging
+	if err := yaml.Unmarshal(b, NewConfig()); err != nil {
+		return nil, fmt.Errorf("failed unmarshal yaml, %w, %s", err, fp)
+	}
+	if err := ExpandEnv(&raw); err != nil {
+		return nil, fmt.Errorf("cannot expand project config, %w", err)
+	}
+	return &ProjectConfig{Path: fp, raw: raw}, nil
+}
+
+// Merge returns the project config merged over base, the keys of the project
+// config override the keys of base. The connections are matched by alias, a
+// connection of both configs takes the keys missing in the project config,
+// such as passwd, from base. The connections of the project config come
+// first, so that its first connection is the default one.
+func (p *ProjectConfig) Merge(base *Config) (*Config, error) {
+	if base == nil {
+		base = NewConfig()
+	}
+	b, err := yaml.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	baseRaw := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(b, &baseRaw); err != nil {
+		return nil, err
+	}
+
+	merged := mergeYAML(baseRaw, p.raw)
+	if conns, ok := p.raw["connections"].([]interface{}); ok {
+		baseConns, _ := baseRaw["connections"].([]interface{})
+		merged["connections"] = mergeConnections(baseConns, conns)
+	}
+
+	b, err = yaml.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	cfg := NewConfig()
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("failed unmarshal yaml, %w, %s", err, p.Path)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("failed validation, %w", err)
+	}
+	return cfg, nil
+}
+
+// mergeYAML merges the mappings of src over dst recursively, the other
+// values of src replace the values of dst. The null values of src are
+// ignored. dst is not modified.
+func mergeYAML(dst, src map[interface{}]interface{}) map[interface{}]interface{} {
+	merged := make(map[interface{}]interface{}, len(dst)+len(src))
+	for k, v := range dst {
+		merged[k] = v
+	}
+	for k, v := range src {
+		if v == nil {
+			continue
+		}
+		srcMap, srcOk := v.(map[interface{}]interface{})
+		dstMap, dstOk := merged[k].(map[interface{}]interface{})
+		if srcOk && dstOk {
+			merged[k] = mergeYAML(dstMap, srcMap)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+func mergeConnections(base, project []interface{}) []interface{} {
+	byAlias := map[string]map[interface{}]interface{}{}
+	for _, c := range base {
+		m, ok := c.(map[interface{}]interface{})
+		if !ok || connectionAlias(m) == "" {
+			continue
+		}
+		if _, ok := byAlias[connectionAlias(m)]; !ok {
+			byAlias[connectionAlias(m)] = m
+		}
+	}
+
+	merged := []interface{}{}
+	used := map[string]struct{}{}
+	for _, c := range project {
+		m, ok := c.(map[interface{}]interface{})
+		if !ok {
+			merged = append(merged, c)
+			continue
+		}
+		alias := connectionAlias(m)
+		if baseConn, ok := byAlias[alias]; ok {
+			m = mergeYAML(baseConn, m)
+			used[alias] = struct{}{}
+		}
+		merged = append(merged, m)
+	}
+	for _, c := range base {
+		if m, ok := c.(map[interface{}]interface{}); ok {
+			if _, ok := used[connectionAlias(m)]; ok {
+				continue
+			}
+		}
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+func connectionAlias(conn map[interface{}]interface{}) string {
+	alias, _ := conn["alias"].(string)
+	return alias
+}
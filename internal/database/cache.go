@@ -2,6 +2,9 @@ package database
 
 import (
 	"context"
+	"errors"
+	"log"
+	"maps"
 	"sort"
 	"strings"
 )
@@ -49,6 +52,21 @@ func (u *DBCacheGenerator) GenerateDBCachePrimary(ctx context.Context) (*DBCache
 		dbCache.SchemaTables[strings.ToUpper(index)] = element
 	}
 
+	// views and sequences are optional, the catalog may not be readable
+	dbCache.SchemaViews, err = u.genViewCache(ctx)
+	if err != nil {
+		log.Println("cannot load views,", err)
+	}
+	dbCache.SchemaSequences, err = u.genSequenceCache(ctx)
+	if err != nil {
+		log.Println("cannot load sequences,", err)
+	}
+	dbCache.excludeViewsFromTables()
+	dbCache.SchemaRoutines, err = u.genRoutineCache(ctx)
+	if err != nil && !errors.Is(err, ErrNotImplementation) {
+		log.Println("cannot load routines,", err)
+	}
+
 	dbCache.ColumnsWithParent, err = u.genColumnCacheCurrent(ctx, dbCache.defaultSchema)
 	if err != nil {
 		return nil, err
@@ -57,6 +75,15 @@ func (u *DBCacheGenerator) GenerateDBCachePrimary(ctx context.Context) (*DBCache
 	if err != nil {
 		return nil, err
 	}
+	dbCache.Indexes, err = u.genIndexCache(ctx, dbCache.defaultSchema)
+	if err != nil && !errors.Is(err, ErrNotImplementation) {
+		log.Println("cannot load indexes,", err)
+	}
+	dbCache.Comments, err = u.genCommentCache(ctx, dbCache.defaultSchema)
+	if err != nil && !errors.Is(err, ErrNotImplementation) {
+		log.Println("cannot load comments,", err)
+	}
+	dbCache.applyComments(dbCache.ColumnsWithParent)
 	return dbCache, nil
 }
 
@@ -64,6 +91,34 @@ func (u *DBCacheGenerator) GenerateDBCacheSecondary(ctx context.Context) (map[st
 	return u.genColumnCacheAll(ctx)
 }
 
+// SchemaCache is the metadata of a schema other than the default schema,
+// which is loaded on first reference.
+type SchemaCache struct {
+	Columns  map[string][]*ColumnDesc
+	Indexes  map[string][]*IndexDesc
+	Comments map[string]string
+	// ForeignKeys is loaded only for the default schema
+	ForeignKeys map[string]map[string][]*ForeignKey
+}
+
+func (u *DBCacheGenerator) GenerateSchemaCache(ctx context.Context, schemaName string) (*SchemaCache, error) {
+	var err error
+	sc := &SchemaCache{}
+	sc.Columns, err = u.genColumnCacheCurrent(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	sc.Indexes, err = u.genIndexCache(ctx, schemaName)
+	if err != nil && !errors.Is(err, ErrNotImplementation) {
+		log.Println("cannot load indexes,", err)
+	}
+	sc.Comments, err = u.genCommentCache(ctx, schemaName)
+	if err != nil && !errors.Is(err, ErrNotImplementation) {
+		log.Println("cannot load comments,", err)
+	}
+	return sc, nil
+}
+
 func (u *DBCacheGenerator) genSchemaCache(ctx context.Context) (map[string]string, error) {
 	dbs, err := u.repo.Schemas(ctx)
 	if err != nil {
@@ -76,6 +131,67 @@ func (u *DBCacheGenerator) genSchemaCache(ctx context.Context) (map[string]strin
 	return databaseMap, nil
 }
 
+func (u *DBCacheGenerator) genViewCache(ctx context.Context) (map[string][]*ViewDesc, error) {
+	schemaViews, err := u.repo.SchemaViews(ctx)
+	if err != nil {
+		return map[string][]*ViewDesc{}, err
+	}
+	viewMap := map[string][]*ViewDesc{}
+	for schema, views := range schemaViews {
+		viewMap[strings.ToUpper(schema)] = views
+	}
+	return viewMap, nil
+}
+
+func (u *DBCacheGenerator) genSequenceCache(ctx context.Context) (map[string][]string, error) {
+	schemaSequences, err := u.repo.SchemaSequences(ctx)
+	if err != nil {
+		return map[string][]string{}, err
+	}
+	sequenceMap := map[string][]string{}
+	for schema, sequences := range schemaSequences {
+		sequenceMap[strings.ToUpper(schema)] = sequences
+	}
+	return sequenceMap, nil
+}
+
+func (u *DBCacheGenerator) genRoutineCache(ctx context.Context) (map[string][]*RoutineDesc, error) {
+	schemaRoutines, err := u.repo.SchemaRoutines(ctx)
+	if err != nil {
+		return map[string][]*RoutineDesc{}, err
+	}
+	routineMap := map[string][]*RoutineDesc{}
+	for schema, routines := range schemaRoutines {
+		routineMap[strings.ToUpper(schema)] = routines
+	}
+	return routineMap, nil
+}
+
+func (u *DBCacheGenerator) genIndexCache(ctx context.Context, schemaName string) (map[string][]*IndexDesc, error) {
+	indexMap := map[string][]*IndexDesc{}
+	indexes, err := u.repo.DescribeIndexesBySchema(ctx, schemaName)
+	if err != nil {
+		return indexMap, err
+	}
+	for _, index := range indexes {
+		key := columnDatabaseKey(index.Schema, index.Table)
+		indexMap[key] = append(indexMap[key], index)
+	}
+	return indexMap, nil
+}
+
+func (u *DBCacheGenerator) genCommentCache(ctx context.Context, schemaName string) (map[string]string, error) {
+	commentMap := map[string]string{}
+	comments, err := u.repo.DescribeCommentsBySchema(ctx, schemaName)
+	if err != nil {
+		return commentMap, err
+	}
+	for _, comment := range comments {
+		commentMap[commentKey(comment.Schema, comment.Table, comment.Column)] = comment.Comment
+	}
+	return commentMap, nil
+}
+
 func (u *DBCacheGenerator) genColumnCacheCurrent(ctx context.Context, schemaName string) (map[string][]*ColumnDesc, error) {
 	columnDescs, err := u.repo.DescribeDatabaseTableBySchema(ctx, schemaName)
 	if err != nil {
@@ -133,6 +249,192 @@ type DBCache struct {
 	SchemaTables      map[string][]string
 	ColumnsWithParent map[string][]*ColumnDesc
 	ForeignKeys       map[string]map[string][]*ForeignKey
+	SchemaViews       map[string][]*ViewDesc
+	SchemaSequences   map[string][]string
+	SchemaRoutines    map[string][]*RoutineDesc
+	Indexes           map[string][]*IndexDesc
+	// Comments is keyed by schema, table and column, the column is empty
+	// for the comment on the table
+	Comments map[string]string
+}
+
+// applyComments sets the cached comments to the columns. It must be called
+// again when the columns are replaced.
+func (dc *DBCache) applyComments(columns map[string][]*ColumnDesc) {
+	if len(dc.Comments) == 0 {
+		return
+	}
+	for _, cols := range columns {
+		for _, col := range cols {
+			if comment, ok := dc.Comments[commentKey(col.Schema, col.Table, col.Name)]; ok {
+				col.Comment = comment
+			}
+		}
+	}
+}
+
+// loadedSchemas returns the schemas having columns in the cache, except for
+// the default schema.
+func (dc *DBCache) loadedSchemas() []string {
+	seen := map[string]struct{}{}
+	schemas := []string{}
+	for key := range dc.ColumnsWithParent {
+		schemaName, _, _ := strings.Cut(key, "\t")
+		if _, ok := seen[schemaName]; ok || schemaName == strings.ToUpper(dc.defaultSchema) {
+			continue
+		}
+		seen[schemaName] = struct{}{}
+		schemas = append(schemas, schemaName)
+	}
+	sort.Strings(schemas)
+	return schemas
+}
+
+// withSchema returns a copy of the cache including the schema. The receiver
+// is not modified, it may be read concurrently.
+func (dc *DBCache) withSchema(sc *SchemaCache) *DBCache {
+	next := *dc
+	next.ColumnsWithParent = maps.Clone(dc.ColumnsWithParent)
+	next.Indexes = maps.Clone(dc.Indexes)
+	next.Comments = maps.Clone(dc.Comments)
+	if next.ColumnsWithParent == nil {
+		next.ColumnsWithParent = map[string][]*ColumnDesc{}
+	}
+	if next.Indexes == nil {
+		next.Indexes = map[string][]*IndexDesc{}
+	}
+	if next.Comments == nil {
+		next.Comments = map[string]string{}
+	}
+	maps.Copy(next.ColumnsWithParent, sc.Columns)
+	maps.Copy(next.Indexes, sc.Indexes)
+	maps.Copy(next.Comments, sc.Comments)
+	next.applyComments(sc.Columns)
+	return &next
+}
+
+// withTables returns a copy of the cache in which the tables of the schema are
+// replaced with the ones in the schema cache. A table missing in the schema
+// cache is removed.
+func (dc *DBCache) withTables(schemaName string, tableNames []string, sc *SchemaCache) *DBCache {
+	next := *dc
+	next.ColumnsWithParent = maps.Clone(dc.ColumnsWithParent)
+	next.Indexes = maps.Clone(dc.Indexes)
+	next.Comments = maps.Clone(dc.Comments)
+	next.SchemaTables = maps.Clone(dc.SchemaTables)
+	if next.ColumnsWithParent == nil {
+		next.ColumnsWithParent = map[string][]*ColumnDesc{}
+	}
+	if next.Indexes == nil {
+		next.Indexes = map[string][]*IndexDesc{}
+	}
+	if next.Comments == nil {
+		next.Comments = map[string]string{}
+	}
+	if next.SchemaTables == nil {
+		next.SchemaTables = map[string][]string{}
+	}
+	if sc.ForeignKeys != nil {
+		next.ForeignKeys = sc.ForeignKeys
+	}
+
+	schemaKey := strings.ToUpper(schemaName)
+	tables := []string{}
+	for _, table := range next.SchemaTables[schemaKey] {
+		changed := false
+		for _, tableName := range tableNames {
+			if strings.EqualFold(table, tableName) {
+				changed = true
+			}
+		}
+		if !changed {
+			tables = append(tables, table)
+		}
+	}
+	for _, tableName := range tableNames {
+		key := columnDatabaseKey(schemaName, tableName)
+		delete(next.ColumnsWithParent, key)
+		delete(next.Indexes, key)
+		maps.DeleteFunc(next.Comments, func(k string, _ string) bool { return strings.HasPrefix(k, key+"\t") })
+
+		cols, ok := sc.Columns[key]
+		if !ok || len(cols) == 0 {
+			continue
+		}
+		next.ColumnsWithParent[key] = cols
+		if indexes, ok := sc.Indexes[key]; ok {
+			next.Indexes[key] = indexes
+		}
+		for k, comment := range sc.Comments {
+			if strings.HasPrefix(k, key+"\t") {
+				next.Comments[k] = comment
+			}
+		}
+		exists := false
+		for _, table := range tables {
+			if table == cols[0].Table {
+				exists = true
+			}
+		}
+		if !exists {
+			tables = append(tables, cols[0].Table)
+		}
+	}
+	next.SchemaTables[schemaKey] = tables
+	next.applyComments(sc.Columns)
+	return &next
+}
+
+// withoutSchemas returns a copy of the cache without the metadata of the
+// schemas loaded by withSchema.
+func (dc *DBCache) withoutSchemas(schemaNames ...string) *DBCache {
+	prefixes := make([]string, len(schemaNames))
+	for i, schemaName := range schemaNames {
+		prefixes[i] = columnDatabaseKey(schemaName, "")
+	}
+	inSchemas := func(key string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	next := *dc
+	next.ColumnsWithParent = maps.Clone(dc.ColumnsWithParent)
+	maps.DeleteFunc(next.ColumnsWithParent, func(key string, _ []*ColumnDesc) bool { return inSchemas(key) })
+	next.Indexes = maps.Clone(dc.Indexes)
+	maps.DeleteFunc(next.Indexes, func(key string, _ []*IndexDesc) bool { return inSchemas(key) })
+	next.Comments = maps.Clone(dc.Comments)
+	maps.DeleteFunc(next.Comments, func(key string, _ string) bool { return inSchemas(key) })
+	return &next
+}
+
+// TableComment returns the comment on the table.
+func (dc *DBCache) TableComment(dbName, tableName string) string {
+	return dc.Comments[commentKey(dbName, tableName, "")]
+}
+
+// excludeViewsFromTables removes views from the table listing, some catalogs
+// list tables and views together.
+func (dc *DBCache) excludeViewsFromTables() {
+	for schema, views := range dc.SchemaViews {
+		tables, ok := dc.SchemaTables[schema]
+		if !ok {
+			continue
+		}
+		viewNames := map[string]struct{}{}
+		for _, view := range views {
+			viewNames[view.Name] = struct{}{}
+		}
+		filtered := []string{}
+		for _, table := range tables {
+			if _, ok := viewNames[table]; !ok {
+				filtered = append(filtered, table)
+			}
+		}
+		dc.SchemaTables[schema] = filtered
+	}
 }
 
 func (dc *DBCache) Database(dbName string) (db string, ok bool) {
@@ -150,7 +452,9 @@ func (dc *DBCache) SortedSchemas() []string {
 }
 
 func (dc *DBCache) SortedTablesByDBName(dbName string) (tbls []string, ok bool) {
+	// sort a copy, the cache is shared by concurrent readers
 	tbls, ok = dc.SchemaTables[strings.ToUpper(dbName)]
+	tbls = append([]string(nil), tbls...)
 	sort.Strings(tbls)
 	return
 }
@@ -186,3 +490,96 @@ func (dc *DBCache) Column(tableName, colName string) (*ColumnDesc, bool) {
 func columnDatabaseKey(dbName, tableName string) string {
 	return strings.ToUpper(dbName) + "\t" + strings.ToUpper(tableName)
 }
+
+func (dc *DBCache) SortedViews() []*ViewDesc {
+	views, _ := dc.SortedViewsByDBName(dc.defaultSchema)
+	return views
+}
+
+func (dc *DBCache) SortedViewsByDBName(dbName string) (views []*ViewDesc, ok bool) {
+	views, ok = dc.SchemaViews[strings.ToUpper(dbName)]
+	views = append([]*ViewDesc(nil), views...)
+	sort.Slice(views, func(i, j int) bool { return views[i].Name < views[j].Name })
+	return
+}
+
+// View finds a view of the default schema.
+func (dc *DBCache) View(viewName string) (*ViewDesc, bool) {
+	return dc.ViewByDBName(dc.defaultSchema, viewName)
+}
+
+func (dc *DBCache) ViewByDBName(dbName, viewName string) (*ViewDesc, bool) {
+	for _, view := range dc.SchemaViews[strings.ToUpper(dbName)] {
+		if strings.EqualFold(view.Name, viewName) {
+			return view, true
+		}
+	}
+	return nil, false
+}
+
+func (dc *DBCache) SortedSequences() []string {
+	seqs, _ := dc.SortedSequencesByDBName(dc.defaultSchema)
+	return seqs
+}
+
+func (dc *DBCache) SortedSequencesByDBName(dbName string) (seqs []string, ok bool) {
+	seqs, ok = dc.SchemaSequences[strings.ToUpper(dbName)]
+	seqs = append([]string(nil), seqs...)
+	sort.Strings(seqs)
+	return
+}
+
+func (dc *DBCache) SortedRoutines() []*RoutineDesc {
+	routines := append([]*RoutineDesc{}, dc.SchemaRoutines[strings.ToUpper(dc.defaultSchema)]...)
+	sort.SliceStable(routines, func(i, j int) bool { return routines[i].Name < routines[j].Name })
+	return routines
+}
+
+// Routines finds the overloads of a routine of the default schema.
+func (dc *DBCache) Routines(routineName string) ([]*RoutineDesc, bool) {
+	return dc.RoutinesByDBName(dc.defaultSchema, routineName)
+}
+
+func (dc *DBCache) RoutinesByDBName(dbName, routineName string) ([]*RoutineDesc, bool) {
+	routines := []*RoutineDesc{}
+	for _, routine := range dc.SchemaRoutines[strings.ToUpper(dbName)] {
+		if strings.EqualFold(routine.Name, routineName) {
+			routines = append(routines, routine)
+		}
+	}
+	return routines, len(routines) > 0
+}
+
+func (dc *DBCache) TableIndexes(tableName string) ([]*IndexDesc, bool) {
+	return dc.IndexesByDBName(dc.defaultSchema, tableName)
+}
+
+func (dc *DBCache) IndexesByDBName(dbName, tableName string) (indexes []*IndexDesc, ok bool) {
+	indexes, ok = dc.Indexes[columnDatabaseKey(dbName, tableName)]
+	return
+}
+
+// ColumnIndexes returns the indexes having the column as a key column.
+func (dc *DBCache) ColumnIndexes(col *ColumnDesc) []*IndexDesc {
+	indexes := []*IndexDesc{}
+	for _, index := range dc.Indexes[columnDatabaseKey(col.Schema, col.Table)] {
+		if index.HasColumn(col.Name) {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
+func (dc *DBCache) SortedIndexes() []*IndexDesc {
+	indexes := []*IndexDesc{}
+	for _, tableIndexes := range dc.Indexes {
+		indexes = append(indexes, tableIndexes...)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		if indexes[i].Name != indexes[j].Name {
+			return indexes[i].Name < indexes[j].Name
+		}
+		return indexes[i].Table < indexes[j].Table
+	})
+	return indexes
+}
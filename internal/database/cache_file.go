@@ -0,0 +1,96 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yaamai/sqls/dialect"
+)
+
+// dbCacheFileVersion is increased when the layout of DBCache changes, older
+// cache files are ignored.
+const dbCacheFileVersion = 1
+
+type dbCacheFile struct {
+	Version       int      `json:"version"`
+	DefaultSchema string   `json:"defaultSchema"`
+	Cache         *DBCache `json:"cache"`
+}
+
+// SchemaCacheFile returns the path of the cache file for the connection. The
+// path is derived from the connection settings except for the secrets. An
+// empty path is returned for the mock and schemafile drivers which have
+// nothing to cache.
+func SchemaCacheFile(connCfg *DBConfig) (string, error) {
+	if connCfg.Driver == "mock" || connCfg.Driver == dialect.DatabaseDriverSchemaFile {
+		return "", nil
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	key := *connCfg
+	key.Passwd = ""
+	if connCfg.SSHCfg != nil {
+		sshCfg := *connCfg.SSHCfg
+		sshCfg.PassPhrase = ""
+		key.SSHCfg = &sshCfg
+	}
+	b, err := json.Marshal(&key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return filepath.Join(cacheDir, "sqls", "schema", hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// LoadDBCache reads the cache saved by SaveDBCache.
+func LoadDBCache(path string) (*DBCache, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f dbCacheFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("invalid schema cache file %s, %w", path, err)
+	}
+	if f.Version != dbCacheFileVersion || f.Cache == nil {
+		return nil, fmt.Errorf("unsupported schema cache file %s, version %d", path, f.Version)
+	}
+	f.Cache.defaultSchema = f.DefaultSchema
+	return f.Cache, nil
+}
+
+// SaveDBCache writes the cache to the path. The file is replaced atomically
+// so that a concurrent reader never sees a partial file.
+func SaveDBCache(path string, dbCache *DBCache) error {
+	b, err := json.Marshal(&dbCacheFile{
+		Version:       dbCacheFileVersion,
+		DefaultSchema: dbCache.defaultSchema,
+		Cache:         dbCache,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSaveLoadDBCache(t *testing.T) {
+	repo := NewMockDBRepository(nil)
+	want, err := NewDBCacheUpdater(repo).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "schema", "cache.json")
+	if err := SaveDBCache(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadDBCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(want, got, cmp.AllowUnexported(DBCache{})); d != "" {
+		t.Errorf("unmatched cache (- want, + got):\n%s", d)
+	}
+}
+
+func TestSchemaCacheFile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	cfg := &DBConfig{Driver: "postgresql", Host: "localhost", User: "postgres", Passwd: "secret"}
+	path, err := SchemaCacheFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the password does not identify the connection
+	other := *cfg
+	other.Passwd = "changed"
+	if otherPath, _ := SchemaCacheFile(&other); otherPath != path {
+		t.Errorf("unexpected cache file %s, want %s", otherPath, path)
+	}
+	other.DBName = "world"
+	if otherPath, _ := SchemaCacheFile(&other); otherPath == path {
+		t.Errorf("unexpected same cache file for another database %s", otherPath)
+	}
+	if path, _ := SchemaCacheFile(&DBConfig{Driver: "mock"}); path != "" {
+		t.Errorf("unexpected cache file for mock driver %s", path)
+	}
+}
+
+func TestWorkerReCacheWithFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	stale := &DBCache{
+		defaultSchema: "world",
+		Schemas:       map[string]string{"WORLD": "world"},
+		SchemaTables:  map[string][]string{"WORLD": {"stale_table"}},
+	}
+	if err := SaveDBCache(path, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	refreshed := make(chan struct{})
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	schemaTables := repo.MockDatabaseTables
+	repo.MockDatabaseTables = func(ctx context.Context) (map[string][]string, error) {
+		// hold the refresh until the saved cache is checked
+		<-refreshed
+		return schemaTables(ctx)
+	}
+
+	worker := NewWorker()
+	worker.Start()
+	defer worker.Stop()
+	if err := worker.ReCacheWithFile(context.Background(), repo, path); err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]string{"stale_table"}, worker.Cache().SortedTables()); d != "" {
+		t.Errorf("unmatched tables of the saved cache (- want, + got):\n%s", d)
+	}
+	close(refreshed)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		saved, err := LoadDBCache(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := saved.ColumnDescs("city"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("refreshed cache was not saved")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := worker.Cache().ColumnDescs("city"); !ok {
+		t.Error("refreshed cache was not swapped")
+	}
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerateDBCachePrimaryViews(t *testing.T) {
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	repo.MockDatabaseTables = func(ctx context.Context) (map[string][]string, error) {
+		return map[string][]string{"world": {"city", "city_view", "country"}}, nil
+	}
+	repo.MockSchemaViews = func(ctx context.Context) (map[string][]*ViewDesc, error) {
+		return map[string][]*ViewDesc{
+			"world": {
+				{Schema: "world", Name: "city_view", Definition: "SELECT * FROM city"},
+				{Schema: "world", Name: "big_city", Materialized: true},
+			},
+		}, nil
+	}
+	repo.MockSchemaSequences = func(ctx context.Context) (map[string][]string, error) {
+		return map[string][]string{"world": {"city_seq", "country_seq"}}, nil
+	}
+
+	dbCache, err := NewDBCacheUpdater(repo).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]string{"city", "country"}, dbCache.SortedTables()); d != "" {
+		t.Errorf("unmatched tables (- want, + got):\n%s", d)
+	}
+	views := []string{}
+	for _, view := range dbCache.SortedViews() {
+		views = append(views, view.Name)
+	}
+	if d := cmp.Diff([]string{"big_city", "city_view"}, views); d != "" {
+		t.Errorf("unmatched views (- want, + got):\n%s", d)
+	}
+	if view, ok := dbCache.View("CITY_VIEW"); !ok || view.Definition != "SELECT * FROM city" {
+		t.Errorf("view not found, %v", view)
+	}
+	if d := cmp.Diff([]string{"city_seq", "country_seq"}, dbCache.SortedSequences()); d != "" {
+		t.Errorf("unmatched sequences (- want, + got):\n%s", d)
+	}
+}
+
+func TestViewDoc(t *testing.T) {
+	view := &ViewDesc{Name: "big_city", Materialized: true, Definition: " SELECT * FROM city "}
+	want := "# `big_city` materialized view\n\n\n```sql\nSELECT * FROM city\n```\n"
+	if d := cmp.Diff(want, ViewDoc(view, nil)); d != "" {
+		t.Errorf("unmatched doc (- want, + got):\n%s", d)
+	}
+}
+
+func TestSQLite3SchemaViews(t *testing.T) {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	for _, q := range []string{
+		"CREATE TABLE city (id INTEGER PRIMARY KEY, population BIGINT)",
+		"CREATE VIEW big_city AS SELECT * FROM city WHERE population > 1000000",
+	} {
+		if _, err := conn.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := NewSQLite3DBRepository(conn).SchemaViews(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]*ViewDesc{
+		"": {{Name: "big_city", Definition: "CREATE VIEW big_city AS SELECT * FROM city WHERE population > 1000000"}},
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unmatched views (- want, + got):\n%s", d)
+	}
+}
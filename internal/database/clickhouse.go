@@ -348,6 +348,71 @@ func (db *clickhouseSQLDBRepository) Query(ctx context.Context, query string) (*
 	return db.Conn.QueryContext(ctx, query)
 }
 
+func (db *clickhouseSQLDBRepository) Explain(ctx context.Context, query string) (*PlanNode, error) {
+	return nil, ErrNotImplementation
+}
+
+func (db *clickhouseSQLDBRepository) ShowCreateTable(ctx context.Context, schemaName, tableName string) (string, error) {
+	return showCreateTable(ctx, db.Conn, qualifiedTableName(schemaName, tableName, dialect.DatabaseDriverClickhouse))
+}
+
+func (db *clickhouseSQLDBRepository) SchemaViews(ctx context.Context) (map[string][]*ViewDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+    SELECT database, name, as_select, if(engine = 'MaterializedView', 'MATERIALIZED VIEW', 'VIEW')
+      FROM system.tables
+     WHERE engine IN ('View', 'MaterializedView', 'LiveView')
+     ORDER BY database, name
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaViews(rows)
+}
+
+func (db *clickhouseSQLDBRepository) SchemaSequences(ctx context.Context) (map[string][]string, error) {
+	// ClickHouse has no sequence
+	return map[string][]string{}, nil
+}
+
+func (db *clickhouseSQLDBRepository) SchemaRoutines(ctx context.Context) (map[string][]*RoutineDesc, error) {
+	return nil, ErrNotImplementation
+}
+
+func (db *clickhouseSQLDBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+    SELECT database, table, name, 'NO', type, expr
+      FROM system.data_skipping_indices
+     WHERE database = ?
+     ORDER BY table, name
+    `, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseIndexes(rows)
+}
+
+func (db *clickhouseSQLDBRepository) DescribeCommentsBySchema(ctx context.Context, schemaName string) ([]*CommentDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+    SELECT database, table, name, comment
+      FROM system.columns
+     WHERE database = ?
+       AND comment != ''
+    `, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseComments(rows)
+}
+
 func (db *clickhouseSQLDBRepository) SchemaTables(ctx context.Context) (map[string][]string, error) {
 	rows, err := db.Conn.QueryContext(
 		ctx,
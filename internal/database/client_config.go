@@ -0,0 +1,294 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// The files of the database clients are read to complete DBConfig, so that
+// the connections already configured for psql, mysql or sqlplus are reused.
+
+// optionGroups are the [group] sections of an INI style option file, the
+// options before the first section are in the "" group.
+type optionGroups map[string]map[string]string
+
+// parseOptionFile parses the INI style option files, pg_service.conf and
+// my.cnf. The lines starting with # or ; are comments, and the lines starting
+// with ! such as !include of my.cnf are ignored. The quotes around the values
+// are removed if unquote is set.
+func parseOptionFile(b []byte, unquote bool) optionGroups {
+	groups := optionGroups{}
+	group := ""
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' || line[0] == '!' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			group = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		key, value, _ := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if unquote && len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if groups[group] == nil {
+			groups[group] = map[string]string{}
+		}
+		groups[group][key] = value
+	}
+	return groups
+}
+
+func readOptionFile(path string, unquote bool) (optionGroups, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseOptionFile(b, unquote), nil
+}
+
+// pgUserFile returns the path of a per-user file of libpq, it is in the home
+// directory on Unix and in %APPDATA%\postgresql on Windows.
+func pgUserFile(env, unixName, windowsName string) (string, error) {
+	if path := os.Getenv(env); path != "" {
+		return path, nil
+	}
+	if runtime.GOOS == "windows" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "postgresql", windowsName), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, unixName), nil
+}
+
+// pgServiceParams returns the connection parameters of the service in the
+// connection service file of PostgreSQL. The per-user file, PGSERVICEFILE or
+// ~/.pg_service.conf, is searched before the system file in PGSYSCONFDIR.
+func pgServiceParams(service string) (map[string]string, error) {
+	paths := []string{}
+	if path, err := pgUserFile("PGSERVICEFILE", ".pg_service.conf", ".pg_service.conf"); err == nil {
+		paths = append(paths, path)
+	}
+	if dir := os.Getenv("PGSYSCONFDIR"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "pg_service.conf"))
+	}
+	for _, path := range paths {
+		groups, err := readOptionFile(path, false)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read connection service file, %w", err)
+		}
+		if params, ok := groups[service]; ok {
+			return params, nil
+		}
+	}
+	return nil, fmt.Errorf("service not found in the connection service files, %q", service)
+}
+
+// pgPassword returns the password of the first line of the password file of
+// PostgreSQL, PGPASSFILE or ~/.pgpass, matching the connection. The file is
+// ignored if others can read it, as libpq does.
+func pgPassword(host, port, dbname, user string) (string, bool) {
+	path, err := pgUserFile("PGPASSFILE", ".pgpass", "pgpass.conf")
+	if err != nil {
+		return "", false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		log.Printf("password file %s has group or world access, permissions should be u=rw (0600) or less", path)
+		return "", false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	// a Unix-domain socket is matched by localhost
+	if host == "" || strings.HasPrefix(host, "/") {
+		host = "localhost"
+	}
+	want := []string{host, port, dbname, user}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := splitPgPassLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+		matched := true
+		for i, v := range want {
+			if fields[i] != "*" && fields[i] != v {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return fields[4], true
+		}
+	}
+	return "", false
+}
+
+// splitPgPassLine splits the hostname:port:database:username:password line,
+// \: and \\ are a colon and a backslash in the fields.
+func splitPgPassLine(line string) []string {
+	fields := []string{}
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line):
+			i++
+			b.WriteByte(line[i])
+		case c == ':' && len(fields) < 4:
+			fields = append(fields, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return append(fields, b.String())
+}
+
+// myCnfFiles are the MySQL option files read in order, the later files
+// override the earlier ones.
+func myCnfFiles() []string {
+	paths := []string{"/etc/my.cnf", "/etc/mysql/my.cnf"}
+	if dir := os.Getenv("MYSQL_HOME"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "my.cnf"))
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(homeDir, ".my.cnf"))
+	}
+	return paths
+}
+
+// myCnfOptions returns the options of the [client] group and then the group
+// in the MySQL option files. The dashes and underscores of the option names
+// are the same, the names are returned with underscores.
+func myCnfOptions(group string) (map[string]string, error) {
+	options := map[string]string{}
+	found := false
+	for _, path := range myCnfFiles() {
+		groups, err := readOptionFile(path, true)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read option file, %w", err)
+		}
+		for _, name := range []string{"client", group} {
+			for k, v := range groups[name] {
+				options[strings.ReplaceAll(k, "-", "_")] = v
+			}
+		}
+		if _, ok := groups[group]; ok {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("option group not found in the option files, %q", group)
+	}
+	return options, nil
+}
+
+// tnsnamesFile returns the path of tnsnames.ora in TNS_ADMIN or in the
+// network/admin directory of ORACLE_HOME.
+func tnsnamesFile() (string, error) {
+	if dir := os.Getenv("TNS_ADMIN"); dir != "" {
+		return filepath.Join(dir, "tnsnames.ora"), nil
+	}
+	if dir := os.Getenv("ORACLE_HOME"); dir != "" {
+		return filepath.Join(dir, "network", "admin", "tnsnames.ora"), nil
+	}
+	return "", errors.New("cannot find tnsnames.ora, set TNS_ADMIN or ORACLE_HOME")
+}
+
+// tnsDescriptor returns the connect descriptor of the net service name in
+// tnsnames.ora, the names are case insensitive.
+func tnsDescriptor(alias string) (string, error) {
+	path, err := tnsnamesFile()
+	if err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read tnsnames.ora, %w", err)
+	}
+	descriptors := parseTnsnames(string(b))
+	if descriptor, ok := descriptors[strings.ToUpper(alias)]; ok {
+		return descriptor, nil
+	}
+	return "", fmt.Errorf("net service name not found in %s, %q", path, alias)
+}
+
+// parseTnsnames returns the connect descriptors of tnsnames.ora keyed by the
+// upper cased net service names, "a, b = (DESCRIPTION = ...)" gives the
+// descriptor to both names. The whitespaces of the descriptors are collapsed
+// into single spaces.
+func parseTnsnames(text string) map[string]string {
+	descriptors := map[string]string{}
+	var stripped strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		stripped.WriteString(line)
+		stripped.WriteByte('\n')
+	}
+	text = stripped.String()
+
+	for text != "" {
+		names, rest, ok := strings.Cut(text, "=")
+		if !ok {
+			break
+		}
+		rest = strings.TrimLeft(rest, " \t\r\n")
+		if !strings.HasPrefix(rest, "(") {
+			break
+		}
+		depth, end := 0, -1
+		for i := 0; i < len(rest) && end < 0; i++ {
+			switch rest[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					end = i + 1
+				}
+			}
+		}
+		if end < 0 {
+			break
+		}
+		descriptor := strings.Join(strings.Fields(rest[:end]), " ")
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				descriptors[strings.ToUpper(name)] = descriptor
+			}
+		}
+		text = rest[end:]
+	}
+	return descriptors
+}
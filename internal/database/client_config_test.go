@@ -0,0 +1,151 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeClientFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_genPostgresConfigService(t *testing.T) {
+	dir := t.TempDir()
+	serviceFile := filepath.Join(dir, "pg_service.conf")
+	writeClientFile(t, serviceFile, `# services
+[prod]
+host=db.example.com
+port=6432
+dbname=app
+user=app
+sslmode=require
+`)
+	passFile := filepath.Join(dir, "pgpass")
+	writeClientFile(t, passFile, `# hostname:port:database:username:password
+other.example.com:*:*:app:other
+db.example.com:6432:app:app:pa\:ss
+*:*:*:*:fallback
+`)
+	t.Setenv("PGSERVICEFILE", serviceFile)
+	t.Setenv("PGPASSFILE", passFile)
+	t.Setenv("PGSYSCONFDIR", "")
+
+	tests := []struct {
+		name    string
+		connCfg *DBConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "service and pgpass",
+			connCfg: &DBConfig{Driver: "postgresql", Service: "prod"},
+			want:    "dbname=app host=db.example.com password=pa:ss port=6432 sslmode=require user=app",
+		},
+		{
+			name:    "config overrides service",
+			connCfg: &DBConfig{Driver: "postgresql", Service: "prod", Proto: "tcp", DBName: "report", Passwd: "secret", Params: map[string]string{"sslmode": "disable"}},
+			want:    "dbname=report host=db.example.com password=secret port=6432 sslmode=disable user=app",
+		},
+		{
+			name:    "pgpass without service",
+			connCfg: &DBConfig{Driver: "postgresql", Proto: "unix", User: "postgres", Path: "/var/run/postgresql"},
+			want:    "host=/var/run/postgresql password=fallback user=postgres",
+		},
+		{
+			name:    "missing service",
+			connCfg: &DBConfig{Driver: "postgresql", Service: "missing"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := genPostgresConfig(tt.connCfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("genPostgresConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(passFile, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := pgPassword("db.example.com", "6432", "app", "app"); ok {
+			t.Error("the password file readable by others is used")
+		}
+	}
+}
+
+func Test_genMysqlConfigService(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("MYSQL_HOME", "")
+	writeClientFile(t, filepath.Join(home, ".my.cnf"), `[client]
+user = me
+password = "client secret"
+
+[sqls_test_prod]
+host=db.example.com
+port=13306
+database=app
+
+[sqls_test_socket]
+socket=/run/mysqld/mysqld.sock
+`)
+
+	cfg, err := genMysqlConfig(&DBConfig{Driver: "mysql", Service: "sqls_test_prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.User != "me" || cfg.Passwd != "client secret" || cfg.Net != "tcp" || cfg.Addr != "db.example.com:13306" || cfg.DBName != "app" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	cfg, err = genMysqlConfig(&DBConfig{Driver: "mysql", Service: "sqls_test_socket", User: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.User != "admin" || cfg.Net != "unix" || cfg.Addr != "/run/mysqld/mysqld.sock" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if _, err := genMysqlConfig(&DBConfig{Driver: "mysql", Service: "sqls_test_missing"}); err == nil {
+		t.Error("expected an error for the missing option group")
+	}
+}
+
+func Test_genOracleConfigService(t *testing.T) {
+	dir := t.TempDir()
+	writeClientFile(t, filepath.Join(dir, "tnsnames.ora"), `# net services
+ORCL, orcl.world =
+  (DESCRIPTION =
+    (ADDRESS = (PROTOCOL = TCP)(HOST = db.example.com)(PORT = 1521))
+    (CONNECT_DATA = (SERVICE_NAME = orclpdb1))
+  )
+REPORT=(DESCRIPTION=(ADDRESS=(PROTOCOL=TCP)(HOST=report.example.com)(PORT=1522))(CONNECT_DATA=(SID=rpt)))
+`)
+	t.Setenv("TNS_ADMIN", dir)
+
+	got, err := genOracleConfig(&DBConfig{Driver: "oracle", Service: "orcl.world", User: "scott", Passwd: "tiger"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "scott/tiger@(DESCRIPTION = (ADDRESS = (PROTOCOL = TCP)(HOST = db.example.com)(PORT = 1521)) (CONNECT_DATA = (SERVICE_NAME = orclpdb1)) )"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unmatched dsn (- want, + got):\n%s", diff)
+	}
+	if _, err := genOracleConfig(&DBConfig{Driver: "oracle", Service: "report", User: "scott", Passwd: "tiger"}); err != nil {
+		t.Error(err)
+	}
+	if _, err := genOracleConfig(&DBConfig{Driver: "oracle", Service: "missing", User: "scott", Passwd: "tiger"}); err == nil {
+		t.Error("expected an error for the missing net service name")
+	}
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// CommentDesc is a comment on a table or a column.
+type CommentDesc struct {
+	Schema string
+	Table  string
+	// Column is empty for the comment on the table
+	Column  string
+	Comment string
+}
+
+// parseComments reads rows of schema, table, column and comment. The column
+// is empty or NULL for the comment on the table.
+func parseComments(rows *sql.Rows) ([]*CommentDesc, error) {
+	comments := []*CommentDesc{}
+	for rows.Next() {
+		var schema, table, column, comment sql.NullString
+		if err := rows.Scan(&schema, &table, &column, &comment); err != nil {
+			return nil, err
+		}
+		if comment.String == "" {
+			continue
+		}
+		comments = append(comments, &CommentDesc{
+			Schema:  schema.String,
+			Table:   table.String,
+			Column:  column.String,
+			Comment: comment.String,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func commentKey(schemaName, tableName, colName string) string {
+	return columnDatabaseKey(schemaName, tableName) + "\t" + strings.ToUpper(colName)
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerateDBCachePrimaryComments(t *testing.T) {
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	repo.MockDescribeDatabaseTableBySchema = func(ctx context.Context, schemaName string) ([]*ColumnDesc, error) {
+		return []*ColumnDesc{
+			{ColumnBase: ColumnBase{Schema: schemaName, Table: "city", Name: "ID"}, Type: "int(11)", Key: "PRI"},
+			{ColumnBase: ColumnBase{Schema: schemaName, Table: "city", Name: "Name"}, Type: "char(35)"},
+		}, nil
+	}
+	repo.MockDescribeCommentsBySchema = func(ctx context.Context, schemaName string) ([]*CommentDesc, error) {
+		return []*CommentDesc{
+			{Schema: schemaName, Table: "city", Comment: "cities of the world"},
+			{Schema: schemaName, Table: "city", Column: "name", Comment: "name | local"},
+		}, nil
+	}
+
+	dbCache, err := NewDBCacheUpdater(repo).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := dbCache.TableComment("WORLD", "City"); got != "cities of the world" {
+		t.Errorf("unmatched table comment, got %q", got)
+	}
+	if col, ok := dbCache.Column("city", "name"); !ok || col.Comment != "name | local" {
+		t.Errorf("unmatched column comment, got %v", col)
+	}
+
+	// comments of a schema loaded on first reference
+	worker := NewWorker()
+	if err := worker.ReCache(context.Background(), repo); err != nil {
+		t.Fatal(err)
+	}
+	if err := worker.LoadSchemas(context.Background(), "sys"); err != nil {
+		t.Fatal(err)
+	}
+	if got := worker.Cache().TableComment("sys", "city"); got != "cities of the world" {
+		t.Errorf("unmatched table comment of loaded schema, got %q", got)
+	}
+	sysCols, _ := worker.Cache().ColumnDatabase("sys", "city")
+	if len(sysCols) != 2 || sysCols[1].Comment != "name | local" {
+		t.Fatalf("unmatched column comment of loaded schema, got %v", sysCols)
+	}
+
+	cols, _ := dbCache.ColumnDescs("city")
+	col := cols[1]
+	want := "# `city` table\n\ncities of the world\n\n" +
+		"| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; | Comment&nbsp;&nbsp; |\n" +
+		"| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- | :------------------ |\n" +
+		"| `ID` | `int(11)` | `PRI` | `-` |  |  |\n" +
+		"| `Name` | `char(35)` | `` | `-` |  | name \\| local |\n"
+	if d := cmp.Diff(want, TableDoc("city", dbCache.TableComment("world", "city"), cols, nil)); d != "" {
+		t.Errorf("unmatched doc (- want, + got):\n%s", d)
+	}
+	wantCol := "`city`.`Name` column\n\n`char(35)`\n\nname | local\n"
+	if d := cmp.Diff(wantCol, ColumnDoc("city", col)); d != "" {
+		t.Errorf("unmatched doc (- want, + got):\n%s", d)
+	}
+}
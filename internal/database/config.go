@@ -25,10 +25,13 @@ type DBConfig struct {
 	Proto          Proto                  `json:"proto" yaml:"proto"`
 	User           string                 `json:"user" yaml:"user"`
 	Passwd         string                 `json:"passwd" yaml:"passwd"`
+	PasswdFile     string                 `json:"passwdFile" yaml:"passwdFile"`
+	PasswdCommand  string                 `json:"passwdCommand" yaml:"passwdCommand"`
 	Host           string                 `json:"host" yaml:"host"`
 	Port           int                    `json:"port" yaml:"port"`
 	Path           string                 `json:"path" yaml:"path"`
 	DBName         string                 `json:"dbName" yaml:"dbName"`
+	Service        string                 `json:"service" yaml:"service"`
 	Params         map[string]string      `json:"params" yaml:"params"`
 	SSHCfg         *SSHConfig             `json:"sshConfig" yaml:"sshConfig"`
 }
@@ -37,6 +40,9 @@ func (c *DBConfig) Validate() error {
 	if c.Driver == "" {
 		return errors.New("required: connections[].driver")
 	}
+	if err := validateSecret("connections[].passwd", c.Passwd, c.PasswdFile, c.PasswdCommand); err != nil {
+		return err
+	}
 
 	switch c.Driver {
 	case
@@ -46,21 +52,22 @@ func (c *DBConfig) Validate() error {
 		dialect.DatabaseDriverMySQL56,
 		dialect.DatabaseDriverPostgreSQL,
 		dialect.DatabaseDriverVertica:
-		if c.DataSourceName == "" && c.Proto == "" {
+		// the service of the client files gives the other settings
+		if c.DataSourceName == "" && c.Proto == "" && c.Service == "" {
 			return errors.New("required: connections[].dataSourceName or connections[].proto")
 		}
 
 		if c.DataSourceName == "" && c.Proto != "" {
-			if c.User == "" {
+			if c.User == "" && c.Service == "" {
 				return errors.New("required: connections[].user")
 			}
 			switch c.Proto {
 			case ProtoTCP, ProtoUDP, ProtoHTTP:
-				if c.Host == "" {
+				if c.Host == "" && c.Service == "" {
 					return errors.New("required: connections[].host")
 				}
 			case ProtoUnix:
-				if c.Path == "" {
+				if c.Path == "" && c.Service == "" {
 					return errors.New("required: connections[].path")
 				}
 			default:
@@ -71,7 +78,7 @@ func (c *DBConfig) Validate() error {
 			}
 		}
 	case dialect.DatabaseDriverSQLite3:
-	case dialect.DatabaseDriverH2:
+	case dialect.DatabaseDriverH2, dialect.DatabaseDriverSchemaFile:
 		if c.DataSourceName == "" {
 			return errors.New("required: connections[].dataSourceName")
 		}
@@ -94,16 +101,20 @@ func (c *DBConfig) Validate() error {
 			}
 		}
 	case dialect.DatabaseDriverOracle:
-		if c.DataSourceName == "" && c.Proto == "" {
+		if c.DataSourceName == "" && c.Proto == "" && c.Service == "" {
 			return errors.New("required: connections[].dataSourceName or connections[].proto")
 		}
 		if c.DataSourceName == "" {
 			if c.User == "" {
 				return errors.New("required: connections[].user")
 			}
-			if c.Passwd == "" {
+			if c.Passwd == "" && c.PasswdFile == "" && c.PasswdCommand == "" {
 				return errors.New("required: connections[].Passwd")
 			}
+			// the net service name gives the address
+			if c.Service != "" {
+				break
+			}
 			if c.Host == "" {
 				return errors.New("required: connections[].Host")
 			}
@@ -144,14 +155,19 @@ func (c *DBConfig) Validate() error {
 }
 
 type SSHConfig struct {
-	Host       string `json:"host" yaml:"host"`
-	Port       int    `json:"port" yaml:"port"`
-	User       string `json:"user" yaml:"user"`
-	PassPhrase string `json:"passPhrase" yaml:"passPhrase"`
-	PrivateKey string `json:"privateKey" yaml:"privateKey"`
+	Host              string `json:"host" yaml:"host"`
+	Port              int    `json:"port" yaml:"port"`
+	User              string `json:"user" yaml:"user"`
+	PassPhrase        string `json:"passPhrase" yaml:"passPhrase"`
+	PassPhraseFile    string `json:"passPhraseFile" yaml:"passPhraseFile"`
+	PassPhraseCommand string `json:"passPhraseCommand" yaml:"passPhraseCommand"`
+	PrivateKey        string `json:"privateKey" yaml:"privateKey"`
 }
 
 func (s *SSHConfig) Validate() error {
+	if err := validateSecret("connections[].sshConfig.passPhrase", s.PassPhrase, s.PassPhraseFile, s.PassPhraseCommand); err != nil {
+		return err
+	}
 	if s.Host == "" {
 		return errors.New("required: connections[]sshConfig.host")
 	}
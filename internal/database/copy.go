@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CopyRows inserts the rows of a query result into the table of opt,
+// mapping the result columns to the table columns by name. Result columns
+// that do not exist in the table are ignored.
+func CopyRows(ctx context.Context, rows *sql.Rows, db *sql.DB, opt *ImportOption) (*ImportResult, error) {
+	colTypes, err := ColumnTypes(rows)
+	if err != nil {
+		return nil, err
+	}
+	srcIndexes := []int{}
+	columns := []*ColumnDesc{}
+	for i, colType := range colTypes {
+		for _, col := range opt.Columns {
+			if strings.EqualFold(col.Name, colType.Name) {
+				srcIndexes = append(srcIndexes, i)
+				columns = append(columns, col)
+				break
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no column of the source matches the columns of table %q", opt.Table)
+	}
+
+	inserter := newRowInserter(ctx, db, columns, opt)
+	result := inserter.result
+	rowNum := 0
+	for rows.Next() {
+		rowNum++
+		row, err := scanRowValue(rows, len(colTypes))
+		if err != nil {
+			return result, err
+		}
+
+		values := make([]interface{}, len(columns))
+		var convErr error
+		for i, col := range columns {
+			if values[i], convErr = convertCopyValue(row[srcIndexes[i]], col); convErr != nil {
+				convErr = fmt.Errorf("column %s, %w", col.Name, convErr)
+				break
+			}
+		}
+		if convErr != nil {
+			result.Errors = append(result.Errors, &ImportError{Line: rowNum, Err: convErr})
+			continue
+		}
+
+		if err := inserter.add(&importRow{line: rowNum, values: values}); err != nil {
+			return result, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+	if err := inserter.flush(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// convertCopyValue converts a value scanned from the source driver into a
+// value accepted by the column of the destination.
+func convertCopyValue(val interface{}, col *ColumnDesc) (interface{}, error) {
+	colType := strings.ToUpper(col.Type)
+	switch v := val.(type) {
+	case []byte:
+		if isBinaryType(colType) {
+			return v, nil
+		}
+		return convertString(string(v), colType)
+	case string:
+		return convertString(v, colType)
+	case bool:
+		switch {
+		case isTextType(colType):
+			return strconv.FormatBool(v), nil
+		case isIntegerType(colType):
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+	case int64:
+		switch {
+		case isTextType(colType):
+			return strconv.FormatInt(v, 10), nil
+		case isBoolType(colType):
+			return v != 0, nil
+		}
+	case float64:
+		if isTextType(colType) {
+			return strconv.FormatFloat(v, 'g', -1, 64), nil
+		}
+	case time.Time:
+		if isTextType(colType) {
+			return v.Format(time.RFC3339Nano), nil
+		}
+	}
+	return val, nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yaamai/sqls/dialect"
+)
+
+func TestCopyRows(t *testing.T) {
+	ctx := context.Background()
+	src, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	src.SetMaxOpenConns(1)
+	dst, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	dst.SetMaxOpenConns(1)
+
+	for _, q := range []string{
+		"CREATE TABLE city (ID INTEGER, Name TEXT, Population TEXT, Extra TEXT)",
+		"INSERT INTO city VALUES (1, 'Kabul', '1780000', 'x'), (2, 'Qandahar', 'unknown', 'y'), (3, 'Herat', NULL, 'z')",
+	} {
+		if _, err := src.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := dst.Exec("CREATE TABLE town (id INTEGER PRIMARY KEY, name TEXT, population INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	cols, err := NewSQLite3DBRepository(dst).(*SQLite3DBRepository).describeTable(ctx, "town")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := src.QueryContext(ctx, "SELECT * FROM city")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	res, err := CopyRows(ctx, rows, dst, &ImportOption{
+		Driver:  dialect.DatabaseDriverSQLite3,
+		Table:   "town",
+		Columns: cols,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Inserted != 2 {
+		t.Errorf("unexpected inserted rows %d", res.Inserted)
+	}
+	if len(res.Errors) != 1 || res.Errors[0].Line != 2 {
+		t.Errorf("unexpected errors %v", res.Errors)
+	}
+
+	gotRows, err := dst.Query("SELECT id, name, population, typeof(population) FROM town ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotRows.Close()
+	got, err := ScanRows(gotRows, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"1", "Kabul", "1780000", "integer"},
+		{"3", "Herat", "", "null"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unmatched rows (- want, + got):\n%s", diff)
+	}
+}
@@ -33,6 +33,13 @@ type DBRepository interface {
 	Exec(ctx context.Context, query string) (sql.Result, error)
 	Query(ctx context.Context, query string) (*sql.Rows, error)
 	DescribeForeignKeysBySchema(ctx context.Context, schemaName string) ([]*ForeignKey, error)
+	Explain(ctx context.Context, query string) (*PlanNode, error)
+	ShowCreateTable(ctx context.Context, schemaName, tableName string) (string, error)
+	SchemaViews(ctx context.Context) (map[string][]*ViewDesc, error)
+	SchemaSequences(ctx context.Context) (map[string][]string, error)
+	SchemaRoutines(ctx context.Context) (map[string][]*RoutineDesc, error)
+	DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error)
+	DescribeCommentsBySchema(ctx context.Context, schemaName string) ([]*CommentDesc, error)
 }
 
 type DBOption struct {
@@ -53,6 +60,21 @@ type ColumnDesc struct {
 	Key     string
 	Default sql.NullString
 	Extra   string
+	Comment string
+}
+
+type ViewDesc struct {
+	Schema       string
+	Name         string
+	Materialized bool
+	Definition   string
+}
+
+func (vd *ViewDesc) Kind() string {
+	if vd.Materialized {
+		return "materialized view"
+	}
+	return "view"
 }
 
 type ForeignKey [][2]*ColumnBase
@@ -88,6 +110,10 @@ func ColumnDoc(tableName string, colDesc *ColumnDesc) string {
 	fmt.Fprintln(buf)
 	fmt.Fprintln(buf)
 	fmt.Fprintln(buf, colDesc.OnelineDesc())
+	if colDesc.Comment != "" {
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf, colDesc.Comment)
+	}
 	return buf.String()
 }
 
@@ -100,19 +126,83 @@ func Coalesce(str ...string) string {
 	return ""
 }
 
-func TableDoc(tableName string, cols []*ColumnDesc) string {
+func TableDoc(tableName, comment string, cols []*ColumnDesc, indexes []*IndexDesc) string {
 	buf := new(bytes.Buffer)
 	fmt.Fprintf(buf, "# `%s` table", tableName)
 	fmt.Fprintln(buf)
 	fmt.Fprintln(buf)
+	if comment != "" {
+		fmt.Fprintln(buf, comment)
+	}
 	fmt.Fprintln(buf)
-	fmt.Fprintln(buf, "| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |")
-	fmt.Fprintln(buf, "| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |")
+	writeColumnsTable(buf, cols)
+	if len(indexes) > 0 {
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf, "| Index&nbsp;&nbsp; | Columns&nbsp;&nbsp; | Unique&nbsp;&nbsp; | Type&nbsp;&nbsp; |")
+		fmt.Fprintln(buf, "| :---------------- | :------------------ | :----------------- | :--------------- |")
+		for _, index := range indexes {
+			unique := ""
+			if index.Unique {
+				unique = "UNIQUE"
+			}
+			fmt.Fprintf(buf, "| `%s` | `%s` | %s | %s |", index.Name, strings.Join(index.Columns, "`, `"), unique, index.Type)
+			fmt.Fprintln(buf)
+		}
+	}
+	return buf.String()
+}
+
+func ViewDoc(view *ViewDesc, cols []*ColumnDesc) string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# `%s` %s", view.Name, view.Kind())
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf)
+	if len(cols) > 0 {
+		fmt.Fprintln(buf)
+		writeColumnsTable(buf, cols)
+	}
+	if view.Definition != "" {
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf, "```sql")
+		fmt.Fprintln(buf, strings.TrimSpace(view.Definition))
+		fmt.Fprintln(buf, "```")
+	}
+	return buf.String()
+}
+
+func SequenceDoc(schemaName, sequenceName string) string {
+	if schemaName == "" {
+		return fmt.Sprintf("`%s` sequence\n", sequenceName)
+	}
+	return fmt.Sprintf("`%s`.`%s` sequence\n", schemaName, sequenceName)
+}
+
+// tableCellReplacer keeps a text in a single markdown table cell.
+var tableCellReplacer = strings.NewReplacer("\r\n", " ", "\n", " ", "|", "\\|")
+
+func writeColumnsTable(buf *bytes.Buffer, cols []*ColumnDesc) {
+	// the comment column is shown only when some column has a comment
+	hasComment := false
+	for _, col := range cols {
+		if col.Comment != "" {
+			hasComment = true
+			break
+		}
+	}
+	if hasComment {
+		fmt.Fprintln(buf, "| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; | Comment&nbsp;&nbsp; |")
+		fmt.Fprintln(buf, "| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- | :------------------ |")
+	} else {
+		fmt.Fprintln(buf, "| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |")
+		fmt.Fprintln(buf, "| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |")
+	}
 	for _, col := range cols {
 		fmt.Fprintf(buf, "| `%s` | `%s` | `%s` | `%s` | %s |", col.Name, col.Type, col.Key, Coalesce(col.Default.String, "-"), col.Extra)
+		if hasComment {
+			fmt.Fprintf(buf, " %s |", tableCellReplacer.Replace(col.Comment))
+		}
 		fmt.Fprintln(buf)
 	}
-	return buf.String()
 }
 
 func SubqueryDoc(name string, views []*parseutil.SubQueryView, dbCache *DBCache) string {
@@ -180,6 +270,45 @@ func SubqueryColumnDoc(identName string, views []*parseutil.SubQueryView, dbCach
 	return buf.String()
 }
 
+// parseSchemaViews reads rows of schema, name, definition and object type,
+// which is either "VIEW" or "MATERIALIZED VIEW".
+func parseSchemaViews(rows *sql.Rows) (map[string][]*ViewDesc, error) {
+	schemaViews := map[string][]*ViewDesc{}
+	for rows.Next() {
+		var (
+			view       ViewDesc
+			definition sql.NullString
+			objectType string
+		)
+		if err := rows.Scan(&view.Schema, &view.Name, &definition, &objectType); err != nil {
+			return nil, err
+		}
+		view.Definition = definition.String
+		view.Materialized = strings.EqualFold(objectType, "MATERIALIZED VIEW")
+		schemaViews[view.Schema] = append(schemaViews[view.Schema], &view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return schemaViews, nil
+}
+
+// parseSchemaObjects reads rows of schema and object name.
+func parseSchemaObjects(rows *sql.Rows) (map[string][]string, error) {
+	schemaObjects := map[string][]string{}
+	for rows.Next() {
+		var schema, name string
+		if err := rows.Scan(&schema, &name); err != nil {
+			return nil, err
+		}
+		schemaObjects[schema] = append(schemaObjects[schema], name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return schemaObjects, nil
+}
+
 func parseForeignKeys(rows *sql.Rows, schemaName string) ([]*ForeignKey, error) {
 	var retVal []*ForeignKey
 	var prevFk string
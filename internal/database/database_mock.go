@@ -18,6 +18,13 @@ type MockDBRepository struct {
 	MockExec                          func(context.Context, string) (sql.Result, error)
 	MockQuery                         func(context.Context, string) (*sql.Rows, error)
 	MockDescribeForeignKeysBySchema   func(context.Context, string) ([]*ForeignKey, error)
+	MockExplain                       func(context.Context, string) (*PlanNode, error)
+	MockShowCreateTable               func(context.Context, string, string) (string, error)
+	MockSchemaViews                   func(context.Context) (map[string][]*ViewDesc, error)
+	MockSchemaSequences               func(context.Context) (map[string][]string, error)
+	MockSchemaRoutines                func(context.Context) (map[string][]*RoutineDesc, error)
+	MockDescribeIndexesBySchema       func(context.Context, string) ([]*IndexDesc, error)
+	MockDescribeCommentsBySchema      func(context.Context, string) ([]*CommentDesc, error)
 }
 
 func NewMockDBRepository(_ *sql.DB) DBRepository {
@@ -65,6 +72,27 @@ func NewMockDBRepository(_ *sql.DB) DBRepository {
 		MockDescribeForeignKeysBySchema: func(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
 			return foreignKeys, nil
 		},
+		MockExplain: func(ctx context.Context, query string) (*PlanNode, error) {
+			return dummyPlan, nil
+		},
+		MockShowCreateTable: func(ctx context.Context, schemaName, tableName string) (string, error) {
+			return "", ErrNotImplementation
+		},
+		MockSchemaViews: func(ctx context.Context) (map[string][]*ViewDesc, error) {
+			return map[string][]*ViewDesc{}, nil
+		},
+		MockSchemaSequences: func(ctx context.Context) (map[string][]string, error) {
+			return map[string][]string{}, nil
+		},
+		MockSchemaRoutines: func(ctx context.Context) (map[string][]*RoutineDesc, error) {
+			return map[string][]*RoutineDesc{}, nil
+		},
+		MockDescribeIndexesBySchema: func(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+			return []*IndexDesc{}, nil
+		},
+		MockDescribeCommentsBySchema: func(ctx context.Context, schemaName string) ([]*CommentDesc, error) {
+			return []*CommentDesc{}, nil
+		},
 	}
 }
 
@@ -112,10 +140,52 @@ func (m *MockDBRepository) Query(ctx context.Context, query string) (*sql.Rows,
 	return m.MockQuery(ctx, query)
 }
 
+func (m *MockDBRepository) Explain(ctx context.Context, query string) (*PlanNode, error) {
+	return m.MockExplain(ctx, query)
+}
+
+func (m *MockDBRepository) ShowCreateTable(ctx context.Context, schemaName, tableName string) (string, error) {
+	return m.MockShowCreateTable(ctx, schemaName, tableName)
+}
+
+func (m *MockDBRepository) SchemaViews(ctx context.Context) (map[string][]*ViewDesc, error) {
+	return m.MockSchemaViews(ctx)
+}
+
+func (m *MockDBRepository) SchemaSequences(ctx context.Context) (map[string][]string, error) {
+	return m.MockSchemaSequences(ctx)
+}
+
+func (m *MockDBRepository) SchemaRoutines(ctx context.Context) (map[string][]*RoutineDesc, error) {
+	return m.MockSchemaRoutines(ctx)
+}
+
+func (m *MockDBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	return m.MockDescribeIndexesBySchema(ctx, schemaName)
+}
+
+func (m *MockDBRepository) DescribeCommentsBySchema(ctx context.Context, schemaName string) ([]*CommentDesc, error) {
+	return m.MockDescribeCommentsBySchema(ctx, schemaName)
+}
+
 func (m *MockDBRepository) DescribeForeignKeysBySchema(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
 	return m.MockDescribeForeignKeysBySchema(ctx, schemaName)
 }
 
+var dummyPlan = &PlanNode{
+	Operation: "Hash Join",
+	Cost:      floatPtr(24.5),
+	Rows:      floatPtr(4079),
+	Children: []*PlanNode{
+		{Operation: "Seq Scan", Object: "city", Cost: floatPtr(10.79), Rows: floatPtr(4079)},
+		{Operation: "Seq Scan", Object: "country", Cost: floatPtr(7.39), Rows: floatPtr(239)},
+	},
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
 var dummyDatabases = []string{
 	"information_schema",
 	"mysql",
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/yaamai/sqls/dialect"
+)
+
+// reconstructTableDDL builds CREATE TABLE from the columns and foreign keys
+// described by repo, for drivers that cannot show the original statement.
+func reconstructTableDDL(ctx context.Context, repo DBRepository, schemaName, tableName string) (string, error) {
+	descs, err := repo.DescribeDatabaseTableBySchema(ctx, schemaName)
+	if err != nil {
+		return "", err
+	}
+	cols := []*ColumnDesc{}
+	for _, desc := range descs {
+		if strings.EqualFold(desc.Table, tableName) {
+			cols = append(cols, desc)
+		}
+	}
+	if len(cols) == 0 {
+		return "", fmt.Errorf("table not found, %q", tableName)
+	}
+
+	// foreign keys are optional, some drivers cannot describe them
+	tableFKs := []*ForeignKey{}
+	if fks, err := repo.DescribeForeignKeysBySchema(ctx, schemaName); err == nil {
+		for _, fk := range fks {
+			if len(*fk) > 0 && strings.EqualFold((*fk)[0][0].Table, tableName) {
+				tableFKs = append(tableFKs, fk)
+			}
+		}
+	}
+	return createTableDDL(schemaName, qualifiedTableName(schemaName, cols[0].Table, repo.Driver()), cols, tableFKs, repo.Driver()), nil
+}
+
+// TableDDL builds CREATE TABLE and CREATE INDEX from the cached columns and
+// indexes of a table, so that hover shows it without querying the database.
+// The index of the primary key is in CREATE TABLE.
+func TableDDL(schemaName string, cols []*ColumnDesc, indexes []*IndexDesc, driver dialect.DatabaseDriver) string {
+	table := qualifiedTableName(schemaName, cols[0].Table, driver)
+	stmts := []string{createTableDDL(schemaName, table, cols, nil, driver)}
+	pks := []string{}
+	for _, col := range cols {
+		if isPrimaryKey(col, driver) {
+			pks = append(pks, col.Name)
+		}
+	}
+	for _, index := range indexes {
+		if index.Unique && slices.EqualFunc(index.Columns, pks, strings.EqualFold) {
+			continue
+		}
+		names := make([]string, len(index.Columns))
+		for i, name := range index.Columns {
+			names[i] = quoteIdentifier(name, driver)
+		}
+		unique := ""
+		if index.Unique {
+			unique = "UNIQUE "
+		}
+		stmts = append(stmts, fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);", unique, quoteIdentifier(index.Name, driver), table, strings.Join(names, ", ")))
+	}
+	return strings.Join(stmts, "\n")
+}
+
+func createViewDDL(schemaName, viewName, definition string, driver dialect.DatabaseDriver) string {
+	definition = strings.TrimSpace(definition)
+	if len(definition) > 6 && strings.EqualFold(definition[:6], "CREATE") {
+		return definition
+	}
+	return fmt.Sprintf("CREATE VIEW %s AS\n%s", qualifiedTableName(schemaName, viewName, driver), definition)
+}
+
+func qualifiedTableName(schemaName, tableName string, driver dialect.DatabaseDriver) string {
+	if schemaName == "" {
+		return quoteIdentifier(tableName, driver)
+	}
+	return quoteIdentifier(schemaName, driver) + "." + quoteIdentifier(tableName, driver)
+}
+
+// showCreateTable runs SHOW CREATE TABLE, whose result has the statement in
+// the second column for both tables and views.
+func showCreateTable(ctx context.Context, conn *sql.DB, tableName string) (string, error) {
+	rows, err := conn.QueryContext(ctx, "SHOW CREATE TABLE "+tableName)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("table not found, %q", tableName)
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	values := make([]sql.NullString, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return "", err
+	}
+	ddl := values[0].String
+	if len(values) > 1 {
+		ddl = values[1].String
+	}
+	return strings.TrimSpace(ddl) + ";", nil
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yaamai/sqls/dialect"
+)
+
+// DDLDBRepository serves the metadata of a DDLSchema. It has no database,
+// so queries cannot be executed.
+type DDLDBRepository struct {
+	driver dialect.DatabaseDriver
+	schema *DDLSchema
+}
+
+// NewDDLDBRepository returns the repository of the schema. The schema must not
+// be changed after that, the cache reads it concurrently.
+func NewDDLDBRepository(driver dialect.DatabaseDriver, schema *DDLSchema) DBRepository {
+	return &DDLDBRepository{
+		driver: driver,
+		schema: schema,
+	}
+}
+
+func (db *DDLDBRepository) Driver() dialect.DatabaseDriver {
+	return db.driver
+}
+
+func (db *DDLDBRepository) CurrentDatabase(ctx context.Context) (string, error) {
+	return "", ErrNotImplementation
+}
+
+func (db *DDLDBRepository) Databases(ctx context.Context) ([]string, error) {
+	return nil, ErrNotImplementation
+}
+
+func (db *DDLDBRepository) CurrentSchema(ctx context.Context) (string, error) {
+	return db.schema.defaultSchema, nil
+}
+
+func (db *DDLDBRepository) Schemas(ctx context.Context) ([]string, error) {
+	schemas := []string{}
+	for _, name := range db.schema.schemas {
+		schemas = append(schemas, name)
+	}
+	sort.Strings(schemas)
+	return schemas, nil
+}
+
+func (db *DDLDBRepository) SchemaTables(ctx context.Context) (map[string][]string, error) {
+	schemaTables := map[string][]string{}
+	for _, t := range db.schema.sortedTables("") {
+		schemaTables[t.schema] = append(schemaTables[t.schema], t.name)
+	}
+	return schemaTables, nil
+}
+
+func (db *DDLDBRepository) DescribeDatabaseTable(ctx context.Context) ([]*ColumnDesc, error) {
+	return db.DescribeDatabaseTableBySchema(ctx, "")
+}
+
+func (db *DDLDBRepository) DescribeDatabaseTableBySchema(ctx context.Context, schemaName string) ([]*ColumnDesc, error) {
+	descs := []*ColumnDesc{}
+	for _, t := range db.schema.sortedTables(schemaName) {
+		for _, col := range t.columns {
+			// the cache sets the comments to the columns
+			copied := *col
+			descs = append(descs, &copied)
+		}
+	}
+	return descs, nil
+}
+
+func (db *DDLDBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
+	return nil, ErrNotImplementation
+}
+
+func (db *DDLDBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
+	return nil, ErrNotImplementation
+}
+
+func (db *DDLDBRepository) DescribeForeignKeysBySchema(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
+	fks := []*ForeignKey{}
+	for _, t := range db.schema.sortedTables(schemaName) {
+		fks = append(fks, t.foreignKeys...)
+	}
+	return fks, nil
+}
+
+func (db *DDLDBRepository) Explain(ctx context.Context, query string) (*PlanNode, error) {
+	return nil, ErrNotImplementation
+}
+
+func (db *DDLDBRepository) ShowCreateTable(ctx context.Context, schemaName, tableName string) (string, error) {
+	t := db.schema.table([]string{Coalesce(schemaName, db.schema.defaultSchema), tableName})
+	if t == nil {
+		return "", fmt.Errorf("table not found, %s", tableName)
+	}
+	if t.ddl == "" {
+		return "", ErrNotImplementation
+	}
+	return t.ddl, nil
+}
+
+func (db *DDLDBRepository) SchemaViews(ctx context.Context) (map[string][]*ViewDesc, error) {
+	schemaViews := map[string][]*ViewDesc{}
+	for _, t := range db.schema.sortedTables("") {
+		if t.view != nil {
+			schemaViews[t.schema] = append(schemaViews[t.schema], t.view)
+		}
+	}
+	return schemaViews, nil
+}
+
+func (db *DDLDBRepository) SchemaSequences(ctx context.Context) (map[string][]string, error) {
+	return map[string][]string{}, nil
+}
+
+func (db *DDLDBRepository) SchemaRoutines(ctx context.Context) (map[string][]*RoutineDesc, error) {
+	return nil, ErrNotImplementation
+}
+
+func (db *DDLDBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	indexes := []*IndexDesc{}
+	for _, t := range db.schema.sortedTables(schemaName) {
+		indexes = append(indexes, t.indexes...)
+	}
+	return indexes, nil
+}
+
+func (db *DDLDBRepository) DescribeCommentsBySchema(ctx context.Context, schemaName string) ([]*CommentDesc, error) {
+	comments := []*CommentDesc{}
+	for _, t := range db.schema.sortedTables(schemaName) {
+		if t.comment != "" {
+			comments = append(comments, &CommentDesc{Schema: t.schema, Table: t.name, Comment: t.comment})
+		}
+		for _, col := range t.columns {
+			if strings.TrimSpace(col.Comment) != "" {
+				comments = append(comments, &CommentDesc{Schema: t.schema, Table: t.name, Column: col.Name, Comment: col.Comment})
+			}
+		}
+	}
+	return comments, nil
+}
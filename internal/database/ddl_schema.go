@@ -0,0 +1,1200 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yaamai/sqls/dialect"
+	"github.com/yaamai/sqls/token"
+)
+
+// DefaultOfflineSchema is the schema of the tables not qualified by a schema
+// in the DDL files.
+const DefaultOfflineSchema = "public"
+
+// OfflineSchemaConfig configures the schema read from the DDL files of the
+// workspace, such as schema.sql and migrations. It is used when no
+// connection is configured.
+type OfflineSchemaConfig struct {
+	// Paths are files, directories or glob patterns, relative paths are
+	// resolved from the workspace root. The .sql files in a directory are
+	// read in the order of the names so that migrations apply in order.
+	Paths         []string               `json:"paths" yaml:"paths"`
+	DefaultSchema string                 `json:"defaultSchema" yaml:"defaultSchema"`
+	Driver        dialect.DatabaseDriver `json:"driver" yaml:"driver"`
+}
+
+func (c *OfflineSchemaConfig) Validate() error {
+	if len(c.Paths) == 0 {
+		return errors.New("required: offlineSchema.paths")
+	}
+	return nil
+}
+
+// DDLFiles returns the files of the paths in the order to read them. The
+// down migrations of golang-migrate, named *.down.sql, are skipped.
+func DDLFiles(baseDir string, paths []string) ([]string, error) {
+	files := []string{}
+	seen := map[string]struct{}{}
+	add := func(path string) {
+		if _, ok := seen[path]; ok {
+			return
+		}
+		seen[path] = struct{}{}
+		files = append(files, path)
+	}
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		matches := []string{path}
+		if strings.ContainsAny(path, "*?[") {
+			var err error
+			matches, err = filepath.Glob(path)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schema file pattern %s, %w", path, err)
+			}
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read schema files, %w", err)
+			}
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				name := strings.ToLower(d.Name())
+				if !d.IsDir() && strings.HasSuffix(name, ".sql") && !strings.HasSuffix(name, ".down.sql") {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("cannot read schema files, %w", err)
+			}
+		}
+	}
+	return files, nil
+}
+
+// DDLSchema is the schema built from DDL statements instead of the catalog
+// of a database. CREATE TABLE, CREATE VIEW, CREATE INDEX, ALTER TABLE, DROP
+// and COMMENT ON statements are applied in order, the other statements are
+// ignored.
+type DDLSchema struct {
+	defaultSchema string
+	// schemas is keyed by the upper schema name
+	schemas map[string]string
+	// tables is keyed by columnDatabaseKey
+	tables map[string]*ddlTable
+}
+
+type ddlTable struct {
+	schema string
+	name   string
+	// view is nil for a table
+	view *ViewDesc
+	// ddl is the statements defining the table
+	ddl         string
+	comment     string
+	columns     []*ColumnDesc
+	foreignKeys []*ForeignKey
+	indexes     []*IndexDesc
+}
+
+func NewDDLSchema(defaultSchema string) *DDLSchema {
+	s := &DDLSchema{
+		defaultSchema: defaultSchema,
+		schemas:       map[string]string{},
+		tables:        map[string]*ddlTable{},
+	}
+	s.addSchema(defaultSchema)
+	return s
+}
+
+// LoadDDLSchema reads the files in order.
+func LoadDDLSchema(defaultSchema string, files []string) (*DDLSchema, error) {
+	s := NewDDLSchema(defaultSchema)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read schema file, %w", err)
+		}
+		s.Apply(string(b))
+	}
+	return s, nil
+}
+
+// Apply applies the statements of the DDL to the schema.
+func (s *DDLSchema) Apply(ddl string) {
+	for _, stmt := range ddlStatements(ddl) {
+		s.applyStatement(stmt)
+	}
+}
+
+func (s *DDLSchema) addSchema(name string) {
+	if _, ok := s.schemas[strings.ToUpper(name)]; !ok {
+		s.schemas[strings.ToUpper(name)] = name
+	}
+}
+
+func (s *DDLSchema) qualify(parts []string) (string, string) {
+	if len(parts) > 1 {
+		return parts[len(parts)-2], parts[len(parts)-1]
+	}
+	return s.defaultSchema, parts[0]
+}
+
+func (s *DDLSchema) table(parts []string) *ddlTable {
+	if len(parts) == 0 {
+		return nil
+	}
+	return s.tables[columnDatabaseKey(s.qualify(parts))]
+}
+
+func (s *DDLSchema) newTable(parts []string, stmt *ddlStatement) *ddlTable {
+	schemaName, name := s.qualify(parts)
+	s.addSchema(schemaName)
+	t := &ddlTable{
+		schema: schemaName,
+		name:   name,
+		ddl:    stmt.text,
+	}
+	s.tables[columnDatabaseKey(schemaName, name)] = t
+	return t
+}
+
+func (s *DDLSchema) applyStatement(stmt *ddlStatement) {
+	p := &ddlParser{words: stmt.words}
+	switch {
+	case p.accept("CREATE"):
+		p.accept("OR", "REPLACE")
+		for p.acceptAny("GLOBAL", "LOCAL", "TEMPORARY", "TEMP", "UNLOGGED", "UNIQUE") {
+		}
+		switch {
+		case p.accept("TABLE"):
+			s.createTable(p, stmt)
+		case p.accept("VIEW"):
+			s.createView(p, stmt, false)
+		case p.accept("MATERIALIZED", "VIEW"):
+			s.createView(p, stmt, true)
+		case p.accept("INDEX"):
+			s.createIndex(p, isWord(stmt.words[1], "UNIQUE"))
+		case p.accept("SCHEMA"):
+			p.accept("IF", "NOT", "EXISTS")
+			if parts := p.name(); len(parts) > 0 {
+				s.addSchema(parts[len(parts)-1])
+			}
+		default:
+			// CREATE ALGORITHM = ... DEFINER = ... VIEW (mysql)
+			if i := indexOfWord(p.words, "VIEW"); i > 0 && indexOfWord(p.words[:i], "AS") < 0 {
+				p.pos = i + 1
+				s.createView(p, stmt, false)
+			}
+		}
+	case p.accept("ALTER", "TABLE"):
+		s.alterTable(p, stmt)
+	case p.accept("DROP"):
+		switch {
+		case p.acceptAny("TABLE", "VIEW"), p.accept("MATERIALIZED", "VIEW"):
+			p.accept("IF", "EXISTS")
+			for !p.done() {
+				if t := s.table(p.name()); t != nil {
+					s.dropTable(t)
+				}
+				if !p.acceptKind(token.Comma) {
+					break
+				}
+			}
+		case p.accept("INDEX"):
+			p.accept("CONCURRENTLY")
+			p.accept("IF", "EXISTS")
+			if parts := p.name(); len(parts) > 0 {
+				s.dropIndex(parts[len(parts)-1])
+			}
+		}
+	case p.accept("RENAME", "TABLE"):
+		// RENAME TABLE a TO b, c TO d (mysql)
+		for !p.done() {
+			t := s.table(p.name())
+			if !p.accept("TO") {
+				break
+			}
+			to := p.name()
+			if t != nil && len(to) > 0 {
+				s.renameTable(t, to)
+			}
+			if !p.acceptKind(token.Comma) {
+				break
+			}
+		}
+	case p.accept("COMMENT", "ON"):
+		s.commentOn(p)
+	}
+}
+
+func (s *DDLSchema) createTable(p *ddlParser, stmt *ddlStatement) {
+	p.accept("IF", "NOT", "EXISTS")
+	parts := p.name()
+	if len(parts) == 0 {
+		return
+	}
+	t := s.newTable(parts, stmt)
+	if p.peekKind(token.LParen) {
+		items, _ := p.group()
+		for _, item := range items {
+			s.tableElement(t, &ddlParser{words: item})
+		}
+	}
+	// table options, such as COMMENT = '...' (mysql)
+	for !p.done() {
+		if p.accept("AS") {
+			t.columns = s.queryColumns(t, nil, p.words[p.pos:])
+			return
+		}
+		if p.accept("COMMENT") {
+			p.acceptKind(token.Eq)
+			if comment, ok := p.str(); ok {
+				t.comment = comment
+			}
+			continue
+		}
+		p.pos++
+	}
+}
+
+func (s *DDLSchema) createView(p *ddlParser, stmt *ddlStatement, materialized bool) {
+	p.accept("IF", "NOT", "EXISTS")
+	parts := p.name()
+	if len(parts) == 0 {
+		return
+	}
+	var names []string
+	if p.peekKind(token.LParen) {
+		names = p.columnList()
+	}
+	for !p.done() && !p.accept("AS") {
+		p.pos++
+	}
+	if p.done() {
+		return
+	}
+	query := p.words[p.pos:]
+	t := s.newTable(parts, stmt)
+	t.view = &ViewDesc{
+		Schema:       t.schema,
+		Name:         t.name,
+		Materialized: materialized,
+		Definition:   stmt.textFrom(p.pos),
+	}
+	t.columns = s.queryColumns(t, names, query)
+}
+
+func (s *DDLSchema) createIndex(p *ddlParser, unique bool) {
+	p.accept("CONCURRENTLY")
+	p.accept("IF", "NOT", "EXISTS")
+	name := ""
+	if !isWord(p.peek(), "ON") {
+		if parts := p.name(); len(parts) > 0 {
+			name = parts[len(parts)-1]
+		}
+	}
+	if !p.accept("ON") {
+		return
+	}
+	p.accept("ONLY")
+	t := s.table(p.name())
+	if t == nil {
+		return
+	}
+	index := &IndexDesc{Schema: t.schema, Table: t.name, Name: name, Unique: unique}
+	if p.accept("USING") {
+		index.Type = strings.ToUpper(p.word())
+	}
+	index.Columns = p.columnList()
+	t.indexes = append(t.indexes, index)
+}
+
+func (s *DDLSchema) alterTable(p *ddlParser, stmt *ddlStatement) {
+	p.accept("IF", "EXISTS")
+	p.accept("ONLY")
+	t := s.table(p.name())
+	if t == nil {
+		return
+	}
+	t.ddl += ";\n\n" + stmt.text
+	for _, action := range splitItems(p.words[p.pos:]) {
+		s.alterAction(t, &ddlParser{words: action})
+	}
+}
+
+func (s *DDLSchema) alterAction(t *ddlTable, p *ddlParser) {
+	switch {
+	case p.accept("ADD"):
+		p.accept("COLUMN")
+		// ADD (col1 type, col2 type) (oracle)
+		if items, ok := p.group(); ok {
+			for _, item := range items {
+				s.tableElement(t, &ddlParser{words: item})
+			}
+			return
+		}
+		if isTableConstraint(p.peek()) {
+			s.tableElement(t, p)
+			return
+		}
+		p.accept("IF", "NOT", "EXISTS")
+		s.tableElement(t, p)
+	case p.accept("DROP"):
+		if p.acceptAny("CONSTRAINT", "INDEX", "KEY", "PRIMARY", "FOREIGN", "CHECK", "DEFAULT") {
+			return
+		}
+		p.accept("COLUMN")
+		p.accept("IF", "EXISTS")
+		t.dropColumn(p.word())
+	case p.accept("RENAME"):
+		switch {
+		case p.accept("COLUMN"):
+		case p.accept("CONSTRAINT"), p.accept("INDEX"), p.accept("KEY"):
+			return
+		case p.acceptAny("TO", "AS"):
+			if to := p.name(); len(to) > 0 {
+				s.renameTable(t, to)
+			}
+			return
+		}
+		from := p.word()
+		if p.accept("TO") {
+			s.renameColumn(t, from, p.word())
+		}
+	case p.acceptAny("MODIFY", "CHANGE"):
+		// MODIFY [COLUMN] col definition, CHANGE [COLUMN] old new definition (mysql)
+		change := isWord(p.words[0], "CHANGE")
+		p.accept("COLUMN")
+		from := p.peekWord()
+		if change {
+			p.pos++
+		}
+		col, _ := t.column(from)
+		if col == nil {
+			return
+		}
+		def := s.columnDef(t, p)
+		if def == nil {
+			return
+		}
+		if !strings.EqualFold(from, def.Name) {
+			s.renameColumn(t, from, def.Name)
+		}
+		def.Key = Coalesce(def.Key, col.Key)
+		def.Comment = Coalesce(def.Comment, col.Comment)
+		*col = *def
+	case p.accept("ALTER"):
+		// ALTER [COLUMN] col TYPE type, SET DATA TYPE type, SET/DROP NOT NULL, SET/DROP DEFAULT
+		p.accept("COLUMN")
+		col, _ := t.column(p.word())
+		if col == nil {
+			return
+		}
+		switch {
+		case p.accept("TYPE"), p.accept("SET", "DATA", "TYPE"):
+			col.Type = joinTokens(p.until(columnConstraintWord))
+		case p.accept("SET", "NOT", "NULL"):
+			col.Null = "NO"
+		case p.accept("DROP", "NOT", "NULL"):
+			col.Null = "YES"
+		case p.accept("SET", "DEFAULT"):
+			col.Default = sql.NullString{String: joinTokens(p.words[p.pos:]), Valid: true}
+		case p.accept("DROP", "DEFAULT"):
+			col.Default = sql.NullString{}
+		}
+	}
+}
+
+func isTableConstraint(tok *token.Token) bool {
+	for _, keyword := range []string{"CONSTRAINT", "PRIMARY", "FOREIGN", "UNIQUE", "KEY", "INDEX", "CHECK", "EXCLUDE", "FULLTEXT", "SPATIAL", "LIKE"} {
+		if isWord(tok, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// tableElement applies a column definition or a table constraint.
+func (s *DDLSchema) tableElement(t *ddlTable, p *ddlParser) {
+	if !isTableConstraint(p.peek()) {
+		if col := s.columnDef(t, p); col != nil {
+			if old, i := t.column(col.Name); old != nil {
+				t.columns[i] = col
+			} else {
+				t.columns = append(t.columns, col)
+			}
+		}
+		return
+	}
+	if p.accept("CONSTRAINT") {
+		p.pos++
+	}
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		for _, name := range p.columnList() {
+			if col, _ := t.column(name); col != nil {
+				col.Key = "YES"
+				col.Null = "NO"
+			}
+		}
+	case p.accept("FOREIGN", "KEY"):
+		// the name of the foreign key is optional in mysql
+		if !p.peekKind(token.LParen) {
+			p.pos++
+		}
+		columns := p.columnList()
+		if p.accept("REFERENCES") {
+			s.addForeignKey(t, columns, p)
+		}
+	case p.accept("UNIQUE"), p.acceptAny("KEY", "INDEX"):
+		unique := isWord(p.words[p.pos-1], "UNIQUE")
+		p.acceptAny("KEY", "INDEX")
+		name := ""
+		if !p.peekKind(token.LParen) {
+			name = p.word()
+		}
+		if p.accept("USING") {
+			p.pos++
+		}
+		t.indexes = append(t.indexes, &IndexDesc{
+			Schema:  t.schema,
+			Table:   t.name,
+			Name:    name,
+			Columns: p.columnList(),
+			Unique:  unique,
+		})
+	case p.accept("LIKE"):
+		if src := s.table(p.name()); src != nil {
+			for _, col := range src.columns {
+				copied := *col
+				copied.Schema, copied.Table = t.schema, t.name
+				t.columns = append(t.columns, &copied)
+			}
+		}
+	}
+}
+
+// columnConstraintWord reports whether the word ends the data type of a
+// column definition.
+func columnConstraintWord(tok *token.Token) bool {
+	for _, keyword := range []string{
+		"NOT", "NULL", "DEFAULT", "PRIMARY", "REFERENCES", "UNIQUE", "CHECK", "CONSTRAINT",
+		"COMMENT", "AUTO_INCREMENT", "AUTOINCREMENT", "IDENTITY", "GENERATED", "COLLATE",
+		"ON", "AS", "CHARSET", "KEY", "FIRST", "AFTER", "USING",
+	} {
+		if isWord(tok, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *DDLSchema) columnDef(t *ddlTable, p *ddlParser) *ColumnDesc {
+	name := p.word()
+	if name == "" {
+		return nil
+	}
+	col := &ColumnDesc{
+		ColumnBase: ColumnBase{Schema: t.schema, Table: t.name, Name: name},
+		Null:       "YES",
+	}
+	typeWords := p.until(func(tok *token.Token) bool {
+		// varchar(10) CHARACTER SET utf8, but character varying is a type
+		return columnConstraintWord(tok) || (isWord(tok, "CHARACTER") && p.pos+1 < len(p.words) && isWord(p.words[p.pos+1], "SET"))
+	})
+	col.Type = joinTokens(typeWords)
+	for !p.done() {
+		switch {
+		case p.accept("NOT", "NULL"):
+			col.Null = "NO"
+		case p.accept("NULL"):
+			col.Null = "YES"
+		case p.accept("PRIMARY", "KEY"):
+			col.Key = "YES"
+			col.Null = "NO"
+		case p.accept("DEFAULT"):
+			// the first word is the default even if it is NULL
+			start := p.pos
+			p.skip()
+			p.until(columnConstraintWord)
+			col.Default = sql.NullString{String: joinTokens(p.words[start:p.pos]), Valid: true}
+		case p.accept("REFERENCES"):
+			s.addForeignKey(t, []string{col.Name}, p)
+		case p.accept("COMMENT"):
+			if comment, ok := p.str(); ok {
+				col.Comment = comment
+			}
+		case p.acceptAny("AUTO_INCREMENT", "AUTOINCREMENT"):
+			col.Extra = "auto_increment"
+		case p.accept("IDENTITY"):
+			col.Extra = "identity"
+		case p.accept("GENERATED"):
+			if i := indexOfWord(p.words[p.pos:], "IDENTITY"); i >= 0 {
+				col.Extra = "identity"
+			} else {
+				col.Extra = "generated"
+			}
+			p.skip()
+		default:
+			p.skip()
+		}
+	}
+	return col
+}
+
+// addForeignKey reads the referenced table and columns after REFERENCES.
+// The primary key is referenced when the columns are omitted.
+func (s *DDLSchema) addForeignKey(t *ddlTable, columns []string, p *ddlParser) {
+	parts := p.name()
+	if len(parts) == 0 {
+		return
+	}
+	refSchema, refTable := s.qualify(parts)
+	var refColumns []string
+	if p.peekKind(token.LParen) {
+		refColumns = p.columnList()
+	} else if ref := s.table(parts); ref != nil {
+		for _, col := range ref.columns {
+			if col.Key == "YES" {
+				refColumns = append(refColumns, col.Name)
+			}
+		}
+	}
+	if len(refColumns) != len(columns) {
+		return
+	}
+	fk := new(ForeignKey)
+	for i, column := range columns {
+		*fk = append(*fk, [2]*ColumnBase{
+			{Schema: t.schema, Table: t.name, Name: column},
+			{Schema: refSchema, Table: refTable, Name: refColumns[i]},
+		})
+	}
+	t.foreignKeys = append(t.foreignKeys, fk)
+}
+
+func (s *DDLSchema) commentOn(p *ddlParser) {
+	switch {
+	case p.acceptAny("TABLE", "VIEW"), p.accept("MATERIALIZED", "VIEW"):
+		t := s.table(p.name())
+		if t != nil && p.accept("IS") {
+			t.comment, _ = p.str()
+		}
+	case p.accept("COLUMN"):
+		parts := p.name()
+		if len(parts) < 2 {
+			return
+		}
+		t := s.table(parts[:len(parts)-1])
+		if t == nil || !p.accept("IS") {
+			return
+		}
+		if col, _ := t.column(parts[len(parts)-1]); col != nil {
+			col.Comment, _ = p.str()
+		}
+	}
+}
+
+func (s *DDLSchema) renameTable(t *ddlTable, parts []string) {
+	schemaName, name := s.qualify(parts)
+	if len(parts) == 1 {
+		schemaName = t.schema
+	}
+	s.eachColumnBase(func(col *ColumnBase) {
+		if strings.EqualFold(col.Schema, t.schema) && strings.EqualFold(col.Table, t.name) {
+			col.Schema, col.Table = schemaName, name
+		}
+	})
+	delete(s.tables, columnDatabaseKey(t.schema, t.name))
+	s.addSchema(schemaName)
+	t.schema, t.name = schemaName, name
+	for _, col := range t.columns {
+		col.Schema, col.Table = schemaName, name
+	}
+	for _, index := range t.indexes {
+		index.Schema, index.Table = schemaName, name
+	}
+	if t.view != nil {
+		t.view.Schema, t.view.Name = schemaName, name
+	}
+	s.tables[columnDatabaseKey(schemaName, name)] = t
+}
+
+func (s *DDLSchema) dropTable(t *ddlTable) {
+	delete(s.tables, columnDatabaseKey(t.schema, t.name))
+	for _, other := range s.tables {
+		other.foreignKeys = removeForeignKeys(other.foreignKeys, func(col *ColumnBase) bool {
+			return strings.EqualFold(col.Schema, t.schema) && strings.EqualFold(col.Table, t.name)
+		})
+	}
+}
+
+func (s *DDLSchema) dropIndex(name string) {
+	for _, t := range s.tables {
+		indexes := t.indexes[:0]
+		for _, index := range t.indexes {
+			if !strings.EqualFold(index.Name, name) {
+				indexes = append(indexes, index)
+			}
+		}
+		t.indexes = indexes
+	}
+}
+
+func (s *DDLSchema) renameColumn(t *ddlTable, from, to string) {
+	col, _ := t.column(from)
+	if col == nil || to == "" {
+		return
+	}
+	col.Name = to
+	s.eachColumnBase(func(c *ColumnBase) {
+		if strings.EqualFold(c.Schema, t.schema) && strings.EqualFold(c.Table, t.name) && strings.EqualFold(c.Name, from) {
+			c.Name = to
+		}
+	})
+	for _, index := range t.indexes {
+		for i, name := range index.Columns {
+			if strings.EqualFold(name, from) {
+				index.Columns[i] = to
+			}
+		}
+	}
+}
+
+func (s *DDLSchema) eachColumnBase(f func(*ColumnBase)) {
+	for _, t := range s.tables {
+		for _, fk := range t.foreignKeys {
+			for _, pair := range *fk {
+				f(pair[0])
+				f(pair[1])
+			}
+		}
+	}
+}
+
+func (t *ddlTable) column(name string) (*ColumnDesc, int) {
+	for i, col := range t.columns {
+		if strings.EqualFold(col.Name, name) {
+			return col, i
+		}
+	}
+	return nil, -1
+}
+
+func (t *ddlTable) dropColumn(name string) {
+	col, i := t.column(name)
+	if col == nil {
+		return
+	}
+	t.columns = append(t.columns[:i:i], t.columns[i+1:]...)
+	t.foreignKeys = removeForeignKeys(t.foreignKeys, func(c *ColumnBase) bool {
+		return strings.EqualFold(c.Table, t.name) && strings.EqualFold(c.Name, name)
+	})
+}
+
+func removeForeignKeys(fks []*ForeignKey, match func(*ColumnBase) bool) []*ForeignKey {
+	retVal := []*ForeignKey{}
+	for _, fk := range fks {
+		matched := false
+		for _, pair := range *fk {
+			if match(pair[0]) || match(pair[1]) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			retVal = append(retVal, fk)
+		}
+	}
+	return retVal
+}
+
+// queryColumns returns the columns of the select list of a view or CREATE
+// TABLE AS. A column is named by the alias or the referenced column, the type
+// is known only for a column of a table in the schema. The names replace the
+// names of the select list when they are given.
+func (s *DDLSchema) queryColumns(t *ddlTable, names []string, query []*token.Token) []*ColumnDesc {
+	selectList, sources := s.selectList(query)
+	columns := []*ColumnDesc{}
+	add := func(name, typ string) {
+		columns = append(columns, &ColumnDesc{
+			ColumnBase: ColumnBase{Schema: t.schema, Table: t.name, Name: name},
+			Type:       typ,
+		})
+	}
+	for _, item := range selectList {
+		if len(item) == 0 {
+			continue
+		}
+		last := item[len(item)-1]
+		// *, alias.*
+		if last.Kind == token.Mult {
+			for _, src := range sources {
+				if len(item) == 1 || (len(item) == 3 && isWord(item[0], src.alias)) {
+					for _, col := range src.table.columns {
+						add(col.Name, col.Type)
+					}
+				}
+			}
+			continue
+		}
+		word, ok := last.Value.(*token.SQLWord)
+		if last.Kind != token.SQLKeyword || !ok || isWord(last, "END") {
+			add("", "")
+			continue
+		}
+		expr := item
+		switch prev := item[max(len(item)-2, 0)]; {
+		case len(item) == 1, len(item) == 3 && item[1].Kind == token.Period:
+		// expr AS alias, expr alias
+		case isWord(prev, "AS"):
+			expr = item[:len(item)-2]
+		case prev.Kind == token.RParen, prev.Kind == token.SQLKeyword:
+			expr = item[:len(item)-1]
+		default:
+			add("", "")
+			continue
+		}
+		add(word.NoQuoteString(), columnType(expr, sources))
+	}
+	if names != nil {
+		for i, name := range names {
+			if i < len(columns) {
+				columns[i].Name = name
+			} else {
+				add(name, "")
+			}
+		}
+		columns = columns[:len(names)]
+	}
+	named := columns[:0]
+	for _, col := range columns {
+		if col.Name != "" {
+			named = append(named, col)
+		}
+	}
+	return named
+}
+
+type ddlSource struct {
+	alias string
+	table *ddlTable
+}
+
+// selectList returns the items of the first select list at the top level
+// and the tables in the FROM clause.
+func (s *DDLSchema) selectList(query []*token.Token) ([][]*token.Token, []*ddlSource) {
+	depth := 0
+	start, from, end := -1, -1, len(query)
+	for i, tok := range query {
+		switch tok.Kind {
+		case token.LParen:
+			depth++
+		case token.RParen:
+			depth--
+		}
+		if depth != 0 {
+			continue
+		}
+		switch {
+		case start < 0 && isWord(tok, "SELECT"):
+			start = i + 1
+		case start >= 0 && from < 0 && isWord(tok, "FROM"):
+			from = i
+		case from >= 0 && (isWord(tok, "WHERE") || isWord(tok, "GROUP") || isWord(tok, "ORDER") || isWord(tok, "LIMIT") ||
+			isWord(tok, "UNION") || isWord(tok, "HAVING") || isWord(tok, "WINDOW") || isWord(tok, "WITH")):
+			end = i
+		}
+		if end < len(query) {
+			break
+		}
+	}
+	if start < 0 {
+		return nil, nil
+	}
+	for start < len(query) && (isWord(query[start], "DISTINCT") || isWord(query[start], "ALL")) {
+		start++
+	}
+	if from < 0 {
+		return splitItems(query[start:end]), nil
+	}
+
+	sources := []*ddlSource{}
+	p := &ddlParser{words: query[from:end]}
+	for !p.done() {
+		if !p.acceptAny("FROM", "JOIN") && !p.acceptKind(token.Comma) {
+			p.skip()
+			continue
+		}
+		parts := p.name()
+		t := s.table(parts)
+		if t == nil {
+			continue
+		}
+		alias := parts[len(parts)-1]
+		p.accept("AS")
+		if word := p.peekWord(); word != "" && !isSourceKeyword(p.peek()) {
+			alias = word
+			p.pos++
+		}
+		sources = append(sources, &ddlSource{alias: alias, table: t})
+	}
+	return splitItems(query[start:from]), sources
+}
+
+// columnType returns the type of the column referenced by the expression,
+// such as col or alias.col. It is empty for the other expressions.
+func columnType(expr []*token.Token, sources []*ddlSource) string {
+	var qualifier *token.Token
+	switch {
+	case len(expr) == 1:
+	case len(expr) == 3 && expr[1].Kind == token.Period:
+		qualifier = expr[0]
+	default:
+		return ""
+	}
+	word, ok := expr[len(expr)-1].Value.(*token.SQLWord)
+	if !ok {
+		return ""
+	}
+	for _, src := range sources {
+		if qualifier != nil && !isWord(qualifier, src.alias) {
+			continue
+		}
+		if col, _ := src.table.column(word.NoQuoteString()); col != nil {
+			return col.Type
+		}
+	}
+	return ""
+}
+
+func isSourceKeyword(tok *token.Token) bool {
+	for _, keyword := range []string{"ON", "USING", "JOIN", "INNER", "LEFT", "RIGHT", "FULL", "OUTER", "CROSS", "NATURAL", "LATERAL", "STRAIGHT_JOIN"} {
+		if isWord(tok, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// ddlStatement is a statement of a DDL file.
+type ddlStatement struct {
+	// words are the tokens except for whitespaces and comments
+	words []*token.Token
+	// offsets are the indexes of the words in the tokens
+	offsets []int
+	tokens  []*token.Token
+	text    string
+}
+
+// textFrom returns the text of the statement from the word.
+func (stmt *ddlStatement) textFrom(word int) string {
+	return strings.TrimSpace(tokensText(stmt.tokens[stmt.offsets[word]:]))
+}
+
+// ddlStatements splits the DDL into the statements. The rest of the file is
+// ignored after the down migration marker of goose or dbmate.
+func ddlStatements(ddl string) []*ddlStatement {
+	tokenizer := token.NewTokenizer(strings.NewReader(ddl), &dialect.GenericSQLDialect{})
+	stmts := []*ddlStatement{}
+	cur := &ddlStatement{}
+	flush := func() {
+		if len(cur.words) > 0 {
+			cur.text = strings.TrimSpace(tokensText(cur.tokens))
+			stmts = append(stmts, cur)
+		}
+		cur = &ddlStatement{}
+	}
+	depth := 0
+	for {
+		tok, err := tokenizer.NextToken()
+		if err != nil {
+			// skip an invalid token unless no character is read
+			if tok != nil && tok.From != tok.To {
+				continue
+			}
+			break
+		}
+		switch tok.Kind {
+		case token.Comment:
+			if v, _ := tok.Value.(string); strings.Contains(v, "+goose Down") || strings.Contains(v, "migrate:down") {
+				flush()
+				return stmts
+			}
+			continue
+		case token.MultilineComment:
+			continue
+		case token.LParen:
+			depth++
+		case token.RParen:
+			depth--
+		case token.Semicolon:
+			if depth <= 0 {
+				flush()
+				depth = 0
+				continue
+			}
+		}
+		if tok.Kind != token.Whitespace {
+			cur.words = append(cur.words, tok)
+			cur.offsets = append(cur.offsets, len(cur.tokens))
+		}
+		cur.tokens = append(cur.tokens, tok)
+	}
+	flush()
+	return stmts
+}
+
+func tokenText(tok *token.Token) string {
+	switch v := tok.Value.(type) {
+	case *token.SQLWord:
+		return v.String()
+	case string:
+		switch tok.Kind {
+		case token.SingleQuotedString:
+			return "'" + strings.ReplaceAll(unquoteString(v), "'", "''") + "'"
+		case token.NationalStringLiteral:
+			return "N'" + strings.ReplaceAll(unquoteString(v), "'", "''") + "'"
+		}
+		return v
+	}
+	return ""
+}
+
+// unquoteString returns the content of a string literal token, the tokenizer
+// keeps the quotes.
+func unquoteString(s string) string {
+	s = strings.TrimPrefix(s, "'")
+	return strings.TrimSuffix(s, "'")
+}
+
+func tokensText(tokens []*token.Token) string {
+	var b strings.Builder
+	for _, tok := range tokens {
+		b.WriteString(tokenText(tok))
+	}
+	return b.String()
+}
+
+// joinTokens renders the words, such as a data type or a default value,
+// with the spaces between them.
+func joinTokens(words []*token.Token) string {
+	var b strings.Builder
+	for i, tok := range words {
+		if i > 0 {
+			prev := words[i-1]
+			switch {
+			case tok.Kind == token.LParen && prev.Kind == token.SQLKeyword,
+				tok.Kind == token.RParen, tok.Kind == token.Comma, tok.Kind == token.Period, tok.Kind == token.DoubleColon,
+				prev.Kind == token.LParen, prev.Kind == token.Period, prev.Kind == token.DoubleColon,
+				prev.Kind == token.Minus && i == 1:
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(tokenText(tok))
+	}
+	return b.String()
+}
+
+// splitItems splits the words by the commas at the top level.
+func splitItems(words []*token.Token) [][]*token.Token {
+	items := [][]*token.Token{}
+	depth, start := 0, 0
+	for i, tok := range words {
+		switch tok.Kind {
+		case token.LParen:
+			depth++
+		case token.RParen:
+			depth--
+		case token.Comma:
+			if depth == 0 {
+				items = append(items, words[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(words) {
+		items = append(items, words[start:])
+	}
+	return items
+}
+
+// ddlParser reads the words of a statement.
+type ddlParser struct {
+	words []*token.Token
+	pos   int
+}
+
+func (p *ddlParser) done() bool {
+	return p.pos >= len(p.words)
+}
+
+func (p *ddlParser) peek() *token.Token {
+	if p.done() {
+		return &token.Token{Kind: token.ILLEGAL}
+	}
+	return p.words[p.pos]
+}
+
+func (p *ddlParser) peekKind(kind token.Kind) bool {
+	return !p.done() && p.words[p.pos].Kind == kind
+}
+
+// peekWord returns the unquoted name of the next word, it is empty when the
+// next token is not a word.
+func (p *ddlParser) peekWord() string {
+	word, ok := p.peek().Value.(*token.SQLWord)
+	if p.peek().Kind != token.SQLKeyword || !ok {
+		return ""
+	}
+	return word.NoQuoteString()
+}
+
+// word reads a name.
+func (p *ddlParser) word() string {
+	word := p.peekWord()
+	if word != "" {
+		p.pos++
+	}
+	return word
+}
+
+// str reads a string literal.
+func (p *ddlParser) str() (string, bool) {
+	if !p.peekKind(token.SingleQuotedString) && !p.peekKind(token.NationalStringLiteral) {
+		return "", false
+	}
+	s, _ := p.words[p.pos].Value.(string)
+	p.pos++
+	return unquoteString(s), true
+}
+
+// accept reads the keywords when the next words are the keywords.
+func (p *ddlParser) accept(keywords ...string) bool {
+	if p.pos+len(keywords) > len(p.words) {
+		return false
+	}
+	for i, keyword := range keywords {
+		if !isWord(p.words[p.pos+i], keyword) {
+			return false
+		}
+	}
+	p.pos += len(keywords)
+	return true
+}
+
+// acceptAny reads a word when it is one of the keywords.
+func (p *ddlParser) acceptAny(keywords ...string) bool {
+	for _, keyword := range keywords {
+		if p.accept(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ddlParser) acceptKind(kind token.Kind) bool {
+	if !p.peekKind(kind) {
+		return false
+	}
+	p.pos++
+	return true
+}
+
+// name reads a qualified name such as schema.table.
+func (p *ddlParser) name() []string {
+	parts, next := readQualifiedName(p.words, p.pos)
+	p.pos = next
+	return parts
+}
+
+// skip reads a word, or the words to the closing parenthesis.
+func (p *ddlParser) skip() {
+	if p.peekKind(token.LParen) {
+		p.group()
+		return
+	}
+	p.pos++
+}
+
+// until reads the words to the word matching stop, skipping the words in
+// parentheses.
+func (p *ddlParser) until(stop func(*token.Token) bool) []*token.Token {
+	start := p.pos
+	for !p.done() && !stop(p.words[p.pos]) {
+		p.skip()
+	}
+	return p.words[start:p.pos]
+}
+
+// group reads the words in the parentheses and splits them by the commas.
+func (p *ddlParser) group() ([][]*token.Token, bool) {
+	if !p.peekKind(token.LParen) {
+		return nil, false
+	}
+	depth := 0
+	for i := p.pos; i < len(p.words); i++ {
+		switch p.words[i].Kind {
+		case token.LParen:
+			depth++
+		case token.RParen:
+			depth--
+			if depth == 0 {
+				items := splitItems(p.words[p.pos+1 : i])
+				p.pos = i + 1
+				return items, true
+			}
+		}
+	}
+	items := splitItems(p.words[p.pos+1:])
+	p.pos = len(p.words)
+	return items, true
+}
+
+// columnList reads the column names in the parentheses, the first word of
+// an item such as "name DESC" is the name.
+func (p *ddlParser) columnList() []string {
+	items, _ := p.group()
+	names := []string{}
+	for _, item := range items {
+		if name := (&ddlParser{words: item}).peekWord(); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// sortedTables returns the tables of the schema sorted by the name.
+func (s *DDLSchema) sortedTables(schemaName string) []*ddlTable {
+	tables := []*ddlTable{}
+	for _, t := range s.tables {
+		if schemaName == "" || strings.EqualFold(t.schema, schemaName) {
+			tables = append(tables, t)
+		}
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		if tables[i].schema != tables[j].schema {
+			return tables[i].schema < tables[j].schema
+		}
+		return tables[i].name < tables[j].name
+	})
+	return tables
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testSchemaDDL = `
+-- users of the service
+CREATE TABLE IF NOT EXISTS users (
+  id serial PRIMARY KEY,
+  name varchar(64) NOT NULL,
+  email character varying(255) NOT NULL UNIQUE,
+  created_at timestamp with time zone DEFAULT now()
+);
+COMMENT ON TABLE users IS 'users of the service';
+COMMENT ON COLUMN users.email IS 'login; unique';
+
+CREATE TABLE ` + "`orders`" + ` (
+  ` + "`id`" + ` int NOT NULL AUTO_INCREMENT,
+  ` + "`user_id`" + ` int NOT NULL,
+  ` + "`amount`" + ` decimal(10, 2) DEFAULT '0.00' COMMENT 'total amount',
+  PRIMARY KEY (` + "`id`" + `),
+  KEY ` + "`idx_user`" + ` (` + "`user_id`" + `),
+  CONSTRAINT ` + "`fk_user`" + ` FOREIGN KEY (` + "`user_id`" + `) REFERENCES ` + "`users`" + ` (` + "`id`" + `) ON DELETE CASCADE
+) ENGINE=InnoDB COMMENT='orders of users';
+
+CREATE VIEW user_orders AS
+SELECT u.id, u.name AS user_name, o.amount, count(*) cnt
+FROM users u JOIN orders o ON o.user_id = u.id
+GROUP BY u.id;
+
+CREATE UNIQUE INDEX idx_users_name ON users USING btree (name);
+CREATE TABLE audit.logs (id bigint, message text);
+`
+
+const testMigrationDDL = `
+-- +goose Up
+ALTER TABLE users ADD COLUMN nickname text, DROP COLUMN created_at;
+ALTER TABLE users RENAME COLUMN name TO full_name;
+ALTER TABLE audit.logs ADD COLUMN user_id int REFERENCES users;
+ALTER TABLE audit.logs RENAME TO audit_logs;
+
+-- +goose Down
+DROP TABLE users;
+`
+
+func TestDDLSchema(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "001_schema.sql"), []byte(testSchemaDDL), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "002_migration.sql"), []byte(testMigrationDDL), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "002_migration.down.sql"), []byte("DROP TABLE users;"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := DDLFiles(dir, []string{"."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{filepath.Join(dir, "001_schema.sql"), filepath.Join(dir, "002_migration.sql")}, files); diff != "" {
+		t.Errorf("unmatched files (- want, + got):\n%s", diff)
+	}
+	schema, err := LoadDDLSchema(DefaultOfflineSchema, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worker := NewWorker()
+	if err := worker.ReCache(context.Background(), NewDDLDBRepository("postgresql", schema)); err != nil {
+		t.Fatal(err)
+	}
+	dbCache := worker.Cache()
+
+	if diff := cmp.Diff([]string{"audit", "public"}, dbCache.SortedSchemas()); diff != "" {
+		t.Errorf("unmatched schemas (- want, + got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"orders", "users"}, dbCache.SortedTables()); diff != "" {
+		t.Errorf("unmatched tables (- want, + got):\n%s", diff)
+	}
+
+	users, ok := dbCache.ColumnDescs("users")
+	if !ok {
+		t.Fatal("not found users")
+	}
+	wantUsers := []*ColumnDesc{
+		{ColumnBase: ColumnBase{Schema: "public", Table: "users", Name: "id"}, Type: "serial", Null: "NO", Key: "YES"},
+		{ColumnBase: ColumnBase{Schema: "public", Table: "users", Name: "full_name"}, Type: "varchar(64)", Null: "NO"},
+		{ColumnBase: ColumnBase{Schema: "public", Table: "users", Name: "email"}, Type: "character varying(255)", Null: "NO", Comment: "login; unique"},
+		{ColumnBase: ColumnBase{Schema: "public", Table: "users", Name: "nickname"}, Type: "text", Null: "YES"},
+	}
+	if diff := cmp.Diff(wantUsers, users); diff != "" {
+		t.Errorf("unmatched users columns (- want, + got):\n%s", diff)
+	}
+	if got := dbCache.TableComment("public", "users"); got != "users of the service" {
+		t.Errorf("unmatched table comment, got %q", got)
+	}
+
+	orders, _ := dbCache.ColumnDescs("orders")
+	wantOrders := []*ColumnDesc{
+		{ColumnBase: ColumnBase{Schema: "public", Table: "orders", Name: "id"}, Type: "int", Null: "NO", Key: "YES", Extra: "auto_increment"},
+		{ColumnBase: ColumnBase{Schema: "public", Table: "orders", Name: "user_id"}, Type: "int", Null: "NO"},
+		{ColumnBase: ColumnBase{Schema: "public", Table: "orders", Name: "amount"}, Type: "decimal(10, 2)", Null: "YES", Default: sql.NullString{String: "'0.00'", Valid: true}, Comment: "total amount"},
+	}
+	if diff := cmp.Diff(wantOrders, orders); diff != "" {
+		t.Errorf("unmatched orders columns (- want, + got):\n%s", diff)
+	}
+	if got := dbCache.TableComment("public", "orders"); got != "orders of users" {
+		t.Errorf("unmatched table comment, got %q", got)
+	}
+
+	wantFK := &ForeignKey{{
+		{Schema: "public", Table: "orders", Name: "user_id"},
+		{Schema: "public", Table: "users", Name: "id"},
+	}}
+	if diff := cmp.Diff([]*ForeignKey{wantFK}, dbCache.ForeignKeys["users"]["orders"]); diff != "" {
+		t.Errorf("unmatched foreign keys (- want, + got):\n%s", diff)
+	}
+
+	view, ok := dbCache.View("user_orders")
+	if !ok {
+		t.Fatal("not found user_orders")
+	}
+	if view.Definition == "" || view.Materialized {
+		t.Errorf("unexpected view %+v", view)
+	}
+	viewCols, _ := dbCache.ColumnDescs("user_orders")
+	gotViewCols := [][2]string{}
+	for _, col := range viewCols {
+		gotViewCols = append(gotViewCols, [2]string{col.Name, col.Type})
+	}
+	if diff := cmp.Diff([][2]string{{"id", "serial"}, {"user_name", "varchar(64)"}, {"amount", "decimal(10, 2)"}, {"cnt", ""}}, gotViewCols); diff != "" {
+		t.Errorf("unmatched view columns (- want, + got):\n%s", diff)
+	}
+
+	indexes, _ := dbCache.TableIndexes("users")
+	if diff := cmp.Diff([]*IndexDesc{{Schema: "public", Table: "users", Name: "idx_users_name", Columns: []string{"full_name"}, Unique: true, Type: "BTREE"}}, indexes); diff != "" {
+		t.Errorf("unmatched indexes (- want, + got):\n%s", diff)
+	}
+
+	// the schema other than the default is loaded on reference
+	if err := worker.LoadSchemas(context.Background(), "audit"); err != nil {
+		t.Fatal(err)
+	}
+	logs, ok := worker.Cache().ColumnDatabase("audit", "audit_logs")
+	if !ok || len(logs) != 3 || logs[2].Name != "user_id" {
+		t.Errorf("unexpected audit_logs columns %v", logs)
+	}
+
+	ddl, err := NewDDLDBRepository("postgresql", schema).ShowCreateTable(context.Background(), "public", "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "CREATE TABLE IF NOT EXISTS users"; len(ddl) < len(want) || ddl[:len(want)] != want {
+		t.Errorf("unexpected ddl %q", ddl)
+	}
+}
+
+func TestDDLSchemaDrop(t *testing.T) {
+	schema := NewDDLSchema(DefaultOfflineSchema)
+	schema.Apply(`
+CREATE TABLE a (id int PRIMARY KEY);
+CREATE TABLE b (id int, a_id int, FOREIGN KEY (a_id) REFERENCES a (id));
+CREATE TABLE c AS SELECT b.* FROM b;
+RENAME TABLE c TO d;
+DROP TABLE IF EXISTS a, x;
+`)
+	repo := NewDDLDBRepository("mysql", schema)
+	tables, _ := repo.SchemaTables(context.Background())
+	if diff := cmp.Diff(map[string][]string{"public": {"b", "d"}}, tables); diff != "" {
+		t.Errorf("unmatched tables (- want, + got):\n%s", diff)
+	}
+	fks, _ := repo.DescribeForeignKeysBySchema(context.Background(), "public")
+	if len(fks) != 0 {
+		t.Errorf("the foreign keys to the dropped table remain, %v", fks)
+	}
+	cols, _ := repo.DescribeDatabaseTableBySchema(context.Background(), "public")
+	names := []string{}
+	for _, col := range cols {
+		names = append(names, col.Table+"."+col.Name)
+	}
+	if diff := cmp.Diff([]string{"b.id", "b.a_id", "d.id", "d.a_id"}, names); diff != "" {
+		t.Errorf("unmatched columns (- want, + got):\n%s", diff)
+	}
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/yaamai/sqls/dialect"
+	"github.com/yaamai/sqls/token"
+)
+
+// DDLTarget is a table or a view changed by a DDL statement.
+type DDLTarget struct {
+	Schema string
+	Name   string
+}
+
+// IsDDL reports whether the statement changes the schema, such as CREATE,
+// ALTER, DROP, RENAME and COMMENT statements.
+func IsDDL(query string) bool {
+	typ, _ := QueryExecType(strings.ToUpper(strings.TrimSpace(query)), "")
+	for _, prefix := range []string{"CREATE", "ALTER", "DROP", "RENAME", "COMMENT"} {
+		if typ == prefix || strings.HasPrefix(typ, prefix+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// DDLTargets returns the tables and views changed by the DDL statement. It
+// returns false when the statement changes other objects, such as functions
+// and schemas, or the targets cannot be read.
+func DDLTargets(query string) ([]*DDLTarget, bool) {
+	words := ddlWords(query)
+	typ, _ := QueryExecType(strings.ToUpper(strings.TrimSpace(query)), "")
+
+	switch typ {
+	case "CREATE TABLE", "CREATE TABLE AS", "CREATE FOREIGN TABLE",
+		"CREATE VIEW", "CREATE MATERIALIZED VIEW",
+		"ALTER FOREIGN TABLE", "ALTER MATERIALIZED VIEW", "ALTER VIEW":
+		targets, _ := readTargetNames(words, indexOfWord(words, "TABLE", "VIEW")+1, false)
+		return targets, len(targets) > 0
+	case "ALTER TABLE":
+		targets, next := readTargetNames(words, indexOfWord(words, "TABLE")+1, false)
+		if len(targets) == 0 {
+			return nil, false
+		}
+		// ALTER TABLE name RENAME TO new_name
+		if i := indexOfWord(words[next:], "RENAME"); i >= 0 && i+2 < len(words[next:]) && isWord(words[next+i+1], "TO") {
+			renamed, _ := readTargetNames(words, next+i+2, false)
+			for _, target := range renamed {
+				if target.Schema == "" {
+					target.Schema = targets[0].Schema
+				}
+			}
+			targets = append(targets, renamed...)
+		}
+		return targets, true
+	case "CREATE INDEX":
+		// CREATE INDEX name ON table (...)
+		targets, _ := readTargetNames(words, indexOfWord(words, "ON")+1, false)
+		return targets, len(targets) > 0
+	case "DROP TABLE", "DROP FOREIGN TABLE", "DROP VIEW", "DROP MATERIALIZED VIEW":
+		targets, _ := readTargetNames(words, indexOfWord(words, "TABLE", "VIEW")+1, true)
+		return targets, len(targets) > 0
+	case "RENAME":
+		// RENAME TABLE a TO b, c TO d (mysql)
+		if len(words) < 2 || !isWord(words[1], "TABLE") {
+			return nil, false
+		}
+		targets := []*DDLTarget{}
+		for i := 2; i < len(words); {
+			names, next := readTargetNames(words, i, false)
+			if len(names) == 0 {
+				break
+			}
+			targets = append(targets, names...)
+			i = next
+			if i < len(words) && (isWord(words[i], "TO") || words[i].Kind == token.Comma) {
+				i++
+			}
+		}
+		return targets, len(targets) > 0
+	case "COMMENT":
+		// COMMENT ON TABLE name IS ..., COMMENT ON COLUMN name.column IS ...
+		if len(words) < 3 || !isWord(words[1], "ON") {
+			return nil, false
+		}
+		switch {
+		case isWord(words[2], "TABLE"), isWord(words[2], "VIEW"):
+			targets, _ := readTargetNames(words, 3, false)
+			return targets, len(targets) > 0
+		case isWord(words[2], "COLUMN"):
+			parts, _ := readQualifiedName(words, 3)
+			if len(parts) < 2 {
+				return nil, false
+			}
+			return []*DDLTarget{newDDLTarget(parts[:len(parts)-1])}, true
+		}
+	}
+	return nil, false
+}
+
+// ddlWords returns the tokens except for whitespaces and comments. The
+// tokens before an invalid token are returned.
+func ddlWords(query string) []*token.Token {
+	tokenizer := token.NewTokenizer(strings.NewReader(query), &dialect.GenericSQLDialect{})
+	words := []*token.Token{}
+	for {
+		tok, err := tokenizer.NextToken()
+		if err != nil {
+			return words
+		}
+		switch tok.Kind {
+		case token.Whitespace, token.Comment, token.MultilineComment:
+			continue
+		}
+		words = append(words, tok)
+	}
+}
+
+// readTargetNames reads a qualified name after the optional IF [NOT] EXISTS
+// and ONLY, or the names separated by commas with multiple. It returns the
+// index after the names.
+func readTargetNames(words []*token.Token, i int, multiple bool) ([]*DDLTarget, int) {
+	if i <= 0 {
+		return nil, i
+	}
+	for i < len(words) && (isWord(words[i], "IF") || isWord(words[i], "NOT") || isWord(words[i], "EXISTS") || isWord(words[i], "ONLY")) {
+		i++
+	}
+	targets := []*DDLTarget{}
+	for {
+		parts, next := readQualifiedName(words, i)
+		if len(parts) == 0 {
+			return targets, i
+		}
+		targets = append(targets, newDDLTarget(parts))
+		i = next
+		if !multiple || i >= len(words) || words[i].Kind != token.Comma {
+			return targets, i
+		}
+		i++
+	}
+}
+
+// readQualifiedName reads a name such as schema.table, it returns the index
+// after the name.
+func readQualifiedName(words []*token.Token, i int) ([]string, int) {
+	parts := []string{}
+	for i < len(words) {
+		word, ok := words[i].Value.(*token.SQLWord)
+		if words[i].Kind != token.SQLKeyword || !ok {
+			break
+		}
+		parts = append(parts, word.NoQuoteString())
+		i++
+		if i >= len(words) || words[i].Kind != token.Period {
+			break
+		}
+		i++
+	}
+	return parts, i
+}
+
+func newDDLTarget(parts []string) *DDLTarget {
+	target := &DDLTarget{Name: parts[len(parts)-1]}
+	if len(parts) > 1 {
+		target.Schema = parts[len(parts)-2]
+	}
+	return target
+}
+
+func indexOfWord(words []*token.Token, keywords ...string) int {
+	for i, word := range words {
+		for _, keyword := range keywords {
+			if isWord(word, keyword) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isWord(tok *token.Token, keyword string) bool {
+	word, ok := tok.Value.(*token.SQLWord)
+	return tok.Kind == token.SQLKeyword && ok && word.QuoteStyle == 0 && strings.EqualFold(word.Value, keyword)
+}
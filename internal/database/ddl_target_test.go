@@ -0,0 +1,53 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDDLTargets(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		want   []*DDLTarget
+		wantOK bool
+	}{
+		{"create table", "create table if not exists city (id int)", []*DDLTarget{{Name: "city"}}, true},
+		{"create temporary table", "CREATE TEMPORARY TABLE world.city AS SELECT 1", []*DDLTarget{{Schema: "world", Name: "city"}}, true},
+		{"create or replace view", "CREATE OR REPLACE VIEW big_city AS SELECT * FROM city", []*DDLTarget{{Name: "big_city"}}, true},
+		{"alter table", "ALTER TABLE ONLY \"world\".\"City\" ADD COLUMN area int", []*DDLTarget{{Schema: "world", Name: "City"}}, true},
+		{"alter table rename", "ALTER TABLE world.city RENAME TO town", []*DDLTarget{{Schema: "world", Name: "city"}, {Schema: "world", Name: "town"}}, true},
+		{"alter table rename column", "ALTER TABLE city RENAME COLUMN name TO title", []*DDLTarget{{Name: "city"}}, true},
+		{"drop tables", "DROP TABLE IF EXISTS city, world.country CASCADE", []*DDLTarget{{Name: "city"}, {Schema: "world", Name: "country"}}, true},
+		{"drop materialized view", "DROP MATERIALIZED VIEW big_city", []*DDLTarget{{Name: "big_city"}}, true},
+		{"create index", "CREATE UNIQUE INDEX idx_city ON world.city (name)", []*DDLTarget{{Schema: "world", Name: "city"}}, true},
+		{"rename tables", "RENAME TABLE city TO town, country TO nation", []*DDLTarget{{Name: "city"}, {Name: "town"}, {Name: "country"}, {Name: "nation"}}, true},
+		{"comment on column", "COMMENT ON COLUMN world.city.name IS 'local name'", []*DDLTarget{{Schema: "world", Name: "city"}}, true},
+		{"comment on table", "COMMENT ON TABLE city IS 'cities'", []*DDLTarget{{Name: "city"}}, true},
+		{"create function", "CREATE FUNCTION f() RETURNS int AS 'SELECT 1' LANGUAGE sql", nil, false},
+		{"drop index", "DROP INDEX idx_city", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !IsDDL(tt.query) {
+				t.Errorf("not DDL, %q", tt.query)
+			}
+			got, ok := DDLTargets(tt.query)
+			if ok != tt.wantOK {
+				t.Errorf("unmatched ok, want %v, got %v", tt.wantOK, ok)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched targets (- want, + got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestIsDDL(t *testing.T) {
+	for _, query := range []string{"SELECT * FROM city", "INSERT INTO city VALUES (1)", "UPDATE city SET name = 'a'"} {
+		if IsDDL(query) {
+			t.Errorf("unexpected DDL, %q", query)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestShowCreateTable(t *testing.T) {
+	ctx := context.Background()
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	for _, q := range []string{
+		"CREATE TABLE country (code TEXT PRIMARY KEY, name TEXT NOT NULL)",
+		"CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT NOT NULL, countrycode TEXT REFERENCES country (code), population BIGINT DEFAULT 0)",
+		"CREATE VIEW big_city AS SELECT * FROM city WHERE population > 1000000",
+	} {
+		if _, err := conn.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := NewSQLite3DBRepository(conn)
+
+	tests := []struct {
+		name  string
+		table string
+		want  string
+	}{
+		{
+			name:  "table",
+			table: "city",
+			want:  "CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT NOT NULL, countrycode TEXT REFERENCES country (code), population BIGINT DEFAULT 0);",
+		},
+		{
+			name:  "view",
+			table: "BIG_CITY",
+			want:  "CREATE VIEW big_city AS SELECT * FROM city WHERE population > 1000000;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.ShowCreateTable(ctx, "", tt.table)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched ddl (- want, + got):\n%s", d)
+			}
+		})
+	}
+
+	if _, err := repo.ShowCreateTable(ctx, "", "town"); err == nil {
+		t.Error("expected error for unknown table")
+	}
+
+	t.Run("reconstruct", func(t *testing.T) {
+		got, err := reconstructTableDDL(ctx, repo, "", "city")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `CREATE TABLE city (
+  id INTEGER,
+  name TEXT NOT NULL,
+  countrycode TEXT,
+  population BIGINT DEFAULT 0,
+  PRIMARY KEY (id),
+  FOREIGN KEY (countrycode) REFERENCES country (code)
+);`
+		if d := cmp.Diff(want, got); d != "" {
+			t.Errorf("unmatched ddl (- want, + got):\n%s", d)
+		}
+	})
+}
+
+func TestTableDDL(t *testing.T) {
+	cols := []*ColumnDesc{
+		{ColumnBase: ColumnBase{Schema: "world", Table: "city", Name: "ID"}, Type: "int(11)", Null: "NO", Key: "PRI"},
+		{ColumnBase: ColumnBase{Schema: "world", Table: "city", Name: "Name"}, Type: "char(35)", Null: "NO"},
+		{ColumnBase: ColumnBase{Schema: "world", Table: "city", Name: "CountryCode"}, Type: "char(3)", Null: "NO"},
+	}
+	indexes := []*IndexDesc{
+		{Schema: "world", Table: "city", Name: "PRIMARY", Columns: []string{"ID"}, Unique: true},
+		{Schema: "world", Table: "city", Name: "idx_city_country", Columns: []string{"CountryCode", "Name"}},
+	}
+	got := TableDDL("world", cols, indexes, "mysql")
+	want := `CREATE TABLE world.city (
+  ID int(11) NOT NULL,
+  Name char(35) NOT NULL,
+  CountryCode char(3) NOT NULL,
+  PRIMARY KEY (ID)
+);
+CREATE INDEX idx_city_country ON world.city (CountryCode, Name);`
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unmatched ddl (- want, + got):\n%s", d)
+	}
+}
+
+func TestCreateViewDDL(t *testing.T) {
+	got := createViewDDL("public", "big city", " SELECT * FROM city", "postgresql")
+	want := "CREATE VIEW public.\"big city\" AS\nSELECT * FROM city"
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unmatched ddl (- want, + got):\n%s", d)
+	}
+	if got := createViewDDL("dbo", "v", "CREATE VIEW v AS SELECT 1", "mssql"); got != "CREATE VIEW v AS SELECT 1" {
+		t.Errorf("unexpected ddl %q", got)
+	}
+}
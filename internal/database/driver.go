@@ -60,6 +60,10 @@ func Open(cfg *DBConfig) (*DBConnection, error) {
 	if !ok {
 		return nil, fmt.Errorf("driver not found, %s", cfg.Driver)
 	}
+	cfg, err := cfg.withSecrets()
+	if err != nil {
+		return nil, err
+	}
 	return OpenFn(cfg)
 }
 
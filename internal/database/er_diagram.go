@@ -0,0 +1,268 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/yaamai/sqls/dialect"
+)
+
+type ERDiagramFormat string
+
+const (
+	ERDiagramFormatMermaid ERDiagramFormat = "mermaid"
+	ERDiagramFormatDOT     ERDiagramFormat = "dot"
+)
+
+// ERDiagram is the tables of a schema and the foreign keys between them.
+type ERDiagram struct {
+	Schema string
+	Tables []*ERDiagramTable
+	// ForeignKeys references tables of the diagram only
+	ForeignKeys []*ForeignKey
+}
+
+type ERDiagramTable struct {
+	Name    string
+	Columns []*ColumnDesc
+	// primaryKeys and foreignKeys are keyed by the upper cased column name
+	primaryKeys map[string]struct{}
+	foreignKeys map[string]struct{}
+}
+
+// NewERDiagram builds the diagram of the tables of the schema, or all tables
+// of the schema if tableNames is empty. The default schema is used if
+// schemaName is empty. The foreign keys of the cache are used if fks is nil,
+// the cache has them for the default schema only.
+func NewERDiagram(dbCache *DBCache, driver dialect.DatabaseDriver, schemaName string, tableNames []string, fks []*ForeignKey) (*ERDiagram, error) {
+	if schemaName == "" {
+		schemaName = dbCache.defaultSchema
+	}
+	if name, ok := dbCache.Database(schemaName); ok {
+		schemaName = name
+	}
+	if len(tableNames) == 0 {
+		tableNames, _ = dbCache.SortedTablesByDBName(schemaName)
+	}
+	if fks == nil {
+		fks = dbCache.schemaForeignKeys(schemaName)
+	}
+
+	d := &ERDiagram{Schema: schemaName}
+	tables := map[string]*ERDiagramTable{}
+	for _, tableName := range tableNames {
+		cols, ok := dbCache.ColumnDatabase(schemaName, tableName)
+		if !ok || len(cols) == 0 {
+			return nil, fmt.Errorf("table not found, %q", tableName)
+		}
+		if _, ok := tables[strings.ToUpper(tableName)]; ok {
+			continue
+		}
+		t := &ERDiagramTable{
+			Name:        cols[0].Table,
+			Columns:     cols,
+			primaryKeys: map[string]struct{}{},
+			foreignKeys: map[string]struct{}{},
+		}
+		for _, col := range cols {
+			if isPrimaryKey(col, driver) {
+				t.primaryKeys[strings.ToUpper(col.Name)] = struct{}{}
+			}
+		}
+		tables[strings.ToUpper(t.Name)] = t
+		d.Tables = append(d.Tables, t)
+	}
+	sort.Slice(d.Tables, func(i, j int) bool { return d.Tables[i].Name < d.Tables[j].Name })
+
+	seen := map[string]struct{}{}
+	for _, fk := range fks {
+		if len(*fk) == 0 || !strings.EqualFold((*fk)[0][0].Schema, schemaName) {
+			continue
+		}
+		child, ok := tables[strings.ToUpper((*fk)[0][0].Table)]
+		if !ok {
+			continue
+		}
+		for _, pair := range *fk {
+			child.foreignKeys[strings.ToUpper(pair[0].Name)] = struct{}{}
+		}
+		// the referenced table must be in the diagram to draw the relation
+		if !strings.EqualFold((*fk)[0][1].Schema, schemaName) {
+			continue
+		}
+		if _, ok := tables[strings.ToUpper((*fk)[0][1].Table)]; !ok {
+			continue
+		}
+		signature := foreignKeySignature(fk)
+		if _, ok := seen[signature]; ok {
+			continue
+		}
+		seen[signature] = struct{}{}
+		d.ForeignKeys = append(d.ForeignKeys, fk)
+	}
+	sort.Slice(d.ForeignKeys, func(i, j int) bool {
+		return foreignKeySignature(d.ForeignKeys[i]) < foreignKeySignature(d.ForeignKeys[j])
+	})
+	return d, nil
+}
+
+// schemaForeignKeys returns the cached foreign keys of the tables in the
+// schema, the cache has each foreign key under both tables.
+func (dc *DBCache) schemaForeignKeys(schemaName string) []*ForeignKey {
+	fks := []*ForeignKey{}
+	for _, refs := range dc.ForeignKeys {
+		for _, tableFKs := range refs {
+			for _, fk := range tableFKs {
+				if len(*fk) > 0 && strings.EqualFold((*fk)[0][0].Schema, schemaName) {
+					fks = append(fks, fk)
+				}
+			}
+		}
+	}
+	return fks
+}
+
+// Render writes the diagram in the format.
+func (d *ERDiagram) Render(format ERDiagramFormat) (string, error) {
+	switch format {
+	case ERDiagramFormatMermaid:
+		return d.Mermaid(), nil
+	case ERDiagramFormatDOT:
+		return d.DOT(), nil
+	}
+	return "", fmt.Errorf("unsupported diagram format %q, specify mermaid or dot", format)
+}
+
+func (t *ERDiagramTable) keyMarkers(col *ColumnDesc) []string {
+	markers := []string{}
+	if _, ok := t.primaryKeys[strings.ToUpper(col.Name)]; ok {
+		markers = append(markers, "PK")
+	}
+	if _, ok := t.foreignKeys[strings.ToUpper(col.Name)]; ok {
+		markers = append(markers, "FK")
+	}
+	return markers
+}
+
+func (d *ERDiagram) table(name string) *ERDiagramTable {
+	for _, t := range d.Tables {
+		if strings.EqualFold(t.Name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// optionalForeignKey reports whether a column of the foreign key is nullable,
+// that is, the referencing row may have no referenced row.
+func (d *ERDiagram) optionalForeignKey(fk *ForeignKey) bool {
+	t := d.table((*fk)[0][0].Table)
+	if t == nil {
+		return true
+	}
+	for _, pair := range *fk {
+		for _, col := range t.Columns {
+			if strings.EqualFold(col.Name, pair[0].Name) && !isNotNull(col) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Mermaid renders the diagram as a Mermaid erDiagram.
+func (d *ERDiagram) Mermaid() string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintln(buf, "erDiagram")
+	for _, t := range d.Tables {
+		fmt.Fprintf(buf, "    %s {\n", mermaidName(t.Name))
+		for _, col := range t.Columns {
+			fmt.Fprintf(buf, "        %s %s", mermaidName(col.Type), mermaidName(col.Name))
+			if markers := t.keyMarkers(col); len(markers) > 0 {
+				fmt.Fprintf(buf, " %s", strings.Join(markers, ", "))
+			}
+			if comment := strings.TrimSpace(col.Comment); comment != "" {
+				fmt.Fprintf(buf, " \"%s\"", mermaidCommentReplacer.Replace(comment))
+			}
+			fmt.Fprintln(buf)
+		}
+		fmt.Fprintln(buf, "    }")
+	}
+	for _, fk := range d.ForeignKeys {
+		parent := "||"
+		if d.optionalForeignKey(fk) {
+			parent = "|o"
+		}
+		cols := []string{}
+		for _, pair := range *fk {
+			cols = append(cols, pair[0].Name)
+		}
+		fmt.Fprintf(buf, "    %s %s--o{ %s : \"%s\"\n", mermaidName((*fk)[0][1].Table), parent, mermaidName((*fk)[0][0].Table), mermaidCommentReplacer.Replace(strings.Join(cols, ", ")))
+	}
+	return buf.String()
+}
+
+var mermaidCommentReplacer = strings.NewReplacer(`"`, "'", "\r\n", " ", "\n", " ")
+
+// mermaidName replaces the characters which Mermaid does not accept in
+// entity names, attribute types and attribute names.
+func mermaidName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '_', r == '-', r == '(', r == ')', r == '[', r == ']':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	s := b.String()
+	if s == "" || !(s[0] >= 'a' && s[0] <= 'z' || s[0] >= 'A' && s[0] <= 'Z' || s[0] == '_') {
+		s = "_" + s
+	}
+	return s
+}
+
+// DOT renders the diagram as a Graphviz digraph, the edges go from the
+// referencing column to the referenced column.
+func (d *ERDiagram) DOT() string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "digraph %s {\n", dotQuote(d.Schema))
+	fmt.Fprintln(buf, "    graph [rankdir=LR];")
+	fmt.Fprintln(buf, "    node [shape=plaintext];")
+	for _, t := range d.Tables {
+		fmt.Fprintf(buf, "    %s [label=<\n", dotQuote(t.Name))
+		fmt.Fprintln(buf, `        <table border="0" cellborder="1" cellspacing="0">`)
+		fmt.Fprintf(buf, "        <tr><td colspan=\"3\"><b>%s</b></td></tr>\n", html.EscapeString(t.Name))
+		for _, col := range t.Columns {
+			fmt.Fprintf(buf, "        <tr><td port=\"%s\" align=\"left\">%s</td><td align=\"left\">%s</td><td>%s</td></tr>\n",
+				html.EscapeString(col.Name), html.EscapeString(col.Name), html.EscapeString(col.Type), strings.Join(t.keyMarkers(col), ", "))
+		}
+		fmt.Fprintln(buf, "        </table>")
+		fmt.Fprintln(buf, "    >];")
+	}
+	for _, fk := range d.ForeignKeys {
+		pair := (*fk)[0]
+		fmt.Fprintf(buf, "    %s:%s -> %s:%s", dotQuote(pair[0].Table), dotQuote(pair[0].Name), dotQuote(pair[1].Table), dotQuote(pair[1].Name))
+		if len(*fk) > 1 {
+			cols := []string{}
+			for _, pair := range *fk {
+				cols = append(cols, pair[0].Name)
+			}
+			fmt.Fprintf(buf, " [label=%s]", dotQuote(strings.Join(cols, ", ")))
+		}
+		fmt.Fprintln(buf, ";")
+	}
+	fmt.Fprintln(buf, "}")
+	return buf.String()
+}
+
+var dotQuoteReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func dotQuote(id string) string {
+	return `"` + dotQuoteReplacer.Replace(id) + `"`
+}
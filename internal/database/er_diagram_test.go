@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestERDiagram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.yml")
+	if err := os.WriteFile(path, []byte(testSchemaFileYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	worker := NewWorker()
+	if err := worker.ReCache(context.Background(), openTestSchemaFile(t, path)); err != nil {
+		t.Fatal(err)
+	}
+	dbCache := worker.Cache()
+
+	d, err := NewERDiagram(dbCache, "postgresql", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMermaid := `erDiagram
+    orders {
+        integer id PK
+        integer user_id FK
+    }
+    users {
+        integer id PK
+        text name "display name"
+    }
+    users |o--o{ orders : "user_id"
+`
+	if diff := cmp.Diff(wantMermaid, d.Mermaid()); diff != "" {
+		t.Errorf("unmatched mermaid (- want, + got):\n%s", diff)
+	}
+	wantDOT := `digraph "public" {
+    graph [rankdir=LR];
+    node [shape=plaintext];
+    "orders" [label=<
+        <table border="0" cellborder="1" cellspacing="0">
+        <tr><td colspan="3"><b>orders</b></td></tr>
+        <tr><td port="id" align="left">id</td><td align="left">integer</td><td>PK</td></tr>
+        <tr><td port="user_id" align="left">user_id</td><td align="left">integer</td><td>FK</td></tr>
+        </table>
+    >];
+    "users" [label=<
+        <table border="0" cellborder="1" cellspacing="0">
+        <tr><td colspan="3"><b>users</b></td></tr>
+        <tr><td port="id" align="left">id</td><td align="left">integer</td><td>PK</td></tr>
+        <tr><td port="name" align="left">name</td><td align="left">text</td><td></td></tr>
+        </table>
+    >];
+    "orders":"user_id" -> "users":"id";
+}
+`
+	if diff := cmp.Diff(wantDOT, d.DOT()); diff != "" {
+		t.Errorf("unmatched dot (- want, + got):\n%s", diff)
+	}
+
+	// the relation is drawn only between the chosen tables
+	d, err = NewERDiagram(dbCache, "postgresql", "public", []string{"orders"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Tables) != 1 || len(d.ForeignKeys) != 0 {
+		t.Errorf("unexpected diagram %+v", d)
+	}
+	if _, err := NewERDiagram(dbCache, "postgresql", "", []string{"missing"}, nil); err == nil {
+		t.Error("expected an error for the missing table")
+	}
+	if _, err := d.Render("plantuml"); err == nil {
+		t.Error("expected an error for the unsupported format")
+	}
+}
+
+func Test_mermaidName(t *testing.T) {
+	tests := map[string]string{
+		"users":                  "users",
+		"character varying(255)": "character_varying(255)",
+		"numeric(10,2)":          "numeric(10_2)",
+		"1st":                    "_1st",
+		"order items":            "order_items",
+	}
+	for name, want := range tests {
+		if got := mermaidName(name); got != want {
+			t.Errorf("mermaidName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PlanNode is one operation of an execution plan.
+type PlanNode struct {
+	Operation string
+	Object    string
+	Detail    string
+	Cost      *float64
+	Rows      *float64
+	Children  []*PlanNode
+}
+
+func (n *PlanNode) label() string {
+	items := []string{n.Operation}
+	if n.Object != "" {
+		items = append(items, "on "+n.Object)
+	}
+	estimates := []string{}
+	if n.Cost != nil {
+		estimates = append(estimates, "cost="+strconv.FormatFloat(*n.Cost, 'f', -1, 64))
+	}
+	if n.Rows != nil {
+		estimates = append(estimates, "rows="+strconv.FormatFloat(*n.Rows, 'f', -1, 64))
+	}
+	if len(estimates) > 0 {
+		items = append(items, "("+strings.Join(estimates, " ")+")")
+	}
+	if n.Detail != "" {
+		items = append(items, n.Detail)
+	}
+	return strings.Join(items, " ")
+}
+
+// PlanDoc renders the plan as an indented tree.
+func PlanDoc(root *PlanNode) string {
+	buf := new(bytes.Buffer)
+	writePlanNode(buf, root, 0)
+	return buf.String()
+}
+
+func writePlanNode(w io.Writer, node *PlanNode, depth int) {
+	if depth == 0 {
+		fmt.Fprintln(w, node.label())
+	} else {
+		fmt.Fprintf(w, "%s-> %s", strings.Repeat("   ", depth-1), node.label())
+		fmt.Fprintln(w)
+	}
+	for _, child := range node.Children {
+		writePlanNode(w, child, depth+1)
+	}
+}
+
+type flatPlanNode struct {
+	id       int64
+	parentID int64
+	node     *PlanNode
+}
+
+// buildPlanTree links plan rows that reference their parent by id.
+func buildPlanTree(flatNodes []*flatPlanNode) *PlanNode {
+	nodes := make(map[int64]*PlanNode, len(flatNodes))
+	for _, fn := range flatNodes {
+		nodes[fn.id] = fn.node
+	}
+	roots := []*PlanNode{}
+	for _, fn := range flatNodes {
+		if parent, ok := nodes[fn.parentID]; ok && fn.parentID != fn.id {
+			parent.Children = append(parent.Children, fn.node)
+		} else {
+			roots = append(roots, fn.node)
+		}
+	}
+	if len(roots) == 1 {
+		return roots[0]
+	}
+	return &PlanNode{Operation: "QUERY PLAN", Children: roots}
+}
+
+func parsePostgreSQLPlan(planJSON []byte) (*PlanNode, error) {
+	var plans []struct {
+		Plan map[string]interface{} `json:"Plan"`
+	}
+	if err := json.Unmarshal(planJSON, &plans); err != nil {
+		return nil, fmt.Errorf("cannot parse plan, %w", err)
+	}
+	if len(plans) == 0 || plans[0].Plan == nil {
+		return nil, fmt.Errorf("empty plan")
+	}
+	return postgreSQLPlanNode(plans[0].Plan), nil
+}
+
+func postgreSQLPlanNode(plan map[string]interface{}) *PlanNode {
+	node := &PlanNode{
+		Operation: jsonString(plan, "Node Type"),
+		Cost:      jsonNumber(plan, "Total Cost"),
+		Rows:      jsonNumber(plan, "Plan Rows"),
+	}
+	if joinType := jsonString(plan, "Join Type"); joinType != "" && joinType != "Inner" {
+		node.Operation = joinType + " " + node.Operation
+	}
+	if relation := jsonString(plan, "Relation Name"); relation != "" {
+		node.Object = relation
+		if alias := jsonString(plan, "Alias"); alias != "" && alias != relation {
+			node.Object += " " + alias
+		}
+	}
+	if index := jsonString(plan, "Index Name"); index != "" {
+		node.Detail = "using " + index
+	}
+	if children, ok := plan["Plans"].([]interface{}); ok {
+		for _, child := range children {
+			if childPlan, ok := child.(map[string]interface{}); ok {
+				node.Children = append(node.Children, postgreSQLPlanNode(childPlan))
+			}
+		}
+	}
+	return node
+}
+
+func parseMySQLPlan(planJSON []byte) (*PlanNode, error) {
+	var plan map[string]interface{}
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		return nil, fmt.Errorf("cannot parse plan, %w", err)
+	}
+	queryBlock, ok := plan["query_block"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("query_block not found in plan")
+	}
+	return mysqlPlanNode("query_block", queryBlock), nil
+}
+
+// mysqlPlanNode converts an object of the MySQL JSON plan. Nested objects
+// and arrays of objects become child operations.
+func mysqlPlanNode(name string, plan map[string]interface{}) *PlanNode {
+	node := &PlanNode{
+		Operation: strings.ReplaceAll(name, "_", " "),
+		Object:    jsonString(plan, "table_name"),
+	}
+	if costInfo, ok := plan["cost_info"].(map[string]interface{}); ok {
+		node.Cost = jsonNumber(costInfo, "query_cost")
+		if node.Cost == nil {
+			node.Cost = jsonNumber(costInfo, "prefix_cost")
+		}
+	}
+	node.Rows = jsonNumber(plan, "rows_examined_per_scan")
+	details := []string{}
+	if accessType := jsonString(plan, "access_type"); accessType != "" {
+		details = append(details, "access_type="+accessType)
+	}
+	if key := jsonString(plan, "key"); key != "" {
+		details = append(details, "key="+key)
+	}
+	node.Detail = strings.Join(details, " ")
+
+	keys := make([]string, 0, len(plan))
+	for k := range plan {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == "cost_info" {
+			continue
+		}
+		switch v := plan[k].(type) {
+		case map[string]interface{}:
+			node.Children = append(node.Children, mysqlPlanNode(k, v))
+		case []interface{}:
+			group := &PlanNode{Operation: strings.ReplaceAll(k, "_", " ")}
+			for _, elem := range v {
+				elemMap, ok := elem.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				group.Children = append(group.Children, mysqlPlanNode("", elemMap).Children...)
+			}
+			if len(group.Children) > 0 {
+				node.Children = append(node.Children, group)
+			}
+		}
+	}
+	return node
+}
+
+func parseMssqlPlan(planXML string) (*PlanNode, error) {
+	decoder := xml.NewDecoder(strings.NewReader(planXML))
+	var (
+		stack []*PlanNode
+		root  *PlanNode
+	)
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse plan, %w", err)
+		}
+		switch elem := tok.(type) {
+		case xml.StartElement:
+			switch elem.Name.Local {
+			case "RelOp":
+				node := &PlanNode{
+					Operation: xmlAttr(elem, "PhysicalOp"),
+					Cost:      parseNumber(xmlAttr(elem, "EstimatedTotalSubtreeCost")),
+					Rows:      parseNumber(xmlAttr(elem, "EstimateRows")),
+				}
+				if logical := xmlAttr(elem, "LogicalOp"); logical != "" && logical != node.Operation {
+					node.Detail = logical
+				}
+				if len(stack) > 0 {
+					parent := stack[len(stack)-1]
+					parent.Children = append(parent.Children, node)
+				} else if root == nil {
+					root = node
+				}
+				stack = append(stack, node)
+			case "Object":
+				if len(stack) > 0 && stack[len(stack)-1].Object == "" {
+					stack[len(stack)-1].Object = trimBrackets(xmlAttr(elem, "Table"))
+					if index := trimBrackets(xmlAttr(elem, "Index")); index != "" {
+						stack[len(stack)-1].Detail = strings.TrimSpace(stack[len(stack)-1].Detail + " using " + index)
+					}
+				}
+			}
+		case xml.EndElement:
+			if elem.Name.Local == "RelOp" && len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("RelOp not found in plan")
+	}
+	return root, nil
+}
+
+func xmlAttr(elem xml.StartElement, name string) string {
+	for _, attr := range elem.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func trimBrackets(s string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+}
+
+func jsonString(m map[string]interface{}, key string) string {
+	if s, ok := m[key].(string); ok {
+		return s
+	}
+	return ""
+}
+
+// jsonNumber returns numbers that may be encoded as JSON numbers or strings.
+func jsonNumber(m map[string]interface{}, key string) *float64 {
+	switch v := m[key].(type) {
+	case float64:
+		return &v
+	case string:
+		return parseNumber(v)
+	}
+	return nil
+}
+
+func parseNumber(s string) *float64 {
+	if s == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+func nullFloat(n sql.NullFloat64) *float64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Float64
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePostgreSQLPlan(t *testing.T) {
+	plan := `[
+  {
+    "Plan": {
+      "Node Type": "Hash Join",
+      "Join Type": "Inner",
+      "Total Cost": 24.5,
+      "Plan Rows": 4079,
+      "Plans": [
+        {"Node Type": "Seq Scan", "Relation Name": "city", "Alias": "ci", "Total Cost": 10.79, "Plan Rows": 4079},
+        {"Node Type": "Index Scan", "Relation Name": "country", "Alias": "country", "Index Name": "country_pkey", "Total Cost": 7.39, "Plan Rows": 239}
+      ]
+    }
+  }
+]`
+	got, err := parsePostgreSQLPlan([]byte(plan))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Hash Join (cost=24.5 rows=4079)
+-> Seq Scan on city ci (cost=10.79 rows=4079)
+-> Index Scan on country (cost=7.39 rows=239) using country_pkey
+`
+	if diff := cmp.Diff(want, PlanDoc(got)); diff != "" {
+		t.Errorf("unmatched plan (- want, + got):\n%s", diff)
+	}
+}
+
+func TestParseMySQLPlan(t *testing.T) {
+	plan := `{
+  "query_block": {
+    "select_id": 1,
+    "cost_info": {"query_cost": "1.40"},
+    "nested_loop": [
+      {"table": {"table_name": "country", "access_type": "ALL", "rows_examined_per_scan": 239, "cost_info": {"prefix_cost": "0.35"}}},
+      {"table": {"table_name": "city", "access_type": "ref", "key": "CountryCode", "rows_examined_per_scan": 17, "cost_info": {"prefix_cost": "1.40"}}}
+    ]
+  }
+}`
+	got, err := parseMySQLPlan([]byte(plan))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `query block (cost=1.4)
+-> nested loop
+   -> table on country (cost=0.35 rows=239) access_type=ALL
+   -> table on city (cost=1.4 rows=17) access_type=ref key=CountryCode
+`
+	if diff := cmp.Diff(want, PlanDoc(got)); diff != "" {
+		t.Errorf("unmatched plan (- want, + got):\n%s", diff)
+	}
+}
+
+func TestParseMssqlPlan(t *testing.T) {
+	plan := `<ShowPlanXML xmlns="http://schemas.microsoft.com/sqlserver/2004/07/showplan">
+<BatchSequence><Batch><Statements><StmtSimple>
+<QueryPlan>
+  <RelOp NodeId="0" PhysicalOp="Nested Loops" LogicalOp="Inner Join" EstimateRows="10" EstimatedTotalSubtreeCost="0.0065">
+    <NestedLoops>
+      <RelOp NodeId="1" PhysicalOp="Clustered Index Scan" LogicalOp="Clustered Index Scan" EstimateRows="10" EstimatedTotalSubtreeCost="0.0032">
+        <IndexScan><Object Database="[world]" Schema="[dbo]" Table="[city]" Index="[PK_city]" /></IndexScan>
+      </RelOp>
+      <RelOp NodeId="2" PhysicalOp="Clustered Index Seek" LogicalOp="Clustered Index Seek" EstimateRows="1" EstimatedTotalSubtreeCost="0.0031">
+        <IndexScan><Object Database="[world]" Schema="[dbo]" Table="[country]" Index="[PK_country]" /></IndexScan>
+      </RelOp>
+    </NestedLoops>
+  </RelOp>
+</QueryPlan>
+</StmtSimple></Statements></Batch></BatchSequence>
+</ShowPlanXML>`
+	got, err := parseMssqlPlan(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Nested Loops (cost=0.0065 rows=10) Inner Join
+-> Clustered Index Scan on city (cost=0.0032 rows=10) using PK_city
+-> Clustered Index Seek on country (cost=0.0031 rows=1) using PK_country
+`
+	if diff := cmp.Diff(want, PlanDoc(got)); diff != "" {
+		t.Errorf("unmatched plan (- want, + got):\n%s", diff)
+	}
+}
+
+func TestSQLite3Explain(t *testing.T) {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	if _, err := conn.Exec("CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewSQLite3DBRepository(conn)
+	got, err := repo.Explain(context.Background(), "SELECT * FROM city WHERE id = 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SEARCH city USING INTEGER PRIMARY KEY (rowid=?)\n"
+	if diff := cmp.Diff(want, PlanDoc(got)); diff != "" {
+		t.Errorf("unmatched plan (- want, + got):\n%s", diff)
+	}
+}
@@ -0,0 +1,327 @@
+package database
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yaamai/sqls/dialect"
+)
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV       ExportFormat = "csv"
+	ExportFormatJSONLines ExportFormat = "jsonl"
+	ExportFormatSQL       ExportFormat = "sql"
+)
+
+// ExportFormatFromPath guesses the export format from the file extension.
+func ExportFormatFromPath(path string) (ExportFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ExportFormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return ExportFormatJSONLines, nil
+	case ".sql":
+		return ExportFormatSQL, nil
+	}
+	return "", fmt.Errorf("cannot determine export format from %q, specify csv, jsonl or sql", path)
+}
+
+type ExportOption struct {
+	Format ExportFormat
+	// Table is the table name used in INSERT statements
+	Table string
+	// Driver decides how identifiers are quoted in INSERT statements
+	Driver       dialect.DatabaseDriver
+	ResultFormat *ResultFormat
+}
+
+type rowWriter interface {
+	WriteRow(row []interface{}) error
+	Flush() error
+}
+
+// ExportRows writes each row to w as soon as it is scanned, so that the
+// whole result set is never held in memory. It returns the number of rows written.
+func ExportRows(rows *sql.Rows, w io.Writer, opt *ExportOption) (int64, error) {
+	colTypes, err := ColumnTypes(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	// exported values must not be truncated
+	var format ResultFormat
+	if opt.ResultFormat != nil {
+		format = *opt.ResultFormat
+	}
+	format.MaxValueLength = 0
+	formatter, err := NewValueFormatter(&format)
+	if err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriter(w)
+	var writer rowWriter
+	switch opt.Format {
+	case ExportFormatCSV:
+		writer, err = newCSVRowWriter(bw, colTypes, formatter)
+	case ExportFormatJSONLines:
+		writer, err = newJSONLinesRowWriter(bw, colTypes, formatter)
+	case ExportFormatSQL:
+		writer, err = newInsertRowWriter(bw, colTypes, formatter, opt.Table, opt.Driver)
+	default:
+		err = fmt.Errorf("unsupported export format: %q", opt.Format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for rows.Next() {
+		row, err := scanRowValue(rows, len(colTypes))
+		if err != nil {
+			return count, err
+		}
+		if err := writer.WriteRow(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	if err := writer.Flush(); err != nil {
+		return count, err
+	}
+	return count, bw.Flush()
+}
+
+type csvRowWriter struct {
+	writer    *csv.Writer
+	colTypes  []*ColumnType
+	formatter *ValueFormatter
+	record    []string
+}
+
+func newCSVRowWriter(w io.Writer, colTypes []*ColumnType, formatter *ValueFormatter) (*csvRowWriter, error) {
+	cw := &csvRowWriter{
+		writer:    csv.NewWriter(w),
+		colTypes:  colTypes,
+		formatter: formatter,
+		record:    make([]string, len(colTypes)),
+	}
+	for i, colType := range colTypes {
+		cw.record[i] = colType.Name
+	}
+	if err := cw.writer.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvRowWriter) WriteRow(row []interface{}) error {
+	for i, val := range row {
+		// NULL is exported as an empty field
+		if val == nil {
+			cw.record[i] = ""
+			continue
+		}
+		s, err := cw.formatter.String(val, columnTypeAt(cw.colTypes, i))
+		if err != nil {
+			return err
+		}
+		cw.record[i] = s
+	}
+	return cw.writer.Write(cw.record)
+}
+
+func (cw *csvRowWriter) Flush() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+type jsonLinesRowWriter struct {
+	writer    io.Writer
+	keys      [][]byte
+	colTypes  []*ColumnType
+	formatter *ValueFormatter
+}
+
+func newJSONLinesRowWriter(w io.Writer, colTypes []*ColumnType, formatter *ValueFormatter) (*jsonLinesRowWriter, error) {
+	keys := make([][]byte, len(colTypes))
+	for i, colType := range colTypes {
+		key, err := json.Marshal(colType.Name)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	return &jsonLinesRowWriter{
+		writer:    w,
+		keys:      keys,
+		colTypes:  colTypes,
+		formatter: formatter,
+	}, nil
+}
+
+// WriteRow writes the row as a JSON object, keeping the column order.
+func (jw *jsonLinesRowWriter) WriteRow(row []interface{}) error {
+	var line []byte
+	line = append(line, '{')
+	for i, val := range row {
+		if i > 0 {
+			line = append(line, ',')
+		}
+		line = append(line, jw.keys[i]...)
+		line = append(line, ':')
+		v, err := jw.formatter.JSONValue(val, columnTypeAt(jw.colTypes, i))
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		line = append(line, b...)
+	}
+	line = append(line, '}', '\n')
+	_, err := jw.writer.Write(line)
+	return err
+}
+
+func (jw *jsonLinesRowWriter) Flush() error {
+	return nil
+}
+
+type insertRowWriter struct {
+	writer    io.Writer
+	prefix    string
+	colTypes  []*ColumnType
+	formatter *ValueFormatter
+	driver    dialect.DatabaseDriver
+}
+
+func newInsertRowWriter(w io.Writer, colTypes []*ColumnType, formatter *ValueFormatter, table string, driver dialect.DatabaseDriver) (*insertRowWriter, error) {
+	if table == "" {
+		return nil, errors.New("table name is required to export INSERT statements")
+	}
+	cols := make([]string, len(colTypes))
+	for i, colType := range colTypes {
+		cols[i] = quoteIdentifier(colType.Name, driver)
+	}
+	return &insertRowWriter{
+		writer:    w,
+		prefix:    fmt.Sprintf("INSERT INTO %s (%s) VALUES (", quoteTableName(table, driver), strings.Join(cols, ", ")),
+		colTypes:  colTypes,
+		formatter: formatter,
+		driver:    driver,
+	}, nil
+}
+
+func (iw *insertRowWriter) WriteRow(row []interface{}) error {
+	values := make([]string, len(row))
+	for i, val := range row {
+		v, err := iw.literal(val, columnTypeAt(iw.colTypes, i))
+		if err != nil {
+			return err
+		}
+		values[i] = v
+	}
+	_, err := io.WriteString(iw.writer, iw.prefix+strings.Join(values, ", ")+");\n")
+	return err
+}
+
+func (iw *insertRowWriter) literal(val interface{}, colType *ColumnType) (string, error) {
+	var dbType string
+	if colType != nil {
+		dbType = strings.ToUpper(colType.DatabaseType)
+	}
+	switch v := val.(type) {
+	case nil:
+		return "NULL", nil
+	case bool:
+		return boolLiteral(v, iw.driver), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return iw.formatter.String(v, colType)
+	case []byte:
+		if isBinaryType(dbType) || (dbType == "" && !utf8.Valid(v)) {
+			return binaryLiteral(v, iw.driver), nil
+		}
+	}
+	s, err := iw.formatter.String(val, colType)
+	if err != nil {
+		return "", err
+	}
+	if _, isTime := val.(time.Time); !isTime && isDecimalType(dbType) {
+		return s, nil
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'", nil
+}
+
+func (iw *insertRowWriter) Flush() error {
+	return nil
+}
+
+// boolLiteral writes 1 and 0 for the drivers without boolean literals.
+func boolLiteral(v bool, driver dialect.DatabaseDriver) string {
+	switch driver {
+	case dialect.DatabaseDriverMssql, dialect.DatabaseDriverOracle:
+		if v {
+			return "1"
+		}
+		return "0"
+	}
+	if v {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func binaryLiteral(v []byte, driver dialect.DatabaseDriver) string {
+	switch driver {
+	case dialect.DatabaseDriverPostgreSQL:
+		return `'\x` + hex.EncodeToString(v) + "'"
+	case dialect.DatabaseDriverMssql:
+		return "0x" + hex.EncodeToString(v)
+	case dialect.DatabaseDriverOracle:
+		return "HEXTORAW('" + hex.EncodeToString(v) + "')"
+	}
+	return "X'" + hex.EncodeToString(v) + "'"
+}
+
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func quoteIdentifier(name string, driver dialect.DatabaseDriver) string {
+	if plainIdentifier.MatchString(name) {
+		return name
+	}
+	switch driver {
+	case dialect.DatabaseDriverMySQL, dialect.DatabaseDriverMySQL8, dialect.DatabaseDriverMySQL57, dialect.DatabaseDriverMySQL56, dialect.DatabaseDriverClickhouse:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case dialect.DatabaseDriverMssql:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	default:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+}
+
+// quoteTableName quotes each part of a table name qualified by the schema.
+func quoteTableName(name string, driver dialect.DatabaseDriver) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quoteIdentifier(part, driver)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yaamai/sqls/dialect"
+)
+
+func TestExportRows(t *testing.T) {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	if _, err := conn.Exec("CREATE TABLE person (id INTEGER, name TEXT, note TEXT, score REAL, data BLOB)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec("INSERT INTO person VALUES (1, 'O''Brien', NULL, 2.5, X'0102')"); err != nil {
+		t.Fatal(err)
+	}
+
+	query := "SELECT id, name, note, score, data FROM person"
+	tests := []struct {
+		name string
+		opt  *ExportOption
+		want string
+	}{
+		{
+			name: "csv",
+			opt:  &ExportOption{Format: ExportFormatCSV},
+			want: "id,name,note,score,data\n1,O'Brien,,2.5,0x0102\n",
+		},
+		{
+			name: "json lines",
+			opt:  &ExportOption{Format: ExportFormatJSONLines},
+			want: `{"id":1,"name":"O'Brien","note":null,"score":2.5,"data":"0x0102"}` + "\n",
+		},
+		{
+			name: "sql",
+			opt:  &ExportOption{Format: ExportFormatSQL, Table: "person", Driver: dialect.DatabaseDriverSQLite3},
+			want: "INSERT INTO person (id, name, note, score, data) VALUES (1, 'O''Brien', NULL, 2.5, X'0102');\n",
+		},
+		{
+			name: "sql qualified table",
+			opt:  &ExportOption{Format: ExportFormatSQL, Table: "main.person list", Driver: dialect.DatabaseDriverMySQL},
+			want: "INSERT INTO main.`person list` (id, name, note, score, data) VALUES (1, 'O''Brien', NULL, 2.5, X'0102');\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := conn.QueryContext(context.Background(), query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+
+			buf := new(bytes.Buffer)
+			count, err := ExportRows(rows, buf, tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != 1 {
+				t.Errorf("unexpected row count %d", count)
+			}
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("unmatched export (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInsertRowWriterLiterals(t *testing.T) {
+	colTypes := []*ColumnType{
+		{Name: "data", DatabaseType: "BLOB"},
+		{Name: "active"},
+		{Name: "deleted"},
+	}
+	row := []interface{}{[]byte{0x01, 0xff}, true, false}
+	formatter, err := NewValueFormatter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		driver dialect.DatabaseDriver
+		want   string
+	}{
+		{
+			driver: dialect.DatabaseDriverPostgreSQL,
+			want:   `INSERT INTO person (data, active, deleted) VALUES ('\x01ff', TRUE, FALSE);` + "\n",
+		},
+		{
+			driver: dialect.DatabaseDriverMySQL,
+			want:   "INSERT INTO person (data, active, deleted) VALUES (X'01ff', TRUE, FALSE);\n",
+		},
+		{
+			driver: dialect.DatabaseDriverSQLite3,
+			want:   "INSERT INTO person (data, active, deleted) VALUES (X'01ff', TRUE, FALSE);\n",
+		},
+		{
+			driver: dialect.DatabaseDriverMssql,
+			want:   "INSERT INTO person (data, active, deleted) VALUES (0x01ff, 1, 0);\n",
+		},
+		{
+			driver: dialect.DatabaseDriverOracle,
+			want:   "INSERT INTO person (data, active, deleted) VALUES (HEXTORAW('01ff'), 1, 0);\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.driver), func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			writer, err := newInsertRowWriter(buf, colTypes, formatter, "person", tt.driver)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.WriteRow(row); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("unmatched export (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExportFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    ExportFormat
+		wantErr bool
+	}{
+		{path: "/tmp/out.csv", want: ExportFormatCSV},
+		{path: "/tmp/out.JSONL", want: ExportFormatJSONLines},
+		{path: "/tmp/out.ndjson", want: ExportFormatJSONLines},
+		{path: "/tmp/out.sql", want: ExportFormatSQL},
+		{path: "/tmp/out.txt", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ExportFormatFromPath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: unexpected error %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 
@@ -270,6 +271,102 @@ func (db *H2DBRepository) Query(ctx context.Context, query string) (*sql.Rows, e
 	return db.Conn.QueryContext(ctx, query)
 }
 
+func (db *H2DBRepository) Explain(ctx context.Context, query string) (*PlanNode, error) {
+	return nil, ErrNotImplementation
+}
+
+func (db *H2DBRepository) ShowCreateTable(ctx context.Context, schemaName, tableName string) (string, error) {
+	var definition string
+	err := db.Conn.QueryRowContext(
+		ctx,
+		fmt.Sprintf(`
+	SELECT view_definition
+	  FROM information_schema.views
+	 WHERE table_schema = '%s'
+	   AND table_name = '%s'
+	`, schemaName, tableName)).Scan(&definition)
+	if err == nil {
+		return createViewDDL(schemaName, tableName, definition, db.driver), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	return reconstructTableDDL(ctx, db, schemaName, tableName)
+}
+
+func (db *H2DBRepository) SchemaViews(ctx context.Context) (map[string][]*ViewDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT table_schema, table_name, view_definition, 'VIEW'
+	  FROM information_schema.views
+	 ORDER BY table_schema, table_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaViews(rows)
+}
+
+func (db *H2DBRepository) SchemaSequences(ctx context.Context) (map[string][]string, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT sequence_schema, sequence_name
+	  FROM information_schema.sequences
+	 ORDER BY sequence_schema, sequence_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaObjects(rows)
+}
+
+func (db *H2DBRepository) SchemaRoutines(ctx context.Context) (map[string][]*RoutineDesc, error) {
+	return nil, ErrNotImplementation
+}
+
+func (db *H2DBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		fmt.Sprintf(`
+	SELECT table_schema, table_name, index_name,
+	       CASE WHEN non_unique THEN 'NO' ELSE 'YES' END,
+	       index_type_name, column_name
+	  FROM information_schema.indexes
+	 WHERE table_schema = '%s'
+	 ORDER BY table_name, index_name, ordinal_position
+	`, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseIndexes(rows)
+}
+
+func (db *H2DBRepository) DescribeCommentsBySchema(ctx context.Context, schemaName string) ([]*CommentDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		fmt.Sprintf(`
+	SELECT table_schema, table_name, '', remarks
+	  FROM information_schema.tables
+	 WHERE table_schema = '%s'
+	   AND remarks <> ''
+	UNION ALL
+	SELECT table_schema, table_name, column_name, remarks
+	  FROM information_schema.columns
+	 WHERE table_schema = '%s'
+	   AND remarks <> ''
+	`, schemaName, schemaName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseComments(rows)
+}
+
 func (db *H2DBRepository) DescribeForeignKeysBySchema(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
 	return nil, fmt.Errorf("describe foreign keys is not supported")
 }
@@ -0,0 +1,288 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/yaamai/sqls/dialect"
+)
+
+const DefaultImportBatchSize = 1000
+
+type ImportOption struct {
+	Driver dialect.DatabaseDriver
+	Table  string
+	// Columns are the columns of the target table, used to map the CSV header
+	// and to convert the values
+	Columns   []*ColumnDesc
+	BatchSize int
+	// Progress is called after each batch with the number of rows processed
+	Progress func(processed int64)
+}
+
+type ImportResult struct {
+	Inserted int64
+	Errors   []*ImportError
+}
+
+// ImportError is a row that could not be converted or inserted.
+type ImportError struct {
+	// Line is the line of the CSV file, or the row number of the copied rows
+	Line int
+	Err  error
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+type importRow struct {
+	line   int
+	values []interface{}
+}
+
+// ImportCSV inserts the rows of a CSV file with a header into a table.
+// Rows are inserted in batched transactions. When a batch fails, its rows
+// are inserted one by one so that only the failing rows are reported.
+func ImportCSV(ctx context.Context, db *sql.DB, r io.Reader, opt *ImportOption) (*ImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read csv header, %w", err)
+	}
+	columns, err := importColumns(header, opt.Columns, opt.Table)
+	if err != nil {
+		return nil, err
+	}
+	reader.FieldsPerRecord = len(header)
+
+	inserter := newRowInserter(ctx, db, columns, opt)
+	result := inserter.result
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				result.Errors = append(result.Errors, &ImportError{Line: parseErr.StartLine, Err: parseErr.Err})
+				continue
+			}
+			return result, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		values := make([]interface{}, len(columns))
+		var convErr error
+		for i, col := range columns {
+			if values[i], convErr = convertImportValue(record[i], col); convErr != nil {
+				convErr = fmt.Errorf("column %s, %w", col.Name, convErr)
+				break
+			}
+		}
+		if convErr != nil {
+			result.Errors = append(result.Errors, &ImportError{Line: line, Err: convErr})
+			continue
+		}
+
+		if err := inserter.add(&importRow{line: line, values: values}); err != nil {
+			return result, err
+		}
+	}
+	if err := inserter.flush(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// rowInserter inserts rows into a table in batched transactions.
+type rowInserter struct {
+	ctx       context.Context
+	db        *sql.DB
+	query     string
+	batch     []*importRow
+	batchSize int
+	processed int64
+	progress  func(processed int64)
+	result    *ImportResult
+}
+
+func newRowInserter(ctx context.Context, db *sql.DB, columns []*ColumnDesc, opt *ImportOption) *rowInserter {
+	names := make([]string, len(columns))
+	binds := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = quoteIdentifier(col.Name, opt.Driver)
+		binds[i] = bindVar(opt.Driver, i+1)
+	}
+	batchSize := opt.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultImportBatchSize
+	}
+	return &rowInserter{
+		ctx:       ctx,
+		db:        db,
+		query:     fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteTableName(opt.Table, opt.Driver), strings.Join(names, ", "), strings.Join(binds, ", ")),
+		batch:     make([]*importRow, 0, batchSize),
+		batchSize: batchSize,
+		progress:  opt.Progress,
+		result:    &ImportResult{},
+	}
+}
+
+func (ri *rowInserter) add(row *importRow) error {
+	ri.batch = append(ri.batch, row)
+	if len(ri.batch) >= ri.batchSize {
+		return ri.flush()
+	}
+	return nil
+}
+
+func (ri *rowInserter) flush() error {
+	if len(ri.batch) == 0 {
+		return nil
+	}
+	if err := insertBatch(ri.ctx, ri.db, ri.query, ri.batch, ri.result); err != nil {
+		return err
+	}
+	ri.processed += int64(len(ri.batch))
+	ri.batch = ri.batch[:0]
+	if ri.progress != nil {
+		ri.progress(ri.processed)
+	}
+	return nil
+}
+
+func insertBatch(ctx context.Context, db *sql.DB, query string, batch []*importRow, result *ImportResult) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	var batchErr error
+	for _, row := range batch {
+		if _, batchErr = stmt.ExecContext(ctx, row.values...); batchErr != nil {
+			break
+		}
+	}
+	stmt.Close()
+	if batchErr == nil {
+		if batchErr = tx.Commit(); batchErr == nil {
+			result.Inserted += int64(len(batch))
+			return nil
+		}
+	} else {
+		_ = tx.Rollback()
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	// find the failing rows
+	for _, row := range batch {
+		if _, err := db.ExecContext(ctx, query, row.values...); err != nil {
+			result.Errors = append(result.Errors, &ImportError{Line: row.line, Err: err})
+			continue
+		}
+		result.Inserted++
+	}
+	return nil
+}
+
+func importColumns(header []string, tableColumns []*ColumnDesc, table string) ([]*ColumnDesc, error) {
+	columns := make([]*ColumnDesc, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		for _, col := range tableColumns {
+			if strings.EqualFold(col.Name, name) {
+				columns[i] = col
+				break
+			}
+		}
+		if columns[i] == nil {
+			return nil, fmt.Errorf("column %q not found in table %q", name, table)
+		}
+	}
+	return columns, nil
+}
+
+// convertImportValue converts a CSV field into a value of the column type.
+// Empty fields are NULL unless the column is a not null character column.
+func convertImportValue(s string, col *ColumnDesc) (interface{}, error) {
+	colType := strings.ToUpper(col.Type)
+	nullable := col.Null != "NO" && col.Null != "N"
+	if s == "" && (nullable || !isTextType(colType)) {
+		return nil, nil
+	}
+	return convertString(s, colType)
+}
+
+// convertString parses s as a value of the upper cased column type.
+func convertString(s, colType string) (interface{}, error) {
+	switch {
+	case isTextType(colType):
+		return s, nil
+	case isBoolType(colType):
+		return strconv.ParseBool(strings.TrimSpace(s))
+	case isIntegerType(colType):
+		return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	case isFloatType(colType):
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	case isDecimalType(colType):
+		v := strings.TrimSpace(s)
+		if _, ok := new(big.Rat).SetString(v); !ok {
+			return nil, fmt.Errorf("invalid number %q", s)
+		}
+		return v, nil
+	case isBinaryType(colType):
+		if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+			return hex.DecodeString(s[2:])
+		}
+		return []byte(s), nil
+	}
+	return s, nil
+}
+
+func isTextType(colType string) bool {
+	return strings.Contains(colType, "CHAR") || strings.Contains(colType, "TEXT") || strings.Contains(colType, "CLOB")
+}
+
+func isBoolType(colType string) bool {
+	return strings.Contains(colType, "BOOL")
+}
+
+func isIntegerType(colType string) bool {
+	return (strings.Contains(colType, "INT") && !strings.Contains(colType, "INTERVAL")) || strings.Contains(colType, "SERIAL")
+}
+
+func isFloatType(colType string) bool {
+	return strings.Contains(colType, "FLOAT") || strings.Contains(colType, "DOUBLE") || strings.Contains(colType, "REAL")
+}
+
+// bindVar returns the placeholder of the n-th (1-origin) parameter.
+func bindVar(driver dialect.DatabaseDriver, n int) string {
+	switch driver {
+	case dialect.DatabaseDriverPostgreSQL:
+		return "$" + strconv.Itoa(n)
+	case dialect.DatabaseDriverMssql:
+		return "@p" + strconv.Itoa(n)
+	case dialect.DatabaseDriverOracle:
+		return ":" + strconv.Itoa(n)
+	}
+	return "?"
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yaamai/sqls/dialect"
+)
+
+func TestImportCSV(t *testing.T) {
+	ctx := context.Background()
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	if _, err := conn.Exec("CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT NOT NULL, population INTEGER, data BLOB)"); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewSQLite3DBRepository(conn).(*SQLite3DBRepository)
+	cols, err := repo.describeTable(ctx, "city")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csvText := `Name,ID,population,data
+Kabul,1,1780000,0x0102
+Qandahar,2,,
+Herat,3,abc,
+Mazar,1,127800,
+Amsterdam,5,731200,
+`
+	var progress []int64
+	res, err := ImportCSV(ctx, conn, strings.NewReader(csvText), &ImportOption{
+		Driver:    dialect.DatabaseDriverSQLite3,
+		Table:     "city",
+		Columns:   cols,
+		BatchSize: 2,
+		Progress:  func(processed int64) { progress = append(progress, processed) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Inserted != 3 {
+		t.Errorf("unexpected inserted rows %d", res.Inserted)
+	}
+	gotErrLines := []int{}
+	for _, e := range res.Errors {
+		gotErrLines = append(gotErrLines, e.Line)
+	}
+	if diff := cmp.Diff([]int{4, 5}, gotErrLines); diff != "" {
+		t.Errorf("unmatched error lines (- want, + got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int64{2, 4}, progress); diff != "" {
+		t.Errorf("unmatched progress (- want, + got):\n%s", diff)
+	}
+
+	rows, err := conn.Query("SELECT id, name, population, data FROM city ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got, err := ScanRows(rows, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"1", "Kabul", "1780000", "\x01\x02"},
+		{"2", "Qandahar", "", ""},
+		{"5", "Amsterdam", "731200", ""},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unmatched rows (- want, + got):\n%s", diff)
+	}
+}
+
+func TestImportCSVUnknownColumn(t *testing.T) {
+	cols := []*ColumnDesc{{ColumnBase: ColumnBase{Table: "city", Name: "id"}, Type: "INTEGER"}}
+	_, err := ImportCSV(context.Background(), nil, strings.NewReader("id,foo\n1,2\n"), &ImportOption{Table: "city", Columns: cols})
+	if err == nil || err.Error() != `column "foo" not found in table "city"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestImportCSVQuotedTable(t *testing.T) {
+	ctx := context.Background()
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	if _, err := conn.Exec(`CREATE TABLE "city list" (id INTEGER PRIMARY KEY, "city name" TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	cols := []*ColumnDesc{
+		{ColumnBase: ColumnBase{Table: "city list", Name: "id"}, Type: "INTEGER"},
+		{ColumnBase: ColumnBase{Table: "city list", Name: "city name"}, Type: "TEXT"},
+	}
+
+	res, err := ImportCSV(ctx, conn, strings.NewReader("id,city name\n1,Kabul\n"), &ImportOption{
+		Driver:  dialect.DatabaseDriverSQLite3,
+		Table:   "main.city list",
+		Columns: cols,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Inserted != 1 || len(res.Errors) != 0 {
+		t.Fatalf("unexpected result, inserted %d, errors %v", res.Inserted, res.Errors)
+	}
+
+	rows, err := conn.Query(`SELECT id, "city name" FROM "city list"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got, err := ScanRows(rows, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([][]string{{"1", "Kabul"}}, got); diff != "" {
+		t.Errorf("unmatched rows (- want, + got):\n%s", diff)
+	}
+}
+
+func TestImportCSVMalformedField(t *testing.T) {
+	ctx := context.Background()
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	if _, err := conn.Exec("CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	cols := []*ColumnDesc{
+		{ColumnBase: ColumnBase{Table: "city", Name: "name"}, Type: "TEXT"},
+		{ColumnBase: ColumnBase{Table: "city", Name: "id"}, Type: "INTEGER"},
+	}
+
+	res, err := ImportCSV(ctx, conn, strings.NewReader("name,id\nx\"y,1\nKabul,2\n"), &ImportOption{
+		Driver:  dialect.DatabaseDriverSQLite3,
+		Table:   "city",
+		Columns: cols,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Inserted != 1 {
+		t.Errorf("unexpected inserted rows %d", res.Inserted)
+	}
+	if len(res.Errors) != 1 || res.Errors[0].Line != 2 {
+		t.Errorf("unexpected errors %v", res.Errors)
+	}
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+)
+
+type IndexDesc struct {
+	Schema  string
+	Table   string
+	Name    string
+	Columns []string
+	Unique  bool
+	// Type is the access method of the index, such as BTREE, HASH or CLUSTERED
+	Type string
+}
+
+// HasColumn reports whether the column is a key column of the index.
+func (id *IndexDesc) HasColumn(colName string) bool {
+	for _, col := range id.Columns {
+		if strings.EqualFold(col, colName) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIndexes reads rows of schema, table, index name, unique ("YES" or
+// "NO"), index type and column name. The rows of an index must be consecutive
+// and ordered by the column position. Expression columns may be NULL.
+func parseIndexes(rows *sql.Rows) ([]*IndexDesc, error) {
+	indexes := []*IndexDesc{}
+	var cur *IndexDesc
+	for rows.Next() {
+		var (
+			schema, table, name, unique, indexType, column sql.NullString
+		)
+		if err := rows.Scan(&schema, &table, &name, &unique, &indexType, &column); err != nil {
+			return nil, err
+		}
+		if cur == nil || cur.Schema != schema.String || cur.Table != table.String || cur.Name != name.String {
+			cur = &IndexDesc{
+				Schema:  schema.String,
+				Table:   table.String,
+				Name:    name.String,
+				Unique:  unique.String == "YES",
+				Type:    indexType.String,
+				Columns: []string{},
+			}
+			indexes = append(indexes, cur)
+		}
+		if column.Valid {
+			cur.Columns = append(cur.Columns, column.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return indexes, nil
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSQLite3DescribeIndexesBySchema(t *testing.T) {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	for _, q := range []string{
+		"CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT, countrycode TEXT, district TEXT)",
+		"CREATE INDEX idx_city_country ON city (countrycode, district)",
+		"CREATE UNIQUE INDEX idx_city_name ON city (name)",
+	} {
+		if _, err := conn.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := NewSQLite3DBRepository(conn).DescribeIndexesBySchema(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*IndexDesc{
+		{Table: "city", Name: "idx_city_country", Columns: []string{"countrycode", "district"}, Type: "BTREE"},
+		{Table: "city", Name: "idx_city_name", Columns: []string{"name"}, Unique: true, Type: "BTREE"},
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unmatched indexes (- want, + got):\n%s", d)
+	}
+}
+
+func TestTableDocIndexes(t *testing.T) {
+	cols := []*ColumnDesc{{ColumnBase: ColumnBase{Table: "city", Name: "id"}, Type: "INTEGER", Key: "YES"}}
+	indexes := []*IndexDesc{{Table: "city", Name: "idx_city", Columns: []string{"id", "name"}, Unique: true, Type: "BTREE"}}
+	want := "# `city` table\n\n\n" +
+		"| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |\n" +
+		"| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |\n" +
+		"| `id` | `INTEGER` | `YES` | `-` |  |\n" +
+		"\n" +
+		"| Index&nbsp;&nbsp; | Columns&nbsp;&nbsp; | Unique&nbsp;&nbsp; | Type&nbsp;&nbsp; |\n" +
+		"| :---------------- | :------------------ | :----------------- | :--------------- |\n" +
+		"| `idx_city` | `id`, `name` | UNIQUE | BTREE |\n"
+	if d := cmp.Diff(want, TableDoc("city", "", cols, indexes)); d != "" {
+		t.Errorf("unmatched doc (- want, + got):\n%s", d)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
@@ -371,6 +372,140 @@ func (db *MssqlDBRepository) Query(ctx context.Context, query string) (*sql.Rows
 	return db.Conn.QueryContext(ctx, query)
 }
 
+func (db *MssqlDBRepository) Explain(ctx context.Context, query string) (*PlanNode, error) {
+	// SHOWPLAN_XML is a session option, so every statement must run on the same connection.
+	conn, err := db.Conn.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SET SHOWPLAN_XML ON"); err != nil {
+		return nil, err
+	}
+	defer func() { _, _ = conn.ExecContext(context.Background(), "SET SHOWPLAN_XML OFF") }()
+
+	var plan string
+	if err := conn.QueryRowContext(ctx, query).Scan(&plan); err != nil {
+		return nil, err
+	}
+	return parseMssqlPlan(plan)
+}
+
+func (db *MssqlDBRepository) ShowCreateTable(ctx context.Context, schemaName, tableName string) (string, error) {
+	var definition sql.NullString
+	err := db.Conn.QueryRowContext(
+		ctx,
+		`
+	SELECT OBJECT_DEFINITION(OBJECT_ID(QUOTENAME(TABLE_SCHEMA) + '.' + QUOTENAME(TABLE_NAME)))
+	  FROM INFORMATION_SCHEMA.VIEWS
+	 WHERE TABLE_SCHEMA = @p1
+	   AND TABLE_NAME = @p2
+	`, schemaName, tableName).Scan(&definition)
+	if err == nil && definition.Valid {
+		return createViewDDL(schemaName, tableName, definition.String, dialect.DatabaseDriverMssql), nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	return reconstructTableDDL(ctx, db, schemaName, tableName)
+}
+
+func (db *MssqlDBRepository) SchemaViews(ctx context.Context) (map[string][]*ViewDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT TABLE_SCHEMA, TABLE_NAME, VIEW_DEFINITION, 'VIEW'
+	  FROM INFORMATION_SCHEMA.VIEWS
+	 ORDER BY TABLE_SCHEMA, TABLE_NAME
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaViews(rows)
+}
+
+func (db *MssqlDBRepository) SchemaSequences(ctx context.Context) (map[string][]string, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT SCHEMA_NAME(schema_id), name
+	  FROM sys.sequences
+	 ORDER BY SCHEMA_NAME(schema_id), name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaObjects(rows)
+}
+
+func (db *MssqlDBRepository) SchemaRoutines(ctx context.Context) (map[string][]*RoutineDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT r.ROUTINE_SCHEMA, r.ROUTINE_NAME, r.SPECIFIC_NAME, r.ROUTINE_TYPE, r.DATA_TYPE,
+	       p.PARAMETER_NAME, p.PARAMETER_MODE, p.DATA_TYPE
+	  FROM INFORMATION_SCHEMA.ROUTINES r
+	  LEFT JOIN INFORMATION_SCHEMA.PARAMETERS p
+	    ON p.SPECIFIC_SCHEMA = r.SPECIFIC_SCHEMA
+	   AND p.SPECIFIC_NAME = r.SPECIFIC_NAME
+	   AND p.ORDINAL_POSITION > 0
+	 ORDER BY r.ROUTINE_SCHEMA, r.ROUTINE_NAME, r.SPECIFIC_NAME, p.ORDINAL_POSITION
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseRoutines(rows)
+}
+
+func (db *MssqlDBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT s.name, t.name, i.name,
+	       CASE WHEN i.is_unique = 1 THEN 'YES' ELSE 'NO' END,
+	       i.type_desc, c.name
+	  FROM sys.indexes i
+	  JOIN sys.tables t ON t.object_id = i.object_id
+	  JOIN sys.schemas s ON s.schema_id = t.schema_id
+	  JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+	  JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+	 WHERE s.name = @p1
+	   AND i.name IS NOT NULL
+	   AND ic.is_included_column = 0
+	 ORDER BY t.name, i.name, ic.key_ordinal
+	`, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseIndexes(rows)
+}
+
+func (db *MssqlDBRepository) DescribeCommentsBySchema(ctx context.Context, schemaName string) ([]*CommentDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT s.name, o.name, c.name, CAST(ep.value AS NVARCHAR(MAX))
+	  FROM sys.extended_properties ep
+	  JOIN sys.objects o ON o.object_id = ep.major_id
+	  JOIN sys.schemas s ON s.schema_id = o.schema_id
+	  LEFT JOIN sys.columns c ON c.object_id = ep.major_id AND c.column_id = ep.minor_id AND ep.minor_id > 0
+	 WHERE ep.class = 1
+	   AND ep.name = 'MS_Description'
+	   AND s.name = @p1
+	 ORDER BY o.name, ep.minor_id
+	`, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseComments(rows)
+}
+
 func genMssqlConfig(connCfg *DBConfig) (string, error) {
 	if connCfg.DataSourceName != "" {
 		return connCfg.DataSourceName, nil
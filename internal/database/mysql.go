@@ -94,22 +94,40 @@ func genMysqlConfig(connCfg *DBConfig) (*mysql.Config, error) {
 		return mysql.ParseDSN(connCfg.DataSourceName)
 	}
 
-	cfg.User = connCfg.User
-	cfg.Passwd = connCfg.Passwd
-	cfg.DBName = connCfg.DBName
+	// the settings of the config override the options of the option group
+	options := map[string]string{}
+	if connCfg.Service != "" {
+		var err error
+		if options, err = myCnfOptions(connCfg.Service); err != nil {
+			return nil, err
+		}
+	}
+	cfg.User = Coalesce(connCfg.User, options["user"])
+	cfg.Passwd = Coalesce(connCfg.Passwd, options["password"])
+	cfg.DBName = Coalesce(connCfg.DBName, options["database"])
 
-	switch connCfg.Proto {
-	case ProtoTCP, ProtoUDP:
-		host, port := connCfg.Host, connCfg.Port
-		if host == "" {
-			host = "127.0.0.1"
+	proto := connCfg.Proto
+	if proto == "" && connCfg.Service != "" {
+		proto = ProtoTCP
+		if options["socket"] != "" && options["host"] == "" {
+			proto = ProtoUnix
 		}
-		if port == 0 {
-			port = 3306
+	}
+	switch proto {
+	case ProtoTCP, ProtoUDP:
+		host, port := Coalesce(connCfg.Host, options["host"], "127.0.0.1"), Coalesce(options["port"], "3306")
+		if connCfg.Port != 0 {
+			port = strconv.Itoa(connCfg.Port)
 		}
-		cfg.Addr = host + ":" + strconv.Itoa(port)
-		cfg.Net = string(connCfg.Proto)
+		cfg.Addr = host + ":" + port
+		cfg.Net = string(proto)
 	case ProtoUnix:
+		if connCfg.Proto == "" {
+			// the socket of the option group
+			cfg.Addr = options["socket"]
+			cfg.Net = string(proto)
+			break
+		}
 		if connCfg.Path != "" {
 			cfg.Addr = "/tmp/mysql.sock"
 			break
@@ -336,3 +354,96 @@ func (db *MySQLDBRepository) Exec(ctx context.Context, query string) (sql.Result
 func (db *MySQLDBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
 	return db.Conn.QueryContext(ctx, query)
 }
+
+func (db *MySQLDBRepository) Explain(ctx context.Context, query string) (*PlanNode, error) {
+	var plan string
+	if err := db.Conn.QueryRowContext(ctx, "EXPLAIN FORMAT=JSON "+query).Scan(&plan); err != nil {
+		return nil, err
+	}
+	return parseMySQLPlan([]byte(plan))
+}
+
+func (db *MySQLDBRepository) ShowCreateTable(ctx context.Context, schemaName, tableName string) (string, error) {
+	return showCreateTable(ctx, db.Conn, qualifiedTableName(schemaName, tableName, dialect.DatabaseDriverMySQL))
+}
+
+func (db *MySQLDBRepository) SchemaViews(ctx context.Context) (map[string][]*ViewDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT table_schema, table_name, view_definition, 'VIEW'
+	  FROM information_schema.views
+	 ORDER BY table_schema, table_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaViews(rows)
+}
+
+func (db *MySQLDBRepository) SchemaSequences(ctx context.Context) (map[string][]string, error) {
+	// MySQL has no sequence
+	return map[string][]string{}, nil
+}
+
+func (db *MySQLDBRepository) SchemaRoutines(ctx context.Context) (map[string][]*RoutineDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT r.routine_schema, r.routine_name, r.specific_name, r.routine_type, r.dtd_identifier,
+	       p.parameter_name, p.parameter_mode, p.dtd_identifier
+	  FROM information_schema.routines r
+	  LEFT JOIN information_schema.parameters p
+	    ON p.specific_schema = r.routine_schema
+	   AND p.specific_name = r.specific_name
+	   AND p.routine_type = r.routine_type
+	   AND p.ordinal_position > 0
+	 WHERE r.routine_schema NOT IN ('mysql', 'sys', 'information_schema', 'performance_schema')
+	 ORDER BY r.routine_schema, r.routine_name, r.specific_name, p.ordinal_position
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseRoutines(rows)
+}
+
+func (db *MySQLDBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT table_schema, table_name, index_name,
+	       CASE WHEN non_unique = 0 THEN 'YES' ELSE 'NO' END,
+	       index_type, column_name
+	  FROM information_schema.statistics
+	 WHERE table_schema = ?
+	 ORDER BY table_name, index_name, seq_in_index
+	`, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseIndexes(rows)
+}
+
+func (db *MySQLDBRepository) DescribeCommentsBySchema(ctx context.Context, schemaName string) ([]*CommentDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT table_schema, table_name, '', table_comment
+	  FROM information_schema.tables
+	 WHERE table_schema = ?
+	   AND table_comment <> ''
+	UNION ALL
+	SELECT table_schema, table_name, column_name, column_comment
+	  FROM information_schema.columns
+	 WHERE table_schema = ?
+	   AND column_comment <> ''
+	`, schemaName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseComments(rows)
+}
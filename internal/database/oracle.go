@@ -3,8 +3,12 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/godror/godror"
 	"github.com/yaamai/sqls/dialect"
@@ -42,6 +46,13 @@ func genOracleConfig(connCfg *DBConfig) (string, error) {
 	if connCfg.DataSourceName != "" {
 		return connCfg.DataSourceName, nil
 	}
+	if connCfg.Service != "" {
+		descriptor, err := tnsDescriptor(connCfg.Service)
+		if err != nil {
+			return "", err
+		}
+		return connCfg.User + "/" + connCfg.Passwd + "@" + descriptor, nil
+	}
 
 	host, port := connCfg.Host, connCfg.Port
 	if host == "" {
@@ -265,3 +276,184 @@ func (db *OracleDBRepository) Exec(ctx context.Context, query string) (sql.Resul
 func (db *OracleDBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
 	return db.Conn.QueryContext(ctx, query)
 }
+
+func (db *OracleDBRepository) Explain(ctx context.Context, query string) (*PlanNode, error) {
+	// PLAN_TABLE rows are visible to the session that wrote them.
+	conn, err := db.Conn.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	statementID := fmt.Sprintf("sqls_%d", time.Now().UnixNano())
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("EXPLAIN PLAN SET STATEMENT_ID = '%s' FOR %s", statementID, query)); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "DELETE FROM PLAN_TABLE WHERE STATEMENT_ID = :1", statementID)
+	}()
+
+	rows, err := conn.QueryContext(ctx, `
+	SELECT
+	  ID,
+	  NVL(PARENT_ID, -1),
+	  OPERATION,
+	  NVL(OPTIONS, ' '),
+	  NVL(OBJECT_NAME, ' '),
+	  COST,
+	  CARDINALITY
+	FROM
+	  PLAN_TABLE
+	WHERE
+	  STATEMENT_ID = :1
+	ORDER BY
+	  ID
+	`, statementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	nodes := []*flatPlanNode{}
+	for rows.Next() {
+		var (
+			id, parentID               int64
+			operation, options, object string
+			cost, cardinality          sql.NullFloat64
+		)
+		if err := rows.Scan(&id, &parentID, &operation, &options, &object, &cost, &cardinality); err != nil {
+			return nil, err
+		}
+		node := &PlanNode{
+			Operation: strings.TrimSpace(operation + " " + options),
+			Object:    strings.TrimSpace(object),
+			Cost:      nullFloat(cost),
+			Rows:      nullFloat(cardinality),
+		}
+		nodes = append(nodes, &flatPlanNode{id: id, parentID: parentID, node: node})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return buildPlanTree(nodes), nil
+}
+
+func (db *OracleDBRepository) ShowCreateTable(ctx context.Context, schemaName, tableName string) (string, error) {
+	var ddl string
+	err := db.Conn.QueryRowContext(
+		ctx,
+		`
+	SELECT DBMS_METADATA.GET_DDL(OBJECT_TYPE, OBJECT_NAME, OWNER)
+	  FROM ALL_OBJECTS
+	 WHERE OWNER = :1
+	   AND OBJECT_NAME = :2
+	   AND OBJECT_TYPE IN ('TABLE', 'VIEW')
+	`, schemaName, tableName).Scan(&ddl)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("table not found, %q", tableName)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(ddl) + ";", nil
+}
+
+func (db *OracleDBRepository) SchemaViews(ctx context.Context) (map[string][]*ViewDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT OWNER, VIEW_NAME, TEXT_VC, 'VIEW'
+	  FROM SYS.ALL_VIEWS
+	UNION ALL
+	SELECT OWNER, MVIEW_NAME, NULL, 'MATERIALIZED VIEW'
+	  FROM SYS.ALL_MVIEWS
+	ORDER BY 1, 2
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaViews(rows)
+}
+
+func (db *OracleDBRepository) SchemaSequences(ctx context.Context) (map[string][]string, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT SEQUENCE_OWNER, SEQUENCE_NAME
+	  FROM SYS.ALL_SEQUENCES
+	 ORDER BY SEQUENCE_OWNER, SEQUENCE_NAME
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaObjects(rows)
+}
+
+func (db *OracleDBRepository) SchemaRoutines(ctx context.Context) (map[string][]*RoutineDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT o.OWNER, o.OBJECT_NAME, TO_CHAR(o.OBJECT_ID), o.OBJECT_TYPE,
+	       (SELECT r.DATA_TYPE
+	          FROM SYS.ALL_ARGUMENTS r
+	         WHERE r.OBJECT_ID = o.OBJECT_ID
+	           AND r.POSITION = 0
+	           AND r.DATA_LEVEL = 0
+	           AND ROWNUM = 1),
+	       a.ARGUMENT_NAME, a.IN_OUT, a.DATA_TYPE
+	  FROM SYS.ALL_OBJECTS o
+	  LEFT JOIN SYS.ALL_ARGUMENTS a
+	    ON a.OBJECT_ID = o.OBJECT_ID
+	   AND a.POSITION > 0
+	   AND a.DATA_LEVEL = 0
+	 WHERE o.OBJECT_TYPE IN ('FUNCTION', 'PROCEDURE')
+	   AND o.OWNER NOT IN ('SYS', 'SYSTEM')
+	 ORDER BY o.OWNER, o.OBJECT_NAME, o.OBJECT_ID, a.POSITION
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseRoutines(rows)
+}
+
+func (db *OracleDBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT i.TABLE_OWNER, i.TABLE_NAME, i.INDEX_NAME,
+	       DECODE(i.UNIQUENESS, 'UNIQUE', 'YES', 'NO'),
+	       i.INDEX_TYPE, c.COLUMN_NAME
+	  FROM SYS.ALL_INDEXES i
+	  JOIN SYS.ALL_IND_COLUMNS c ON c.INDEX_OWNER = i.OWNER AND c.INDEX_NAME = i.INDEX_NAME
+	 WHERE i.TABLE_OWNER = :1
+	 ORDER BY i.TABLE_NAME, i.INDEX_NAME, c.COLUMN_POSITION
+	`, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseIndexes(rows)
+}
+
+func (db *OracleDBRepository) DescribeCommentsBySchema(ctx context.Context, schemaName string) ([]*CommentDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT OWNER, TABLE_NAME, NULL, COMMENTS
+	  FROM SYS.ALL_TAB_COMMENTS
+	 WHERE OWNER = :1
+	   AND COMMENTS IS NOT NULL
+	UNION ALL
+	SELECT OWNER, TABLE_NAME, COLUMN_NAME, COMMENTS
+	  FROM SYS.ALL_COL_COMMENTS
+	 WHERE OWNER = :2
+	   AND COMMENTS IS NOT NULL
+	`, schemaName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseComments(rows)
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -391,32 +392,186 @@ func (db *PostgreSQLDBRepository) Query(ctx context.Context, query string) (*sql
 	return db.Conn.QueryContext(ctx, query)
 }
 
+func (db *PostgreSQLDBRepository) Explain(ctx context.Context, query string) (*PlanNode, error) {
+	var plan string
+	if err := db.Conn.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) "+query).Scan(&plan); err != nil {
+		return nil, err
+	}
+	return parsePostgreSQLPlan([]byte(plan))
+}
+
+func (db *PostgreSQLDBRepository) ShowCreateTable(ctx context.Context, schemaName, tableName string) (string, error) {
+	var (
+		relkind    string
+		definition sql.NullString
+	)
+	err := db.Conn.QueryRowContext(
+		ctx,
+		`
+	SELECT c.relkind, pg_get_viewdef(c.oid, true)
+	  FROM pg_class c
+	  JOIN pg_namespace n ON n.oid = c.relnamespace
+	 WHERE n.nspname = $1
+	   AND c.relname = $2
+	   AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+	`, schemaName, tableName).Scan(&relkind, &definition)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("table not found, %q", tableName)
+	}
+	if err != nil {
+		return "", err
+	}
+	switch relkind {
+	case "v":
+		return createViewDDL(schemaName, tableName, definition.String, dialect.DatabaseDriverPostgreSQL), nil
+	case "m":
+		return fmt.Sprintf("CREATE MATERIALIZED VIEW %s AS\n%s", qualifiedTableName(schemaName, tableName, dialect.DatabaseDriverPostgreSQL), strings.TrimSpace(definition.String)), nil
+	}
+	return reconstructTableDDL(ctx, db, schemaName, tableName)
+}
+
+func (db *PostgreSQLDBRepository) SchemaViews(ctx context.Context) (map[string][]*ViewDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT schemaname, viewname, definition, 'VIEW'
+	  FROM pg_views
+	 WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+	UNION ALL
+	SELECT schemaname, matviewname, definition, 'MATERIALIZED VIEW'
+	  FROM pg_matviews
+	ORDER BY 1, 2
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaViews(rows)
+}
+
+func (db *PostgreSQLDBRepository) SchemaSequences(ctx context.Context) (map[string][]string, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT sequence_schema, sequence_name
+	  FROM information_schema.sequences
+	 ORDER BY sequence_schema, sequence_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaObjects(rows)
+}
+
+func (db *PostgreSQLDBRepository) SchemaRoutines(ctx context.Context) (map[string][]*RoutineDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT r.routine_schema, r.routine_name, r.specific_name, COALESCE(r.routine_type, 'FUNCTION'), r.data_type,
+	       p.parameter_name, p.parameter_mode, p.data_type
+	  FROM information_schema.routines r
+	  LEFT JOIN information_schema.parameters p
+	    ON p.specific_schema = r.specific_schema
+	   AND p.specific_name = r.specific_name
+	 WHERE r.routine_schema NOT IN ('pg_catalog', 'information_schema')
+	 ORDER BY r.routine_schema, r.routine_name, r.specific_name, p.ordinal_position
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseRoutines(rows)
+}
+
+func (db *PostgreSQLDBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT n.nspname, t.relname, i.relname,
+	       CASE WHEN ix.indisunique THEN 'YES' ELSE 'NO' END,
+	       am.amname,
+	       COALESCE(a.attname, pg_get_indexdef(ix.indexrelid, k.ord::int, true))
+	  FROM pg_index ix
+	  JOIN pg_class t ON t.oid = ix.indrelid
+	  JOIN pg_class i ON i.oid = ix.indexrelid
+	  JOIN pg_namespace n ON n.oid = t.relnamespace
+	  JOIN pg_am am ON am.oid = i.relam
+	  JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+	  LEFT JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+	 WHERE n.nspname = $1
+	   AND k.ord <= ix.indnkeyatts
+	 ORDER BY t.relname, i.relname, k.ord
+	`, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseIndexes(rows)
+}
+
+func (db *PostgreSQLDBRepository) DescribeCommentsBySchema(ctx context.Context, schemaName string) ([]*CommentDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT n.nspname, c.relname, a.attname, d.description
+	  FROM pg_description d
+	  JOIN pg_class c ON c.oid = d.objoid AND d.classoid = 'pg_class'::regclass
+	  JOIN pg_namespace n ON n.oid = c.relnamespace
+	  LEFT JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = d.objsubid AND d.objsubid > 0
+	 WHERE n.nspname = $1
+	 ORDER BY c.relname, d.objsubid
+	`, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseComments(rows)
+}
+
 func genPostgresConfig(connCfg *DBConfig) (string, error) {
 	if connCfg.DataSourceName != "" {
 		return connCfg.DataSourceName, nil
 	}
 
+	// the settings of the config override the parameters of the service
 	q := url.Values{}
-	q.Set("user", connCfg.User)
-	q.Set("password", connCfg.Passwd)
-	q.Set("dbname", connCfg.DBName)
+	if connCfg.Service != "" {
+		params, err := pgServiceParams(connCfg.Service)
+		if err != nil {
+			return "", err
+		}
+		for k, v := range params {
+			q.Set(k, v)
+		}
+	}
+	q.Set("user", Coalesce(connCfg.User, q.Get("user")))
+	q.Set("password", Coalesce(connCfg.Passwd, q.Get("password")))
+	q.Set("dbname", Coalesce(connCfg.DBName, q.Get("dbname")))
 
 	switch connCfg.Proto {
 	case ProtoTCP, ProtoUDP:
-		host, port := connCfg.Host, connCfg.Port
-		if host == "" {
-			host = "127.0.0.1"
-		}
-		if port == 0 {
-			port = 5432
+		host, port := Coalesce(connCfg.Host, q.Get("host"), "127.0.0.1"), Coalesce(q.Get("port"), "5432")
+		if connCfg.Port != 0 {
+			port = strconv.Itoa(connCfg.Port)
 		}
 		q.Set("host", host)
-		q.Set("port", strconv.Itoa(port))
+		q.Set("port", port)
 	case ProtoUnix:
-		q.Set("host", connCfg.Path)
+		q.Set("host", Coalesce(connCfg.Path, q.Get("host")))
 	case ProtoHTTP:
 	default:
-		return "", fmt.Errorf("default addr for network %s unknown", connCfg.Proto)
+		// the service gives the address
+		if connCfg.Proto != "" || connCfg.Service == "" {
+			return "", fmt.Errorf("default addr for network %s unknown", connCfg.Proto)
+		}
+	}
+
+	if q.Get("password") == "" {
+		dbname := Coalesce(q.Get("dbname"), q.Get("user"))
+		if passwd, ok := pgPassword(q.Get("host"), Coalesce(q.Get("port"), "5432"), dbname, q.Get("user")); ok {
+			q.Set("password", passwd)
+		}
 	}
 
 	for k, v := range connCfg.Params {
@@ -225,6 +225,26 @@ var createIgnore = map[string]bool{
 	"UNLOGGED":   true,
 }
 
+// trimLeadingComments removes the comments before the statement, such as the
+// connection header of a document.
+func trimLeadingComments(s string) string {
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		var found bool
+		switch {
+		case strings.HasPrefix(s, "--"):
+			_, s, found = strings.Cut(s, "\n")
+		case strings.HasPrefix(s, "/*"):
+			_, s, found = strings.Cut(s[2:], "*/")
+		default:
+			return s
+		}
+		if !found {
+			return ""
+		}
+	}
+}
+
 func splitMultiSep(s string, sep []string) []string {
 	var ret []string
 	ret = strings.Split(s, sep[0])
@@ -241,6 +261,7 @@ func splitMultiSep(s string, sep []string) []string {
 // QueryExecType is the default way to determine the "EXEC" prefix for a SQL
 // query and whether or not it should be Exec'd or Query'd.
 func QueryExecType(prefix, sqlstr string) (string, bool) {
+	prefix = trimLeadingComments(prefix)
 	if prefix == "" {
 		return "EXEC", false
 	}
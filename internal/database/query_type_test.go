@@ -77,6 +77,13 @@ func TestQueryExecType(t *testing.T) {
 			wantPrefix:   "DELETE",
 			wantExecType: false,
 		},
+		{
+			name:         "leading comments",
+			prefix:       "-- sqls:connection=pg\n/* cities */ select * from city",
+			sqlstr:       "",
+			wantPrefix:   "SELECT",
+			wantExecType: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
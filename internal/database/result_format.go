@@ -0,0 +1,270 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type BinaryFormat string
+
+const (
+	BinaryFormatHex    BinaryFormat = "hex"
+	BinaryFormatBase64 BinaryFormat = "base64"
+	BinaryFormatText   BinaryFormat = "text"
+)
+
+const (
+	DefaultNullString     = "NULL"
+	DefaultDateFormat     = "2006-01-02"
+	DefaultTimeFormat     = "15:04:05.999999999"
+	DefaultDateTimeFormat = time.RFC3339Nano
+)
+
+// ResultFormat controls how query result values are rendered.
+type ResultFormat struct {
+	NullString       string       `json:"nullString" yaml:"nullString"`
+	BinaryFormat     BinaryFormat `json:"binaryFormat" yaml:"binaryFormat"`
+	DateFormat       string       `json:"dateFormat" yaml:"dateFormat"`
+	TimeFormat       string       `json:"timeFormat" yaml:"timeFormat"`
+	DateTimeFormat   string       `json:"dateTimeFormat" yaml:"dateTimeFormat"`
+	TimeZone         string       `json:"timeZone" yaml:"timeZone"`
+	DecimalPrecision *int         `json:"decimalPrecision" yaml:"decimalPrecision"`
+	MaxValueLength   int          `json:"maxValueLength" yaml:"maxValueLength"`
+}
+
+func (f *ResultFormat) Validate() error {
+	switch f.BinaryFormat {
+	case "", BinaryFormatHex, BinaryFormatBase64, BinaryFormatText:
+	default:
+		return errors.New("invalid: resultFormat.binaryFormat")
+	}
+	if f.TimeZone != "" {
+		if _, err := time.LoadLocation(f.TimeZone); err != nil {
+			return fmt.Errorf("invalid: resultFormat.timeZone, %w", err)
+		}
+	}
+	if f.DecimalPrecision != nil && *f.DecimalPrecision < 0 {
+		return errors.New("invalid: resultFormat.decimalPrecision")
+	}
+	if f.MaxValueLength < 0 {
+		return errors.New("invalid: resultFormat.maxValueLength")
+	}
+	return nil
+}
+
+// ValueFormatter renders values scanned from the driver according to a ResultFormat.
+type ValueFormatter struct {
+	format ResultFormat
+	loc    *time.Location
+	// plain is set without a ResultFormat, the values are rendered as the
+	// driver returns them and NULL is empty
+	plain bool
+}
+
+// NewValueFormatter returns the formatter of the format. Without a format,
+// that is when resultFormat is not configured, the values are rendered as
+// they always were, so that the output does not change unless asked for.
+func NewValueFormatter(format *ResultFormat) (*ValueFormatter, error) {
+	if format == nil {
+		return &ValueFormatter{plain: true}, nil
+	}
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+	vf := &ValueFormatter{format: *format}
+	if vf.format.NullString == "" {
+		vf.format.NullString = DefaultNullString
+	}
+	if vf.format.BinaryFormat == "" {
+		vf.format.BinaryFormat = BinaryFormatHex
+	}
+	if vf.format.DateFormat == "" {
+		vf.format.DateFormat = DefaultDateFormat
+	}
+	if vf.format.TimeFormat == "" {
+		vf.format.TimeFormat = DefaultTimeFormat
+	}
+	if vf.format.DateTimeFormat == "" {
+		vf.format.DateTimeFormat = DefaultDateTimeFormat
+	}
+	if vf.format.TimeZone != "" {
+		loc, err := time.LoadLocation(vf.format.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		vf.loc = loc
+	}
+	return vf, nil
+}
+
+func (vf *ValueFormatter) RowStrings(row []interface{}, colTypes []*ColumnType) ([]string, error) {
+	stringRow := make([]string, len(row))
+	for i, val := range row {
+		s, err := vf.String(val, columnTypeAt(colTypes, i))
+		if err != nil {
+			return nil, err
+		}
+		stringRow[i] = s
+	}
+	return stringRow, nil
+}
+
+// RowJSONValues converts the values into values that keep their JSON type
+// (number, bool, null) when marshaled, rendering everything else as text.
+func (vf *ValueFormatter) RowJSONValues(row []interface{}, colTypes []*ColumnType) ([]interface{}, error) {
+	jsonRow := make([]interface{}, len(row))
+	for i, val := range row {
+		v, err := vf.JSONValue(val, columnTypeAt(colTypes, i))
+		if err != nil {
+			return nil, err
+		}
+		jsonRow[i] = v
+	}
+	return jsonRow, nil
+}
+
+func (vf *ValueFormatter) JSONValue(val interface{}, colType *ColumnType) (interface{}, error) {
+	switch v := (val).(type) {
+	case nil:
+		return nil, nil
+	case bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		map[string]interface{}, []interface{}:
+		return v, nil
+	case float32, float64:
+		if vf.plain || vf.format.DecimalPrecision == nil {
+			return v, nil
+		}
+	}
+	return vf.String(val, colType)
+}
+
+func (vf *ValueFormatter) String(val interface{}, colType *ColumnType) (string, error) {
+	if vf.plain {
+		if val == nil {
+			return "", nil
+		}
+		return sqlValToString(val)
+	}
+
+	var dbType string
+	if colType != nil {
+		dbType = strings.ToUpper(colType.DatabaseType)
+	}
+
+	var (
+		res string
+		err error
+	)
+	switch v := (val).(type) {
+	case nil:
+		return vf.format.NullString, nil
+	case []byte:
+		if isBinaryType(dbType) || (dbType == "" && !utf8.Valid(v)) {
+			return vf.truncate(vf.formatBinary(v), len(v), "bytes"), nil
+		} else {
+			res = vf.formatDecimal(string(v), dbType)
+		}
+	case string:
+		res = vf.formatDecimal(v, dbType)
+	case time.Time:
+		res = vf.formatTime(v, dbType)
+	case float64:
+		res = vf.formatFloat(v, 64)
+	case float32:
+		res = vf.formatFloat(float64(v), 32)
+	default:
+		res, err = sqlValToString(v)
+		if err != nil {
+			return "", err
+		}
+	}
+	return vf.truncate(res, utf8.RuneCountInString(res), "chars"), nil
+}
+
+func (vf *ValueFormatter) formatBinary(b []byte) string {
+	switch vf.format.BinaryFormat {
+	case BinaryFormatBase64:
+		return base64.StdEncoding.EncodeToString(b)
+	case BinaryFormatText:
+		return string(b)
+	default:
+		return "0x" + hex.EncodeToString(b)
+	}
+}
+
+func (vf *ValueFormatter) formatDecimal(s, dbType string) string {
+	if vf.format.DecimalPrecision == nil || !isDecimalType(dbType) {
+		return s
+	}
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return s
+	}
+	return r.FloatString(*vf.format.DecimalPrecision)
+}
+
+func (vf *ValueFormatter) formatFloat(f float64, bitSize int) string {
+	if vf.format.DecimalPrecision == nil {
+		return strconv.FormatFloat(f, 'g', -1, bitSize)
+	}
+	return strconv.FormatFloat(f, 'f', *vf.format.DecimalPrecision, bitSize)
+}
+
+func (vf *ValueFormatter) formatTime(t time.Time, dbType string) string {
+	if vf.loc != nil {
+		t = t.In(vf.loc)
+	}
+	switch {
+	case dbType == "DATE":
+		return t.Format(vf.format.DateFormat)
+	case strings.HasPrefix(dbType, "TIME") && !strings.HasPrefix(dbType, "TIMESTAMP"):
+		return t.Format(vf.format.TimeFormat)
+	default:
+		return t.Format(vf.format.DateTimeFormat)
+	}
+}
+
+// truncate shortens s to the configured max length, appending the length
+// of the original value so that it is clear the value has been cut.
+func (vf *ValueFormatter) truncate(s string, length int, unit string) string {
+	max := vf.format.MaxValueLength
+	runes := []rune(s)
+	if max <= 0 || len(runes) <= max {
+		return s
+	}
+	return fmt.Sprintf("%s...(%d %s)", string(runes[:max]), length, unit)
+}
+
+func columnTypeAt(colTypes []*ColumnType, i int) *ColumnType {
+	if i < len(colTypes) {
+		return colTypes[i]
+	}
+	return nil
+}
+
+func isBinaryType(dbType string) bool {
+	for _, t := range []string{"BLOB", "BINARY", "BYTEA", "RAW", "IMAGE", "BFILE"} {
+		if strings.Contains(dbType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDecimalType(dbType string) bool {
+	for _, t := range []string{"DECIMAL", "NUMERIC", "NUMBER", "MONEY"} {
+		if strings.Contains(dbType, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValueFormatterString(t *testing.T) {
+	precision := 2
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		name    string
+		format  *ResultFormat
+		val     interface{}
+		colType *ColumnType
+		want    string
+	}{
+		{
+			name: "null without result format",
+			val:  nil,
+			want: "",
+		},
+		{
+			name:    "binary without result format",
+			val:     []byte("Kabul"),
+			colType: &ColumnType{DatabaseType: "BLOB"},
+			want:    "Kabul",
+		},
+		{
+			name:    "date without result format",
+			val:     ts,
+			colType: &ColumnType{DatabaseType: "DATE"},
+			want:    "2021-03-04T05:06:07Z",
+		},
+		{
+			name:   "null with default marker",
+			format: &ResultFormat{},
+			val:    nil,
+			want:   "NULL",
+		},
+		{
+			name:   "null with custom marker",
+			format: &ResultFormat{NullString: "<null>"},
+			val:    nil,
+			want:   "<null>",
+		},
+		{
+			name:    "text bytes",
+			format:  &ResultFormat{},
+			val:     []byte("Kabul"),
+			colType: &ColumnType{DatabaseType: "VARCHAR"},
+			want:    "Kabul",
+		},
+		{
+			name:    "binary as hex",
+			format:  &ResultFormat{},
+			val:     []byte{0xde, 0xad, 0xbe, 0xef},
+			colType: &ColumnType{DatabaseType: "BLOB"},
+			want:    "0xdeadbeef",
+		},
+		{
+			name:    "binary as base64",
+			format:  &ResultFormat{BinaryFormat: BinaryFormatBase64},
+			val:     []byte{0xde, 0xad, 0xbe, 0xef},
+			colType: &ColumnType{DatabaseType: "BYTEA"},
+			want:    "3q2+7w==",
+		},
+		{
+			name:   "invalid utf8 without column type",
+			format: &ResultFormat{},
+			val:    []byte{0xff, 0x00},
+			want:   "0xff00",
+		},
+		{
+			name:    "date",
+			format:  &ResultFormat{},
+			val:     ts,
+			colType: &ColumnType{DatabaseType: "DATE"},
+			want:    "2021-03-04",
+		},
+		{
+			name:    "time",
+			format:  &ResultFormat{},
+			val:     ts,
+			colType: &ColumnType{DatabaseType: "TIME"},
+			want:    "05:06:07",
+		},
+		{
+			name:    "timestamp",
+			format:  &ResultFormat{},
+			val:     ts,
+			colType: &ColumnType{DatabaseType: "TIMESTAMP"},
+			want:    "2021-03-04T05:06:07Z",
+		},
+		{
+			name:    "timestamp with layout and zone",
+			format:  &ResultFormat{DateTimeFormat: "2006-01-02 15:04:05 MST", TimeZone: "Asia/Tokyo"},
+			val:     ts,
+			colType: &ColumnType{DatabaseType: "DATETIME"},
+			want:    "2021-03-04 14:06:07 JST",
+		},
+		{
+			name:    "decimal precision",
+			format:  &ResultFormat{DecimalPrecision: &precision},
+			val:     []byte("12.3456"),
+			colType: &ColumnType{DatabaseType: "DECIMAL"},
+			want:    "12.35",
+		},
+		{
+			name:    "decimal precision keeps non decimal column",
+			format:  &ResultFormat{DecimalPrecision: &precision},
+			val:     "12.3456",
+			colType: &ColumnType{DatabaseType: "TEXT"},
+			want:    "12.3456",
+		},
+		{
+			name:   "float precision",
+			format: &ResultFormat{DecimalPrecision: &precision},
+			val:    float64(1) / 3,
+			want:   "0.33",
+		},
+		{
+			name:   "truncate text",
+			format: &ResultFormat{MaxValueLength: 5},
+			val:    "abcdefghij",
+			want:   "abcde...(10 chars)",
+		},
+		{
+			name:    "truncate binary",
+			format:  &ResultFormat{MaxValueLength: 6},
+			val:     []byte{0x01, 0x02, 0x03, 0x04},
+			colType: &ColumnType{DatabaseType: "VARBINARY"},
+			want:    "0x0102...(4 bytes)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vf, err := NewValueFormatter(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := vf.String(tt.val, tt.colType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultFormatValidate(t *testing.T) {
+	precision := -1
+	tests := []struct {
+		name    string
+		format  *ResultFormat
+		wantErr bool
+	}{
+		{name: "empty", format: &ResultFormat{}},
+		{name: "invalid binary format", format: &ResultFormat{BinaryFormat: "octal"}, wantErr: true},
+		{name: "invalid time zone", format: &ResultFormat{TimeZone: "Nowhere/Unknown"}, wantErr: true},
+		{name: "negative precision", format: &ResultFormat{DecimalPrecision: &precision}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.format.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
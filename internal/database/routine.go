@@ -0,0 +1,119 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// RoutineDesc is a stored function or procedure.
+type RoutineDesc struct {
+	Schema string
+	Name   string
+	// Kind is FUNCTION or PROCEDURE
+	Kind       string
+	Params     []*RoutineParam
+	ReturnType string
+}
+
+type RoutineParam struct {
+	Name string
+	// Mode is IN, OUT or INOUT, it is empty when the database does not tell
+	Mode string
+	Type string
+}
+
+func (rp *RoutineParam) String() string {
+	items := []string{}
+	if rp.Mode != "" && !strings.EqualFold(rp.Mode, "IN") {
+		items = append(items, rp.Mode)
+	}
+	if rp.Name != "" {
+		items = append(items, rp.Name)
+	}
+	if rp.Type != "" {
+		items = append(items, rp.Type)
+	}
+	return strings.Join(items, " ")
+}
+
+func (rd *RoutineDesc) IsProcedure() bool {
+	return strings.EqualFold(rd.Kind, "PROCEDURE")
+}
+
+// Signature returns the routine in the form of "name(param type, ...) RETURNS type".
+func (rd *RoutineDesc) Signature() string {
+	params := make([]string, len(rd.Params))
+	for i, p := range rd.Params {
+		params[i] = p.String()
+	}
+	signature := fmt.Sprintf("%s(%s)", rd.Name, strings.Join(params, ", "))
+	if rd.ReturnType != "" && !rd.IsProcedure() {
+		signature += " RETURNS " + rd.ReturnType
+	}
+	return signature
+}
+
+func RoutineDoc(routines []*RoutineDesc) string {
+	buf := new(bytes.Buffer)
+	for i, routine := range routines {
+		if i > 0 {
+			fmt.Fprintln(buf)
+		}
+		kind := "function"
+		if routine.IsProcedure() {
+			kind = "procedure"
+		}
+		fmt.Fprintf(buf, "`%s`.`%s` %s", routine.Schema, routine.Name, kind)
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf, "```sql")
+		fmt.Fprintln(buf, routine.Signature())
+		fmt.Fprintln(buf, "```")
+	}
+	return buf.String()
+}
+
+// parseRoutines reads rows of schema, routine name, specific name, routine
+// type, return type, parameter name, parameter mode and parameter type. The
+// rows of a routine must be consecutive and ordered by the parameter position.
+// A routine without parameters has a single row with a NULL parameter.
+func parseRoutines(rows *sql.Rows) (map[string][]*RoutineDesc, error) {
+	schemaRoutines := map[string][]*RoutineDesc{}
+	var (
+		cur         *RoutineDesc
+		curSpecific string
+	)
+	for rows.Next() {
+		var (
+			schema, name, specific, kind, returnType sql.NullString
+			paramName, paramMode, paramType          sql.NullString
+		)
+		if err := rows.Scan(&schema, &name, &specific, &kind, &returnType, &paramName, &paramMode, &paramType); err != nil {
+			return nil, err
+		}
+		if cur == nil || cur.Schema != schema.String || curSpecific != specific.String {
+			cur = &RoutineDesc{
+				Schema:     schema.String,
+				Name:       name.String,
+				Kind:       strings.ToUpper(kind.String),
+				ReturnType: returnType.String,
+				Params:     []*RoutineParam{},
+			}
+			curSpecific = specific.String
+			schemaRoutines[cur.Schema] = append(schemaRoutines[cur.Schema], cur)
+		}
+		if paramName.Valid || paramType.Valid {
+			cur.Params = append(cur.Params, &RoutineParam{
+				Name: paramName.String,
+				Mode: strings.ToUpper(paramMode.String),
+				Type: paramType.String,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return schemaRoutines, nil
+}
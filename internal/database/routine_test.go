@@ -0,0 +1,46 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRoutines(t *testing.T) {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query(`
+	SELECT 'public', 'add', 'add_1', 'FUNCTION', 'integer', 'a', 'IN', 'integer'
+	UNION ALL SELECT 'public', 'add', 'add_1', 'FUNCTION', 'integer', 'b', 'IN', 'integer'
+	UNION ALL SELECT 'public', 'add', 'add_2', 'FUNCTION', 'numeric', 'a', 'IN', 'numeric'
+	UNION ALL SELECT 'public', 'refresh', 'refresh_3', 'PROCEDURE', NULL, NULL, NULL, NULL
+	UNION ALL SELECT 'public', 'split', 'split_4', 'PROCEDURE', NULL, 'total', 'inout', 'integer'
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got, err := parseRoutines(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signatures := []string{}
+	for _, routine := range got["public"] {
+		signatures = append(signatures, routine.Signature())
+	}
+	want := []string{
+		"add(a integer, b integer) RETURNS integer",
+		"add(a numeric) RETURNS numeric",
+		"refresh()",
+		"split(INOUT total integer)",
+	}
+	if d := cmp.Diff(want, signatures); d != "" {
+		t.Errorf("unmatched signatures (- want, + got):\n%s", d)
+	}
+}
@@ -8,6 +8,14 @@ import (
 	"time"
 )
 
+type ColumnType struct {
+	Name         string
+	DatabaseType string
+	Nullable     bool
+	// NullableKnown is false when the driver cannot report nullability
+	NullableKnown bool
+}
+
 func Columns(rows *sql.Rows) ([]string, error) {
 	var cols []string
 	var err error
@@ -26,38 +34,84 @@ func Columns(rows *sql.Rows) ([]string, error) {
 	return cols, nil
 }
 
-func ScanRows(rows *sql.Rows, columnLength int) ([][]string, error) {
-	stringRows := [][]string{}
-	for rows.Next() {
-		// scan to []interface{}
-		rowBuffer := make([]interface{}, columnLength)
-		for i := range rowBuffer {
-			rowBuffer[i] = new(interface{})
-		}
-		if err := rows.Scan(rowBuffer...); err != nil {
-			return nil, err
+func ColumnTypes(rows *sql.Rows) ([]*ColumnType, error) {
+	cols, err := Columns(rows)
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get query column types, %w", err)
+	}
+
+	colTypes := make([]*ColumnType, len(cols))
+	for i, c := range cols {
+		colType := &ColumnType{Name: c}
+		if i < len(types) {
+			colType.DatabaseType = types[i].DatabaseTypeName()
+			colType.Nullable, colType.NullableKnown = types[i].Nullable()
 		}
+		colTypes[i] = colType
+	}
+	return colTypes, nil
+}
 
-		stringRow := make([]string, columnLength)
-		for i, buf := range rowBuffer {
-			val, err := sqlValToString(buf)
-			if err != nil {
-				return nil, err
-			}
-			stringRow[i] = val
+func ScanRows(rows *sql.Rows, columnLength int) ([][]string, error) {
+	formatter, err := NewValueFormatter(nil)
+	if err != nil {
+		return nil, err
+	}
+	valueRows, err := ScanRowValues(rows, columnLength)
+	if err != nil {
+		return nil, err
+	}
+	stringRows := make([][]string, 0, len(valueRows))
+	for _, valueRow := range valueRows {
+		stringRow, err := formatter.RowStrings(valueRow, nil)
+		if err != nil {
+			return nil, err
 		}
 		stringRows = append(stringRows, stringRow)
 	}
 	return stringRows, nil
 }
 
-func sqlValToString(pointer interface{}) (string, error) {
-	res := ""
-	if pointer == nil {
-		return res, nil
+// ScanRowValues scans all rows into the values returned by the driver.
+func ScanRowValues(rows *sql.Rows, columnLength int) ([][]interface{}, error) {
+	valueRows := [][]interface{}{}
+	for rows.Next() {
+		valueRow, err := scanRowValue(rows, columnLength)
+		if err != nil {
+			return nil, err
+		}
+		valueRows = append(valueRows, valueRow)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return valueRows, nil
+}
+
+// scanRowValue scans the current row into the values returned by the driver.
+func scanRowValue(rows *sql.Rows, columnLength int) ([]interface{}, error) {
+	// scan to []interface{}
+	rowBuffer := make([]interface{}, columnLength)
+	for i := range rowBuffer {
+		rowBuffer[i] = new(interface{})
+	}
+	if err := rows.Scan(rowBuffer...); err != nil {
+		return nil, err
 	}
 
-	val := *pointer.(*interface{})
+	valueRow := make([]interface{}, columnLength)
+	for i, buf := range rowBuffer {
+		valueRow[i] = *buf.(*interface{})
+	}
+	return valueRow, nil
+}
+
+func sqlValToString(val interface{}) (string, error) {
+	res := ""
 	switch v := (val).(type) {
 	case []byte:
 		res = string(v)
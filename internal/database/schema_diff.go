@@ -0,0 +1,432 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yaamai/sqls/dialect"
+)
+
+// SchemaSnapshot is the definition of the tables of one schema.
+type SchemaSnapshot struct {
+	Driver dialect.DatabaseDriver
+	Schema string
+	// Tables is keyed by the upper cased table name
+	Tables      map[string][]*ColumnDesc
+	ForeignKeys []*ForeignKey
+}
+
+// NewSchemaSnapshot describes the tables and foreign keys of schemaName,
+// or of the current schema if schemaName is empty.
+func NewSchemaSnapshot(ctx context.Context, repo DBRepository, schemaName string) (*SchemaSnapshot, error) {
+	if schemaName == "" {
+		current, err := repo.CurrentSchema(ctx)
+		if err != nil {
+			return nil, err
+		}
+		schemaName = current
+	}
+	snapshot := &SchemaSnapshot{
+		Driver: repo.Driver(),
+		Schema: schemaName,
+		Tables: map[string][]*ColumnDesc{},
+	}
+
+	// some catalogs list the views with the tables, the views are not compared
+	views := map[string]bool{}
+	if schemaViews, err := repo.SchemaViews(ctx); err == nil {
+		for k, schemaViewDescs := range schemaViews {
+			if !strings.EqualFold(k, schemaName) {
+				continue
+			}
+			for _, view := range schemaViewDescs {
+				views[strings.ToUpper(view.Name)] = true
+			}
+		}
+	}
+
+	schemaTables, err := repo.SchemaTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for k, tables := range schemaTables {
+		if !strings.EqualFold(k, schemaName) {
+			continue
+		}
+		for _, table := range tables {
+			if !views[strings.ToUpper(table)] {
+				snapshot.Tables[strings.ToUpper(table)] = []*ColumnDesc{}
+			}
+		}
+	}
+	columns, err := repo.DescribeDatabaseTableBySchema(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	for _, col := range columns {
+		key := strings.ToUpper(col.Table)
+		if views[key] {
+			continue
+		}
+		snapshot.Tables[key] = append(snapshot.Tables[key], col)
+	}
+
+	// some drivers cannot describe foreign keys, the tables are still compared
+	fks, err := repo.DescribeForeignKeysBySchema(ctx, schemaName)
+	if err == nil {
+		for _, fk := range fks {
+			if len(*fk) > 0 {
+				snapshot.ForeignKeys = append(snapshot.ForeignKeys, fk)
+			}
+		}
+	}
+	return snapshot, nil
+}
+
+func (s *SchemaSnapshot) tableName(key string) string {
+	if cols := s.Tables[key]; len(cols) > 0 {
+		return cols[0].Table
+	}
+	return strings.ToLower(key)
+}
+
+// SchemaDiff is the difference needed to bring the target schema in line
+// with the source schema.
+type SchemaDiff struct {
+	Source *SchemaSnapshot
+	Target *SchemaSnapshot
+	// AddedTables exist only in the source, RemovedTables only in the target
+	AddedTables        []string
+	RemovedTables      []string
+	ChangedTables      []*TableDiff
+	AddedForeignKeys   []*ForeignKey
+	RemovedForeignKeys []*ForeignKey
+}
+
+type TableDiff struct {
+	Table          string
+	AddedColumns   []*ColumnDesc
+	RemovedColumns []*ColumnDesc
+	ChangedColumns []*ColumnChange
+}
+
+type ColumnChange struct {
+	Source *ColumnDesc
+	Target *ColumnDesc
+	// Changes describes each changed attribute, e.g. "type int -> bigint"
+	Changes []string
+}
+
+func (d *SchemaDiff) Empty() bool {
+	return len(d.AddedTables) == 0 && len(d.RemovedTables) == 0 && len(d.ChangedTables) == 0 &&
+		len(d.AddedForeignKeys) == 0 && len(d.RemovedForeignKeys) == 0
+}
+
+func DiffSchema(source, target *SchemaSnapshot) *SchemaDiff {
+	diff := &SchemaDiff{Source: source, Target: target}
+
+	for _, key := range sortedTableKeys(source.Tables) {
+		targetCols, ok := target.Tables[key]
+		if !ok {
+			diff.AddedTables = append(diff.AddedTables, key)
+			continue
+		}
+		if tableDiff := diffTable(source.tableName(key), source.Tables[key], targetCols); tableDiff != nil {
+			diff.ChangedTables = append(diff.ChangedTables, tableDiff)
+		}
+	}
+	for _, key := range sortedTableKeys(target.Tables) {
+		if _, ok := source.Tables[key]; !ok {
+			diff.RemovedTables = append(diff.RemovedTables, key)
+		}
+	}
+
+	targetFKs := map[string]bool{}
+	for _, fk := range target.ForeignKeys {
+		targetFKs[foreignKeySignature(fk)] = true
+	}
+	sourceFKs := map[string]bool{}
+	for _, fk := range source.ForeignKeys {
+		sig := foreignKeySignature(fk)
+		sourceFKs[sig] = true
+		if !targetFKs[sig] {
+			diff.AddedForeignKeys = append(diff.AddedForeignKeys, fk)
+		}
+	}
+	for _, fk := range target.ForeignKeys {
+		if !sourceFKs[foreignKeySignature(fk)] {
+			diff.RemovedForeignKeys = append(diff.RemovedForeignKeys, fk)
+		}
+	}
+	return diff
+}
+
+func diffTable(table string, sourceCols, targetCols []*ColumnDesc) *TableDiff {
+	tableDiff := &TableDiff{Table: table}
+	targetByName := map[string]*ColumnDesc{}
+	for _, col := range targetCols {
+		targetByName[strings.ToUpper(col.Name)] = col
+	}
+	sourceByName := map[string]*ColumnDesc{}
+	for _, col := range sourceCols {
+		sourceByName[strings.ToUpper(col.Name)] = col
+		targetCol, ok := targetByName[strings.ToUpper(col.Name)]
+		if !ok {
+			tableDiff.AddedColumns = append(tableDiff.AddedColumns, col)
+			continue
+		}
+		if change := diffColumn(col, targetCol); change != nil {
+			tableDiff.ChangedColumns = append(tableDiff.ChangedColumns, change)
+		}
+	}
+	for _, col := range targetCols {
+		if _, ok := sourceByName[strings.ToUpper(col.Name)]; !ok {
+			tableDiff.RemovedColumns = append(tableDiff.RemovedColumns, col)
+		}
+	}
+	if len(tableDiff.AddedColumns) == 0 && len(tableDiff.RemovedColumns) == 0 && len(tableDiff.ChangedColumns) == 0 {
+		return nil
+	}
+	return tableDiff
+}
+
+func diffColumn(source, target *ColumnDesc) *ColumnChange {
+	change := &ColumnChange{Source: source, Target: target}
+	if !strings.EqualFold(source.Type, target.Type) {
+		change.Changes = append(change.Changes, fmt.Sprintf("type %s -> %s", target.Type, source.Type))
+	}
+	if isNotNull(source) != isNotNull(target) {
+		change.Changes = append(change.Changes, fmt.Sprintf("null %s -> %s", nullText(target), nullText(source)))
+	}
+	if source.Default.Valid != target.Default.Valid || source.Default.String != target.Default.String {
+		change.Changes = append(change.Changes, fmt.Sprintf("default %s -> %s", defaultText(target), defaultText(source)))
+	}
+	if len(change.Changes) == 0 {
+		return nil
+	}
+	return change
+}
+
+// Report describes the differences for humans.
+func (d *SchemaDiff) Report() string {
+	buf := new(bytes.Buffer)
+	if d.Empty() {
+		fmt.Fprintln(buf, "No differences")
+		return buf.String()
+	}
+	for _, key := range d.AddedTables {
+		fmt.Fprintf(buf, "+ table %s", d.Source.tableName(key))
+		fmt.Fprintln(buf, "")
+	}
+	for _, key := range d.RemovedTables {
+		fmt.Fprintf(buf, "- table %s", d.Target.tableName(key))
+		fmt.Fprintln(buf, "")
+	}
+	for _, tableDiff := range d.ChangedTables {
+		fmt.Fprintf(buf, "~ table %s", tableDiff.Table)
+		fmt.Fprintln(buf, "")
+		for _, col := range tableDiff.AddedColumns {
+			fmt.Fprintf(buf, "  + column %s %s", col.Name, col.Type)
+			fmt.Fprintln(buf, "")
+		}
+		for _, col := range tableDiff.RemovedColumns {
+			fmt.Fprintf(buf, "  - column %s %s", col.Name, col.Type)
+			fmt.Fprintln(buf, "")
+		}
+		for _, change := range tableDiff.ChangedColumns {
+			fmt.Fprintf(buf, "  ~ column %s: %s", change.Source.Name, strings.Join(change.Changes, ", "))
+			fmt.Fprintln(buf, "")
+		}
+	}
+	for _, fk := range d.AddedForeignKeys {
+		fmt.Fprintf(buf, "+ foreign key %s", foreignKeyText(fk))
+		fmt.Fprintln(buf, "")
+	}
+	for _, fk := range d.RemovedForeignKeys {
+		fmt.Fprintf(buf, "- foreign key %s", foreignKeyText(fk))
+		fmt.Fprintln(buf, "")
+	}
+	return buf.String()
+}
+
+// DDL returns the statements that bring the target schema in line with the
+// source schema, in the dialect of the target driver. The tables are
+// qualified with the target schema.
+func (d *SchemaDiff) DDL() []string {
+	driver := d.Target.Driver
+	schemaName := d.Target.Schema
+	stmts := []string{}
+	for _, key := range d.AddedTables {
+		stmts = append(stmts, createTableDDL(schemaName, qualifiedTableName(schemaName, d.Source.tableName(key), driver), d.Source.Tables[key], nil, driver))
+	}
+	for _, tableDiff := range d.ChangedTables {
+		table := qualifiedTableName(schemaName, tableDiff.Table, driver)
+		for _, col := range tableDiff.AddedColumns {
+			stmts = append(stmts, addColumnDDL(table, col, driver))
+		}
+		for _, change := range tableDiff.ChangedColumns {
+			stmts = append(stmts, alterColumnDDL(table, change, driver)...)
+		}
+		for _, col := range tableDiff.RemovedColumns {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", table, quoteIdentifier(col.Name, driver)))
+		}
+	}
+	for _, fk := range d.AddedForeignKeys {
+		stmts = append(stmts, addForeignKeyDDL(schemaName, fk, driver))
+	}
+	for _, fk := range d.RemovedForeignKeys {
+		// the constraint name is not part of the snapshot
+		stmts = append(stmts, fmt.Sprintf("-- drop the foreign key %s", foreignKeyText(fk)))
+	}
+	for _, key := range d.RemovedTables {
+		stmts = append(stmts, fmt.Sprintf("DROP TABLE %s;", qualifiedTableName(schemaName, d.Target.tableName(key), driver)))
+	}
+	return stmts
+}
+
+// createTableDDL builds CREATE TABLE of the qualified table, the referenced
+// tables of the foreign keys are in schemaName.
+func createTableDDL(schemaName, table string, cols []*ColumnDesc, fks []*ForeignKey, driver dialect.DatabaseDriver) string {
+	lines := []string{}
+	pks := []string{}
+	for _, col := range cols {
+		lines = append(lines, "  "+columnDefinition(col, driver))
+		if isPrimaryKey(col, driver) {
+			pks = append(pks, quoteIdentifier(col.Name, driver))
+		}
+	}
+	if len(pks) > 0 {
+		lines = append(lines, fmt.Sprintf("  PRIMARY KEY (%s)", strings.Join(pks, ", ")))
+	}
+	for _, fk := range fks {
+		cols, refCols := foreignKeyColumns(fk, driver)
+		lines = append(lines, fmt.Sprintf("  FOREIGN KEY (%s) REFERENCES %s (%s)", cols, qualifiedTableName(schemaName, (*fk)[0][1].Table, driver), refCols))
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n);", table, strings.Join(lines, ",\n"))
+}
+
+func addColumnDDL(table string, col *ColumnDesc, driver dialect.DatabaseDriver) string {
+	switch driver {
+	case dialect.DatabaseDriverMssql:
+		return fmt.Sprintf("ALTER TABLE %s ADD %s;", table, columnDefinition(col, driver))
+	case dialect.DatabaseDriverOracle:
+		return fmt.Sprintf("ALTER TABLE %s ADD (%s);", table, columnDefinition(col, driver))
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", table, columnDefinition(col, driver))
+}
+
+func alterColumnDDL(table string, change *ColumnChange, driver dialect.DatabaseDriver) []string {
+	col := change.Source
+	name := quoteIdentifier(col.Name, driver)
+	switch driver {
+	case dialect.DatabaseDriverMySQL, dialect.DatabaseDriverMySQL8, dialect.DatabaseDriverMySQL57, dialect.DatabaseDriverMySQL56:
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;", table, columnDefinition(col, driver))}
+	case dialect.DatabaseDriverOracle:
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY (%s);", table, columnDefinition(col, driver))}
+	case dialect.DatabaseDriverMssql:
+		return []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s %s;", table, name, col.Type, nullText(col))}
+	case dialect.DatabaseDriverSQLite3:
+		return []string{fmt.Sprintf("-- SQLite cannot alter column %s.%s (%s), recreate the table", table, col.Name, strings.Join(change.Changes, ", "))}
+	}
+
+	stmts := []string{}
+	if !strings.EqualFold(change.Source.Type, change.Target.Type) {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", table, name, col.Type))
+	}
+	if isNotNull(change.Source) != isNotNull(change.Target) {
+		if isNotNull(col) {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, name))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", table, name))
+		}
+	}
+	if change.Source.Default != change.Target.Default {
+		if col.Default.Valid {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, name, col.Default.String))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, name))
+		}
+	}
+	return stmts
+}
+
+func addForeignKeyDDL(schemaName string, fk *ForeignKey, driver dialect.DatabaseDriver) string {
+	cols, refCols := foreignKeyColumns(fk, driver)
+	table := qualifiedTableName(schemaName, (*fk)[0][0].Table, driver)
+	refTable := qualifiedTableName(schemaName, (*fk)[0][1].Table, driver)
+	return fmt.Sprintf("ALTER TABLE %s ADD FOREIGN KEY (%s) REFERENCES %s (%s);", table, cols, refTable, refCols)
+}
+
+func foreignKeyColumns(fk *ForeignKey, driver dialect.DatabaseDriver) (string, string) {
+	cols, refCols := []string{}, []string{}
+	for _, pair := range *fk {
+		cols = append(cols, quoteIdentifier(pair[0].Name, driver))
+		refCols = append(refCols, quoteIdentifier(pair[1].Name, driver))
+	}
+	return strings.Join(cols, ", "), strings.Join(refCols, ", ")
+}
+
+func columnDefinition(col *ColumnDesc, driver dialect.DatabaseDriver) string {
+	items := []string{quoteIdentifier(col.Name, driver), col.Type}
+	if col.Default.Valid {
+		items = append(items, "DEFAULT "+col.Default.String)
+	}
+	if isNotNull(col) {
+		items = append(items, "NOT NULL")
+	}
+	return strings.Join(items, " ")
+}
+
+func isNotNull(col *ColumnDesc) bool {
+	return col.Null == "NO" || col.Null == "N"
+}
+
+func nullText(col *ColumnDesc) string {
+	if isNotNull(col) {
+		return "NOT NULL"
+	}
+	return "NULL"
+}
+
+func defaultText(col *ColumnDesc) string {
+	if !col.Default.Valid {
+		return "none"
+	}
+	return col.Default.String
+}
+
+func isPrimaryKey(col *ColumnDesc, driver dialect.DatabaseDriver) bool {
+	switch col.Key {
+	case "YES", "PRI":
+		return true
+	case "", "0", "NO":
+		return false
+	}
+	// SQLite reports the position in the primary key
+	return driver == dialect.DatabaseDriverSQLite3
+}
+
+func foreignKeySignature(fk *ForeignKey) string {
+	return strings.ToUpper(foreignKeyText(fk))
+}
+
+func foreignKeyText(fk *ForeignKey) string {
+	cols, refCols := []string{}, []string{}
+	for _, pair := range *fk {
+		cols = append(cols, pair[0].Name)
+		refCols = append(refCols, pair[1].Name)
+	}
+	return fmt.Sprintf("%s(%s) -> %s(%s)", (*fk)[0][0].Table, strings.Join(cols, ", "), (*fk)[0][1].Table, strings.Join(refCols, ", "))
+}
+
+func sortedTableKeys(tables map[string][]*ColumnDesc) []string {
+	keys := make([]string, 0, len(tables))
+	for k := range tables {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
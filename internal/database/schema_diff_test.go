@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yaamai/sqls/dialect"
+)
+
+func TestDiffSchema(t *testing.T) {
+	ctx := context.Background()
+	snapshot := func(queries ...string) *SchemaSnapshot {
+		conn, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		conn.SetMaxOpenConns(1)
+		for _, q := range queries {
+			if _, err := conn.Exec(q); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s, err := NewSchemaSnapshot(ctx, NewSQLite3DBRepository(conn), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	source := snapshot(
+		"CREATE TABLE country (code TEXT PRIMARY KEY, name TEXT NOT NULL)",
+		"CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT NOT NULL, countrycode TEXT REFERENCES country (code), population BIGINT DEFAULT 0)",
+	)
+	target := snapshot(
+		"CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT, countrycode TEXT, district TEXT)",
+		"CREATE TABLE old_city (id INTEGER)",
+	)
+
+	diff := DiffSchema(source, target)
+	wantReport := `+ table country
+- table old_city
+~ table city
+  + column population BIGINT
+  - column district TEXT
+  ~ column name: null NULL -> NOT NULL
++ foreign key city(countrycode) -> country(code)
+`
+	if d := cmp.Diff(wantReport, diff.Report()); d != "" {
+		t.Errorf("unmatched report (- want, + got):\n%s", d)
+	}
+	wantDDL := []string{
+		"CREATE TABLE country (\n  code TEXT,\n  name TEXT NOT NULL,\n  PRIMARY KEY (code)\n);",
+		"ALTER TABLE city ADD COLUMN population BIGINT DEFAULT 0;",
+		"-- SQLite cannot alter column city.name (null NULL -> NOT NULL), recreate the table",
+		"ALTER TABLE city DROP COLUMN district;",
+		"ALTER TABLE city ADD FOREIGN KEY (countrycode) REFERENCES country (code);",
+		"DROP TABLE old_city;",
+	}
+	if d := cmp.Diff(wantDDL, diff.DDL()); d != "" {
+		t.Errorf("unmatched ddl (- want, + got):\n%s", d)
+	}
+
+	if got := DiffSchema(source, source).Report(); got != "No differences\n" {
+		t.Errorf("unexpected report %q", got)
+	}
+}
+
+func TestAlterColumnDDL(t *testing.T) {
+	source := &ColumnDesc{ColumnBase: ColumnBase{Table: "city", Name: "population"}, Type: "bigint", Null: "NO", Default: sql.NullString{String: "0", Valid: true}}
+	target := &ColumnDesc{ColumnBase: ColumnBase{Table: "city", Name: "population"}, Type: "integer", Null: "YES"}
+	change := diffColumn(source, target)
+
+	tests := []struct {
+		driver dialect.DatabaseDriver
+		want   []string
+	}{
+		{
+			driver: dialect.DatabaseDriverPostgreSQL,
+			want: []string{
+				"ALTER TABLE city ALTER COLUMN population TYPE bigint;",
+				"ALTER TABLE city ALTER COLUMN population SET NOT NULL;",
+				"ALTER TABLE city ALTER COLUMN population SET DEFAULT 0;",
+			},
+		},
+		{
+			driver: dialect.DatabaseDriverMySQL,
+			want:   []string{"ALTER TABLE city MODIFY COLUMN population bigint DEFAULT 0 NOT NULL;"},
+		},
+		{
+			driver: dialect.DatabaseDriverMssql,
+			want:   []string{"ALTER TABLE city ALTER COLUMN population bigint NOT NULL;"},
+		},
+		{
+			driver: dialect.DatabaseDriverOracle,
+			want:   []string{"ALTER TABLE city MODIFY (population bigint DEFAULT 0 NOT NULL);"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.driver), func(t *testing.T) {
+			if d := cmp.Diff(tt.want, alterColumnDDL("city", change, tt.driver)); d != "" {
+				t.Errorf("unmatched ddl (- want, + got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestDiffSchemaTargetSchema(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	repo.MockDatabaseTables = func(ctx context.Context) (map[string][]string, error) {
+		return map[string][]string{"world": {"city", "Town Hall", "city_view"}}, nil
+	}
+	repo.MockDescribeDatabaseTableBySchema = func(ctx context.Context, schemaName string) ([]*ColumnDesc, error) {
+		return []*ColumnDesc{
+			{ColumnBase: ColumnBase{Schema: "world", Table: "city", Name: "id"}, Type: "integer"},
+			{ColumnBase: ColumnBase{Schema: "world", Table: "city", Name: "hall_id"}, Type: "integer"},
+			{ColumnBase: ColumnBase{Schema: "world", Table: "Town Hall", Name: "id"}, Type: "integer"},
+			{ColumnBase: ColumnBase{Schema: "world", Table: "city_view", Name: "id"}, Type: "integer"},
+		}, nil
+	}
+	repo.MockDescribeForeignKeysBySchema = func(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
+		return []*ForeignKey{{{
+			{Schema: "world", Table: "city", Name: "hall_id"},
+			{Schema: "world", Table: "Town Hall", Name: "id"},
+		}}}, nil
+	}
+	repo.MockSchemaViews = func(ctx context.Context) (map[string][]*ViewDesc, error) {
+		return map[string][]*ViewDesc{"world": {{Schema: "world", Name: "city_view"}}}, nil
+	}
+	source, err := NewSchemaSnapshot(ctx, repo, "world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := &SchemaSnapshot{
+		Driver: dialect.DatabaseDriverPostgreSQL,
+		Schema: "staging",
+		Tables: map[string][]*ColumnDesc{
+			"CITY":     {{ColumnBase: ColumnBase{Schema: "staging", Table: "city", Name: "id"}, Type: "integer"}},
+			"OLD_CITY": {{ColumnBase: ColumnBase{Schema: "staging", Table: "old_city", Name: "id"}, Type: "integer"}},
+		},
+	}
+
+	// the view is not created and the tables are in the target schema
+	wantDDL := []string{
+		"CREATE TABLE staging.\"Town Hall\" (\n  id integer\n);",
+		"ALTER TABLE staging.city ADD COLUMN hall_id integer;",
+		"ALTER TABLE staging.city ADD FOREIGN KEY (hall_id) REFERENCES staging.\"Town Hall\" (id);",
+		"DROP TABLE staging.old_city;",
+	}
+	if d := cmp.Diff(wantDDL, DiffSchema(source, target).DDL()); d != "" {
+		t.Errorf("unmatched ddl (- want, + got):\n%s", d)
+	}
+}
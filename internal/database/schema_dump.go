@@ -0,0 +1,243 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type SchemaDumpFormat string
+
+const (
+	SchemaDumpFormatJSON     SchemaDumpFormat = "json"
+	SchemaDumpFormatYAML     SchemaDumpFormat = "yaml"
+	SchemaDumpFormatMarkdown SchemaDumpFormat = "markdown"
+)
+
+// ParseSchemaDumpFormat parses the format name, yml and md are accepted as
+// well.
+func ParseSchemaDumpFormat(name string) (SchemaDumpFormat, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return SchemaDumpFormatJSON, nil
+	case "yaml", "yml":
+		return SchemaDumpFormatYAML, nil
+	case "markdown", "md":
+		return SchemaDumpFormatMarkdown, nil
+	}
+	return "", fmt.Errorf("unsupported schema dump format %q, specify json, yaml or markdown", name)
+}
+
+// DumpSchemaFile describes the schemas of the repository, or all schemas if
+// schemaNames is empty. Everything is sorted so that dumps of the same schema
+// are identical and can be compared by diff. The JSON and YAML dumps are
+// readable by the schemafile driver.
+func DumpSchemaFile(ctx context.Context, repo DBRepository, schemaNames ...string) (*SchemaFile, error) {
+	generator := NewDBCacheUpdater(repo)
+	dbCache, err := generator.GenerateDBCachePrimary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f := &SchemaFile{
+		Driver:        repo.Driver(),
+		DefaultSchema: dbCache.defaultSchema,
+		Schemas:       []*SchemaFileSchema{},
+	}
+	if len(schemaNames) == 0 {
+		schemaNames = dbCache.SortedSchemas()
+	}
+	for _, name := range schemaNames {
+		schemaName, ok := dbCache.Database(name)
+		if !ok {
+			return nil, fmt.Errorf("schema not found, %s", name)
+		}
+		schema, err := dumpSchema(ctx, repo, generator, dbCache, schemaName)
+		if err != nil {
+			return nil, fmt.Errorf("cannot dump schema %s, %w", schemaName, err)
+		}
+		f.Schemas = append(f.Schemas, schema)
+	}
+	return f, nil
+}
+
+func dumpSchema(ctx context.Context, repo DBRepository, generator *DBCacheGenerator, dbCache *DBCache, schemaName string) (*SchemaFileSchema, error) {
+	sc, err := generator.GenerateSchemaCache(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	fks, err := repo.DescribeForeignKeysBySchema(ctx, schemaName)
+	if err != nil && !errors.Is(err, ErrNotImplementation) {
+		return nil, err
+	}
+
+	schema := &SchemaFileSchema{Name: schemaName, Tables: []*SchemaFileTable{}}
+	newTable := func(tableName string) *SchemaFileTable {
+		key := columnDatabaseKey(schemaName, tableName)
+		t := &SchemaFileTable{
+			Name:    tableName,
+			Comment: sc.Comments[commentKey(schemaName, tableName, "")],
+			Columns: []*SchemaFileColumn{},
+		}
+		for _, col := range sc.Columns[key] {
+			c := &SchemaFileColumn{
+				Name:       col.Name,
+				Type:       col.Type,
+				PrimaryKey: isPrimaryKey(col, repo.Driver()),
+				NotNull:    isNotNull(col),
+				Extra:      col.Extra,
+				Comment:    Coalesce(sc.Comments[commentKey(schemaName, tableName, col.Name)], col.Comment),
+			}
+			if col.Default.Valid {
+				def := col.Default.String
+				c.Default = &def
+			}
+			t.Columns = append(t.Columns, c)
+		}
+		indexes := append([]*IndexDesc{}, sc.Indexes[key]...)
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+		for _, index := range indexes {
+			t.Indexes = append(t.Indexes, &SchemaFileIndex{
+				Name:    index.Name,
+				Columns: index.Columns,
+				Unique:  index.Unique,
+				Type:    index.Type,
+			})
+		}
+		tableFKs := []*ForeignKey{}
+		for _, fk := range fks {
+			if len(*fk) > 0 && strings.EqualFold((*fk)[0][0].Table, tableName) {
+				tableFKs = append(tableFKs, fk)
+			}
+		}
+		sort.Slice(tableFKs, func(i, j int) bool { return foreignKeyText(tableFKs[i]) < foreignKeyText(tableFKs[j]) })
+		for _, fk := range tableFKs {
+			ref := &SchemaFileReference{Table: (*fk)[0][1].Table}
+			if !strings.EqualFold((*fk)[0][1].Schema, schemaName) {
+				ref.Schema = (*fk)[0][1].Schema
+			}
+			sfk := &SchemaFileForeignKey{References: ref}
+			for _, pair := range *fk {
+				sfk.Columns = append(sfk.Columns, pair[0].Name)
+				ref.Columns = append(ref.Columns, pair[1].Name)
+			}
+			t.ForeignKeys = append(t.ForeignKeys, sfk)
+		}
+		return t
+	}
+
+	tables, _ := dbCache.SortedTablesByDBName(schemaName)
+	for _, tableName := range tables {
+		schema.Tables = append(schema.Tables, newTable(tableName))
+	}
+	views, _ := dbCache.SortedViewsByDBName(schemaName)
+	for _, view := range views {
+		t := newTable(view.Name)
+		t.View = true
+		t.Materialized = view.Materialized
+		t.Definition = strings.TrimSpace(view.Definition)
+		schema.Tables = append(schema.Tables, t)
+	}
+	sort.SliceStable(schema.Tables, func(i, j int) bool { return schema.Tables[i].Name < schema.Tables[j].Name })
+	return schema, nil
+}
+
+// WriteSchemaFile writes the schema file in the format.
+func WriteSchemaFile(w io.Writer, f *SchemaFile, format SchemaDumpFormat) error {
+	switch format {
+	case SchemaDumpFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(f)
+	case SchemaDumpFormatYAML:
+		b, err := yaml.Marshal(f)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case SchemaDumpFormatMarkdown:
+		return writeSchemaMarkdown(w, f)
+	}
+	return fmt.Errorf("unsupported schema dump format %q, specify json, yaml or markdown", format)
+}
+
+func writeSchemaMarkdown(w io.Writer, f *SchemaFile) error {
+	bw := bufio.NewWriter(w)
+	for i, schema := range f.Schemas {
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintf(bw, "# %s\n", schema.Name)
+		for _, table := range schema.Tables {
+			kind := "table"
+			switch {
+			case table.Materialized:
+				kind = "materialized view"
+			case table.View:
+				kind = "view"
+			}
+			fmt.Fprintln(bw)
+			fmt.Fprintf(bw, "## `%s` %s\n", table.Name, kind)
+			if table.Comment != "" {
+				fmt.Fprintln(bw)
+				fmt.Fprintln(bw, strings.TrimSpace(table.Comment))
+			}
+			fmt.Fprintln(bw)
+			fmt.Fprintln(bw, "| Column | Type | Null | Primary key | Default | Extra | Comment |")
+			fmt.Fprintln(bw, "| :----- | :--- | :--- | :---------- | :------ | :---- | :------ |")
+			for _, col := range table.Columns {
+				null, pk, def := "YES", "", ""
+				if col.NotNull {
+					null = "NO"
+				}
+				if col.PrimaryKey {
+					pk = "YES"
+				}
+				if col.Default != nil {
+					def = "`" + tableCellReplacer.Replace(*col.Default) + "`"
+				}
+				fmt.Fprintf(bw, "| `%s` | `%s` | %s | %s | %s | %s | %s |\n",
+					col.Name, tableCellReplacer.Replace(col.Type), null, pk, def,
+					tableCellReplacer.Replace(col.Extra), tableCellReplacer.Replace(col.Comment))
+			}
+			if len(table.Indexes) > 0 {
+				fmt.Fprintln(bw)
+				fmt.Fprintln(bw, "| Index | Columns | Unique | Type |")
+				fmt.Fprintln(bw, "| :---- | :------ | :----- | :--- |")
+				for _, index := range table.Indexes {
+					unique := ""
+					if index.Unique {
+						unique = "YES"
+					}
+					fmt.Fprintf(bw, "| `%s` | `%s` | %s | %s |\n", index.Name, strings.Join(index.Columns, "`, `"), unique, index.Type)
+				}
+			}
+			if len(table.ForeignKeys) > 0 {
+				fmt.Fprintln(bw)
+				fmt.Fprintln(bw, "Foreign keys:")
+				fmt.Fprintln(bw)
+				for _, fk := range table.ForeignKeys {
+					ref := fk.References.Table
+					if fk.References.Schema != "" {
+						ref = fk.References.Schema + "." + ref
+					}
+					fmt.Fprintf(bw, "- `%s` → `%s` (`%s`)\n", strings.Join(fk.Columns, "`, `"), ref, strings.Join(fk.References.Columns, "`, `"))
+				}
+			}
+			if table.Definition != "" {
+				fmt.Fprintln(bw)
+				fmt.Fprintln(bw, "```sql")
+				fmt.Fprintln(bw, table.Definition)
+				fmt.Fprintln(bw, "```")
+			}
+		}
+	}
+	return bw.Flush()
+}
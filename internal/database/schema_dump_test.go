@@ -0,0 +1,108 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func openTestSchemaFile(t *testing.T, path string) DBRepository {
+	t.Helper()
+	cfg := &DBConfig{Driver: "schemafile", DataSourceName: path}
+	conn, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	repo, err := CreateRepository(cfg.Driver, conn.Conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestDumpSchemaFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schema.yml")
+	if err := os.WriteFile(path, []byte(testSchemaFileYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := DumpSchemaFile(ctx, openTestSchemaFile(t, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.DefaultSchema != "public" || len(f.Schemas) != 2 || f.Schemas[0].Name != "audit" {
+		t.Fatalf("unexpected dump %+v", f)
+	}
+	tables := []string{}
+	for _, table := range f.Schemas[1].Tables {
+		tables = append(tables, table.Name)
+	}
+	if diff := cmp.Diff([]string{"orders", "user_names", "users"}, tables); diff != "" {
+		t.Errorf("unmatched tables (- want, + got):\n%s", diff)
+	}
+	orders := f.Schemas[1].Tables[0]
+	wantFK := []*SchemaFileForeignKey{{Columns: []string{"user_id"}, References: &SchemaFileReference{Table: "users", Columns: []string{"id"}}}}
+	if diff := cmp.Diff(wantFK, orders.ForeignKeys); diff != "" {
+		t.Errorf("unmatched foreign keys (- want, + got):\n%s", diff)
+	}
+	if !orders.Columns[0].PrimaryKey || !orders.Columns[0].NotNull || *orders.Columns[1].Default != "0" {
+		t.Errorf("unexpected columns %+v %+v", orders.Columns[0], orders.Columns[1])
+	}
+
+	// the YAML dump is a schema file, dumping it again gives the same result
+	var yamlDump bytes.Buffer
+	if err := WriteSchemaFile(&yamlDump, f, SchemaDumpFormatYAML); err != nil {
+		t.Fatal(err)
+	}
+	dumpPath := filepath.Join(dir, "dump.yml")
+	if err := os.WriteFile(dumpPath, yamlDump.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	redumped, err := DumpSchemaFile(ctx, openTestSchemaFile(t, dumpPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(f, redumped); diff != "" {
+		t.Errorf("unmatched dump of the dump (- want, + got):\n%s", diff)
+	}
+
+	var jsonDump bytes.Buffer
+	if err := WriteSchemaFile(&jsonDump, f, SchemaDumpFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(jsonDump.String(), `"name": "user_names"`) {
+		t.Errorf("unexpected JSON dump:\n%s", jsonDump.String())
+	}
+
+	var markdown bytes.Buffer
+	if err := WriteSchemaFile(&markdown, f, SchemaDumpFormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# public\n",
+		"## `users` table\n\nusers of the service\n",
+		"| `name` | `text` | NO |  |  |  | display name |\n",
+		"| `users_name_idx` | `name` | YES | BTREE |\n",
+		"- `user_id` → `users` (`id`)\n",
+		"## `user_names` view\n",
+		"```sql\nSELECT name FROM users\n```\n",
+	} {
+		if !strings.Contains(markdown.String(), want) {
+			t.Errorf("%q is not found in the markdown dump:\n%s", want, markdown.String())
+		}
+	}
+
+	if _, err := DumpSchemaFile(ctx, openTestSchemaFile(t, path), "missing"); err == nil {
+		t.Error("expected an error for the missing schema")
+	}
+	if _, err := ParseSchemaDumpFormat("csv"); err == nil {
+		t.Error("expected an error for the unsupported format")
+	}
+}
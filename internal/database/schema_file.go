@@ -0,0 +1,266 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yaamai/sqls/dialect"
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	RegisterOpen(dialect.DatabaseDriverSchemaFile, schemaFileOpen)
+	RegisterFactory(dialect.DatabaseDriverSchemaFile, NewSchemaFileDBRepository)
+}
+
+// SchemaFile is a snapshot of schemas read by the schemafile driver, written
+// in JSON or YAML. Queries are the canned results of the queries executed on
+// the connection.
+type SchemaFile struct {
+	// Driver is the dialect of the schema, it is used for keyword completion
+	Driver        dialect.DatabaseDriver `json:"driver,omitempty" yaml:"driver,omitempty"`
+	DefaultSchema string                 `json:"defaultSchema" yaml:"defaultSchema"`
+	Schemas       []*SchemaFileSchema    `json:"schemas" yaml:"schemas"`
+	Queries       []*SchemaFileQuery     `json:"queries,omitempty" yaml:"queries,omitempty"`
+}
+
+type SchemaFileSchema struct {
+	Name   string             `json:"name" yaml:"name"`
+	Tables []*SchemaFileTable `json:"tables" yaml:"tables"`
+}
+
+type SchemaFileTable struct {
+	Name    string `json:"name" yaml:"name"`
+	Comment string `json:"comment,omitempty" yaml:"comment,omitempty"`
+	// View is true for a view, Definition is the query of the view
+	View         bool                    `json:"view,omitempty" yaml:"view,omitempty"`
+	Materialized bool                    `json:"materialized,omitempty" yaml:"materialized,omitempty"`
+	Definition   string                  `json:"definition,omitempty" yaml:"definition,omitempty"`
+	Columns      []*SchemaFileColumn     `json:"columns" yaml:"columns"`
+	Indexes      []*SchemaFileIndex      `json:"indexes,omitempty" yaml:"indexes,omitempty"`
+	ForeignKeys  []*SchemaFileForeignKey `json:"foreignKeys,omitempty" yaml:"foreignKeys,omitempty"`
+}
+
+type SchemaFileColumn struct {
+	Name       string  `json:"name" yaml:"name"`
+	Type       string  `json:"type" yaml:"type"`
+	PrimaryKey bool    `json:"primaryKey,omitempty" yaml:"primaryKey,omitempty"`
+	NotNull    bool    `json:"notNull,omitempty" yaml:"notNull,omitempty"`
+	Default    *string `json:"default,omitempty" yaml:"default,omitempty"`
+	Extra      string  `json:"extra,omitempty" yaml:"extra,omitempty"`
+	Comment    string  `json:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+type SchemaFileIndex struct {
+	Name    string   `json:"name" yaml:"name"`
+	Columns []string `json:"columns" yaml:"columns"`
+	Unique  bool     `json:"unique,omitempty" yaml:"unique,omitempty"`
+	Type    string   `json:"type,omitempty" yaml:"type,omitempty"`
+}
+
+type SchemaFileForeignKey struct {
+	Columns    []string             `json:"columns" yaml:"columns"`
+	References *SchemaFileReference `json:"references" yaml:"references"`
+}
+
+type SchemaFileReference struct {
+	// Schema is the schema of the table by default
+	Schema  string   `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Table   string   `json:"table" yaml:"table"`
+	Columns []string `json:"columns" yaml:"columns"`
+}
+
+// SchemaFileQuery is the canned result of a query. The query matches
+// regardless of the case and the whitespaces. Error is returned instead of
+// the result when it is set.
+type SchemaFileQuery struct {
+	Query        string          `json:"query" yaml:"query"`
+	Columns      []string        `json:"columns,omitempty" yaml:"columns,omitempty"`
+	Rows         [][]interface{} `json:"rows,omitempty" yaml:"rows,omitempty"`
+	RowsAffected int64           `json:"rowsAffected,omitempty" yaml:"rowsAffected,omitempty"`
+	Error        string          `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// LoadSchemaFile reads a schema file, a file with the .json extension is
+// read as JSON and the others as YAML.
+func LoadSchemaFile(path string) (*SchemaFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read schema file, %w", err)
+	}
+	f := &SchemaFile{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(b, f)
+	} else {
+		err = yaml.Unmarshal(b, f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema file %s, %w", path, err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid schema file %s, %w", path, err)
+	}
+	return f, nil
+}
+
+func (f *SchemaFile) Validate() error {
+	for _, schema := range f.Schemas {
+		if schema.Name == "" {
+			return fmt.Errorf("required: schemas[].name")
+		}
+		for _, table := range schema.Tables {
+			if table.Name == "" {
+				return fmt.Errorf("required: schemas[].tables[].name in %s", schema.Name)
+			}
+			for _, fk := range table.ForeignKeys {
+				if fk.References == nil || fk.References.Table == "" {
+					return fmt.Errorf("required: foreignKeys[].references.table in %s.%s", schema.Name, table.Name)
+				}
+				if len(fk.Columns) == 0 || len(fk.Columns) != len(fk.References.Columns) {
+					return fmt.Errorf("unmatched foreign key columns in %s.%s", schema.Name, table.Name)
+				}
+			}
+		}
+	}
+	for _, query := range f.Queries {
+		if query.Query == "" {
+			return fmt.Errorf("required: queries[].query")
+		}
+	}
+	return nil
+}
+
+// ddlSchema returns the schema served by the repository.
+func (f *SchemaFile) ddlSchema() *DDLSchema {
+	defaultSchema := f.DefaultSchema
+	if defaultSchema == "" && len(f.Schemas) > 0 {
+		defaultSchema = f.Schemas[0].Name
+	}
+	s := NewDDLSchema(defaultSchema)
+	for _, schema := range f.Schemas {
+		s.addSchema(schema.Name)
+		for _, table := range schema.Tables {
+			t := &ddlTable{
+				schema:  schema.Name,
+				name:    table.Name,
+				comment: table.Comment,
+			}
+			if table.View {
+				t.view = &ViewDesc{
+					Schema:       schema.Name,
+					Name:         table.Name,
+					Materialized: table.Materialized,
+					Definition:   table.Definition,
+				}
+			}
+			for _, col := range table.Columns {
+				desc := &ColumnDesc{
+					ColumnBase: ColumnBase{Schema: schema.Name, Table: table.Name, Name: col.Name},
+					Type:       col.Type,
+					Null:       "YES",
+					Extra:      col.Extra,
+					Comment:    col.Comment,
+				}
+				if col.NotNull || col.PrimaryKey {
+					desc.Null = "NO"
+				}
+				if col.PrimaryKey {
+					desc.Key = "YES"
+				}
+				if col.Default != nil {
+					desc.Default = sql.NullString{String: *col.Default, Valid: true}
+				}
+				t.columns = append(t.columns, desc)
+			}
+			for _, index := range table.Indexes {
+				t.indexes = append(t.indexes, &IndexDesc{
+					Schema:  schema.Name,
+					Table:   table.Name,
+					Name:    index.Name,
+					Columns: index.Columns,
+					Unique:  index.Unique,
+					Type:    index.Type,
+				})
+			}
+			for _, fk := range table.ForeignKeys {
+				refSchema := Coalesce(fk.References.Schema, schema.Name)
+				pairs := ForeignKey{}
+				for i, column := range fk.Columns {
+					pairs = append(pairs, [2]*ColumnBase{
+						{Schema: schema.Name, Table: table.Name, Name: column},
+						{Schema: refSchema, Table: fk.References.Table, Name: fk.References.Columns[i]},
+					})
+				}
+				t.foreignKeys = append(t.foreignKeys, &pairs)
+			}
+			s.tables[columnDatabaseKey(schema.Name, table.Name)] = t
+		}
+	}
+	return s
+}
+
+// query returns the canned result of the query.
+func (f *SchemaFile) query(query string) (*SchemaFileQuery, bool) {
+	normalized := normalizeCannedQuery(query)
+	for _, q := range f.Queries {
+		if normalizeCannedQuery(q.Query) == normalized {
+			return q, true
+		}
+	}
+	return nil, false
+}
+
+func normalizeCannedQuery(query string) string {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	return strings.ToUpper(strings.Join(strings.Fields(query), " "))
+}
+
+func schemaFileOpen(connCfg *DBConfig) (*DBConnection, error) {
+	f, err := LoadSchemaFile(connCfg.DataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	return &DBConnection{
+		Conn:   sql.OpenDB(&schemaFileConnector{file: f}),
+		Driver: f.Driver,
+	}, nil
+}
+
+// SchemaFileDBRepository serves the metadata of a schema file. Queries are
+// answered by the canned results of the file.
+type SchemaFileDBRepository struct {
+	*DDLDBRepository
+	Conn *sql.DB
+}
+
+func NewSchemaFileDBRepository(conn *sql.DB) DBRepository {
+	f := &SchemaFile{}
+	if connector, ok := conn.Driver().(*schemaFileConnector); ok {
+		f = connector.file
+	}
+	return &SchemaFileDBRepository{
+		DDLDBRepository: &DDLDBRepository{driver: f.Driver, schema: f.ddlSchema()},
+		Conn:            conn,
+	}
+}
+
+func (db *SchemaFileDBRepository) CurrentDatabase(ctx context.Context) (string, error) {
+	return "", nil
+}
+
+func (db *SchemaFileDBRepository) Databases(ctx context.Context) ([]string, error) {
+	return []string{}, nil
+}
+
+func (db *SchemaFileDBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
+	return db.Conn.ExecContext(ctx, query)
+}
+
+func (db *SchemaFileDBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
+	return db.Conn.QueryContext(ctx, query)
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// schemaFileConnector is the database/sql connector of a schema file, it is
+// also the driver of the connections so that the repository can read the
+// file from sql.DB.
+type schemaFileConnector struct {
+	file *SchemaFile
+}
+
+func (c *schemaFileConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &schemaFileConn{file: c.file}, nil
+}
+
+func (c *schemaFileConnector) Driver() driver.Driver {
+	return c
+}
+
+func (c *schemaFileConnector) Open(name string) (driver.Conn, error) {
+	return &schemaFileConn{file: c.file}, nil
+}
+
+type schemaFileConn struct {
+	file *SchemaFile
+}
+
+func (c *schemaFileConn) Prepare(query string) (driver.Stmt, error) {
+	return &schemaFileStmt{conn: c, query: query}, nil
+}
+
+func (c *schemaFileConn) Close() error {
+	return nil
+}
+
+func (c *schemaFileConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported by the schema file")
+}
+
+func (c *schemaFileConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, err := c.cannedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return &schemaFileRows{query: q}, nil
+}
+
+func (c *schemaFileConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	q, err := c.cannedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(q.RowsAffected), nil
+}
+
+func (c *schemaFileConn) cannedQuery(query string) (*SchemaFileQuery, error) {
+	q, ok := c.file.query(query)
+	if !ok {
+		return nil, fmt.Errorf("no canned result in the schema file for the query: %s", query)
+	}
+	if q.Error != "" {
+		return nil, errors.New(q.Error)
+	}
+	return q, nil
+}
+
+type schemaFileStmt struct {
+	conn  *schemaFileConn
+	query string
+}
+
+func (s *schemaFileStmt) Close() error {
+	return nil
+}
+
+func (s *schemaFileStmt) NumInput() int {
+	return -1
+}
+
+func (s *schemaFileStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, nil)
+}
+
+func (s *schemaFileStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, nil)
+}
+
+type schemaFileRows struct {
+	query *SchemaFileQuery
+	next  int
+}
+
+func (r *schemaFileRows) Columns() []string {
+	return r.query.Columns
+}
+
+func (r *schemaFileRows) Close() error {
+	return nil
+}
+
+func (r *schemaFileRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.query.Rows) {
+		return io.EOF
+	}
+	row := r.query.Rows[r.next]
+	r.next++
+	for i := range dest {
+		if i >= len(row) {
+			dest[i] = nil
+			continue
+		}
+		dest[i] = schemaFileValue(row[i])
+	}
+	return nil
+}
+
+// schemaFileValue converts a value decoded from JSON or YAML to a value of
+// database/sql.
+func schemaFileValue(v interface{}) driver.Value {
+	switch v := v.(type) {
+	case nil, int64, float64, bool, string:
+		return v
+	case int:
+		return int64(v)
+	case uint64:
+		return float64(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testSchemaFileYAML = `
+driver: postgresql
+defaultSchema: public
+schemas:
+  - name: public
+    tables:
+      - name: users
+        comment: users of the service
+        columns:
+          - {name: id, type: integer, primaryKey: true}
+          - {name: name, type: text, notNull: true, comment: display name}
+        indexes:
+          - {name: users_name_idx, columns: [name], unique: true, type: BTREE}
+      - name: orders
+        columns:
+          - {name: id, type: integer, primaryKey: true}
+          - {name: user_id, type: integer, default: "0"}
+        foreignKeys:
+          - columns: [user_id]
+            references: {table: users, columns: [id]}
+      - name: user_names
+        view: true
+        definition: SELECT name FROM users
+        columns:
+          - {name: name, type: text}
+  - name: audit
+    tables:
+      - name: logs
+        columns:
+          - {name: message, type: text}
+queries:
+  - query: SELECT id, name FROM users
+    columns: [id, name]
+    rows:
+      - [1, alice]
+      - [2, null]
+  - query: DELETE FROM users
+    rowsAffected: 2
+  - query: DROP TABLE users
+    error: permission denied
+`
+
+func TestSchemaFileDriver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.yml")
+	if err := os.WriteFile(path, []byte(testSchemaFileYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &DBConfig{Driver: "schemafile", DataSourceName: path}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if conn.Driver != "postgresql" {
+		t.Errorf("unexpected dialect %q", conn.Driver)
+	}
+	repo, err := CreateRepository(cfg.Driver, conn.Conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	worker := NewWorker()
+	if err := worker.ReCache(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+	dbCache := worker.Cache()
+	if diff := cmp.Diff([]string{"audit", "public"}, dbCache.SortedSchemas()); diff != "" {
+		t.Errorf("unmatched schemas (- want, + got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"orders", "users"}, dbCache.SortedTables()); diff != "" {
+		t.Errorf("unmatched tables (- want, + got):\n%s", diff)
+	}
+	if _, ok := dbCache.View("user_names"); !ok {
+		t.Error("not found view user_names")
+	}
+	col, ok := dbCache.Column("users", "name")
+	if !ok || col.Type != "text" || col.Null != "NO" || col.Comment != "display name" {
+		t.Errorf("unexpected column %+v", col)
+	}
+	if got := dbCache.TableComment("public", "users"); got != "users of the service" {
+		t.Errorf("unmatched table comment, got %q", got)
+	}
+	if indexes, _ := dbCache.TableIndexes("users"); len(indexes) != 1 || !indexes[0].Unique {
+		t.Errorf("unexpected indexes %v", indexes)
+	}
+	wantFK := &ForeignKey{{
+		{Schema: "public", Table: "orders", Name: "user_id"},
+		{Schema: "public", Table: "users", Name: "id"},
+	}}
+	if diff := cmp.Diff([]*ForeignKey{wantFK}, dbCache.ForeignKeys["orders"]["users"]); diff != "" {
+		t.Errorf("unmatched foreign keys (- want, + got):\n%s", diff)
+	}
+
+	rows, err := repo.Query(ctx, "select id,  name\nfrom users;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got := [][]interface{}{}
+	for rows.Next() {
+		var id int64
+		var name *string
+		if err := rows.Scan(&id, &name); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, []interface{}{id, name})
+	}
+	if len(got) != 2 || got[0][0] != int64(1) || *got[0][1].(*string) != "alice" || got[1][1].(*string) != nil {
+		t.Errorf("unexpected rows %v", got)
+	}
+
+	res, err := repo.Exec(ctx, "DELETE FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 2 {
+		t.Errorf("unexpected rows affected %d", n)
+	}
+	if _, err := repo.Exec(ctx, "DROP TABLE users"); err == nil || err.Error() != "permission denied" {
+		t.Errorf("expected the canned error, got %v", err)
+	}
+	if _, err := repo.Query(ctx, "SELECT 1"); err == nil {
+		t.Error("expected an error for the query without a canned result")
+	}
+}
+
+func TestLoadSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "schema.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"schemas": [{"name": "main", "tables": [{"name": "t", "columns": [{"name": "c", "type": "int"}]}]}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := LoadSchemaFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema := f.ddlSchema(); schema.defaultSchema != "main" || schema.table([]string{"t"}) == nil {
+		t.Errorf("unexpected schema of %+v", f)
+	}
+
+	invalidPath := filepath.Join(dir, "invalid.yml")
+	invalid := "schemas:\n  - name: main\n    tables:\n      - name: t\n        foreignKeys:\n          - columns: [a, b]\n            references: {table: u, columns: [a]}\n"
+	if err := os.WriteFile(invalidPath, []byte(invalid), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSchemaFile(invalidPath); err == nil {
+		t.Error("expected an error for the unmatched foreign key columns")
+	}
+}
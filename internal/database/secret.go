@@ -0,0 +1,104 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// secretCommandTimeout limits the time of a password command, which may wait
+// for the passphrase of a password manager.
+const secretCommandTimeout = time.Minute
+
+func validateSecret(name, value, file, command string) error {
+	n := 0
+	for _, v := range []string{value, file, command} {
+		if v != "" {
+			n++
+		}
+	}
+	if n > 1 {
+		return fmt.Errorf("invalid: specify one of %s, %sFile and %sCommand", name, name, name)
+	}
+	return nil
+}
+
+// withSecrets returns the config in which the password and the SSH
+// passphrase are read from their files or commands. c is returned as is if
+// it has no secret to read.
+func (c *DBConfig) withSecrets() (*DBConfig, error) {
+	hasSSHSecret := c.SSHCfg != nil && (c.SSHCfg.PassPhraseFile != "" || c.SSHCfg.PassPhraseCommand != "")
+	if c.PasswdFile == "" && c.PasswdCommand == "" && !hasSSHSecret {
+		return c, nil
+	}
+
+	resolved := *c
+	passwd, err := readSecret("passwd", c.Passwd, c.PasswdFile, c.PasswdCommand)
+	if err != nil {
+		return nil, err
+	}
+	resolved.Passwd = passwd
+	if hasSSHSecret {
+		sshCfg := *c.SSHCfg
+		passPhrase, err := readSecret("passPhrase", sshCfg.PassPhrase, sshCfg.PassPhraseFile, sshCfg.PassPhraseCommand)
+		if err != nil {
+			return nil, err
+		}
+		sshCfg.PassPhrase = passPhrase
+		resolved.SSHCfg = &sshCfg
+	}
+	return &resolved, nil
+}
+
+// readSecret returns the content of the file or the output of the command
+// without the trailing newline, or value if neither is given. The command is
+// run by the shell.
+func readSecret(name, value, file, command string) (string, error) {
+	switch {
+	case file != "":
+		path, err := expandHome(file)
+		if err != nil {
+			return "", err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot read %sFile, %w", name, err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	case command != "":
+		ctx, cancel := context.WithTimeout(context.Background(), secretCommandTimeout)
+		defer cancel()
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+		} else {
+			cmd = exec.CommandContext(ctx, "sh", "-c", command)
+		}
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("failed %sCommand, %w, %s", name, err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimRight(string(out), "\r\n"), nil
+	}
+	return value, nil
+}
+
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, rest), nil
+}
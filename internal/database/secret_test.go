@@ -0,0 +1,49 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDBConfig_withSecrets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the password command uses sh")
+	}
+	passwdFile := filepath.Join(t.TempDir(), "passwd")
+	if err := os.WriteFile(passwdFile, []byte("file-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &DBConfig{Driver: "mysql", Passwd: "plain"}
+	if got, err := cfg.withSecrets(); err != nil || got != cfg {
+		t.Errorf("config without secrets is not returned as is, %v", err)
+	}
+
+	cfg = &DBConfig{
+		Driver:     "mysql",
+		PasswdFile: passwdFile,
+		SSHCfg:     &SSHConfig{Host: "bastion", PassPhraseCommand: "echo command-secret"},
+	}
+	got, err := cfg.withSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Passwd != "file-secret" || got.SSHCfg.PassPhrase != "command-secret" {
+		t.Errorf("unexpected secrets %q, %q", got.Passwd, got.SSHCfg.PassPhrase)
+	}
+	if cfg.Passwd != "" || cfg.SSHCfg.PassPhrase != "" {
+		t.Error("the config is modified")
+	}
+
+	if _, err := (&DBConfig{PasswdCommand: "echo failed >&2; exit 1"}).withSecrets(); err == nil {
+		t.Error("expected an error for the failed command")
+	}
+	if _, err := (&DBConfig{PasswdFile: filepath.Join(t.TempDir(), "missing")}).withSecrets(); err == nil {
+		t.Error("expected an error for the missing file")
+	}
+	if err := (&DBConfig{Driver: "mysql", Passwd: "plain", PasswdCommand: "echo secret", DataSourceName: "dsn"}).Validate(); err == nil {
+		t.Error("expected an error for both passwd and passwdCommand")
+	}
+}
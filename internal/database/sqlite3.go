@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 
@@ -170,3 +171,96 @@ func (db *SQLite3DBRepository) Exec(ctx context.Context, query string) (sql.Resu
 func (db *SQLite3DBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
 	return db.Conn.QueryContext(ctx, query)
 }
+
+func (db *SQLite3DBRepository) Explain(ctx context.Context, query string) (*PlanNode, error) {
+	rows, err := db.Conn.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	nodes := []*flatPlanNode{}
+	for rows.Next() {
+		var (
+			id, parent, notused int64
+			detail              string
+		)
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, &flatPlanNode{id: id, parentID: parent, node: &PlanNode{Operation: detail}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return buildPlanTree(nodes), nil
+}
+
+func (db *SQLite3DBRepository) ShowCreateTable(ctx context.Context, schemaName, tableName string) (string, error) {
+	var ddl string
+	err := db.Conn.QueryRowContext(
+		ctx,
+		`
+	SELECT sql
+	  FROM sqlite_master
+	 WHERE type IN ('table', 'view')
+	   AND name = ?
+	   COLLATE NOCASE
+	`, tableName).Scan(&ddl)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("table not found, %q", tableName)
+	}
+	if err != nil {
+		return "", err
+	}
+	return ddl + ";", nil
+}
+
+func (db *SQLite3DBRepository) SchemaViews(ctx context.Context) (map[string][]*ViewDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT '', name, sql, 'VIEW'
+	  FROM sqlite_master
+	 WHERE type = 'view'
+	 ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaViews(rows)
+}
+
+func (db *SQLite3DBRepository) SchemaSequences(ctx context.Context) (map[string][]string, error) {
+	// SQLite has no sequence
+	return map[string][]string{}, nil
+}
+
+func (db *SQLite3DBRepository) SchemaRoutines(ctx context.Context) (map[string][]*RoutineDesc, error) {
+	return nil, ErrNotImplementation
+}
+
+func (db *SQLite3DBRepository) DescribeIndexesBySchema(ctx context.Context, _ string) ([]*IndexDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT '', m.name, il.name,
+	       CASE WHEN il."unique" THEN 'YES' ELSE 'NO' END,
+	       'BTREE', ii.name
+	  FROM sqlite_master m
+	  JOIN pragma_index_list(m.name) il
+	  JOIN pragma_index_info(il.name) ii
+	 WHERE m.type = 'table'
+	 ORDER BY m.name, il.name, ii.seqno
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseIndexes(rows)
+}
+
+func (db *SQLite3DBRepository) DescribeCommentsBySchema(ctx context.Context, _ string) ([]*CommentDesc, error) {
+	// SQLite has no comment on tables and columns
+	return nil, ErrNotImplementation
+}
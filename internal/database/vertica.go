@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/yaamai/sqls/dialect"
 	_ "github.com/vertica/vertica-sql-go"
@@ -237,6 +238,84 @@ func (db *VerticaDBRepository) Query(ctx context.Context, query string) (*sql.Ro
 	return db.Conn.QueryContext(ctx, query)
 }
 
+func (db *VerticaDBRepository) Explain(ctx context.Context, query string) (*PlanNode, error) {
+	return nil, ErrNotImplementation
+}
+
+func (db *VerticaDBRepository) ShowCreateTable(ctx context.Context, schemaName, tableName string) (string, error) {
+	var definition string
+	err := db.Conn.QueryRowContext(
+		ctx,
+		`
+	SELECT view_definition
+	  FROM v_catalog.views
+	 WHERE table_schema = ?
+	   AND table_name = ?
+	`, schemaName, tableName).Scan(&definition)
+	if err == nil {
+		return createViewDDL(schemaName, tableName, definition, dialect.DatabaseDriverVertica), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	return reconstructTableDDL(ctx, db, schemaName, tableName)
+}
+
+func (db *VerticaDBRepository) SchemaViews(ctx context.Context) (map[string][]*ViewDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+    SELECT table_schema, table_name, view_definition, 'VIEW'
+      FROM v_catalog.views
+     ORDER BY table_schema, table_name
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaViews(rows)
+}
+
+func (db *VerticaDBRepository) SchemaSequences(ctx context.Context) (map[string][]string, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+    SELECT sequence_schema, sequence_name
+      FROM v_catalog.sequences
+     ORDER BY sequence_schema, sequence_name
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseSchemaObjects(rows)
+}
+
+func (db *VerticaDBRepository) SchemaRoutines(ctx context.Context) (map[string][]*RoutineDesc, error) {
+	return nil, ErrNotImplementation
+}
+
+func (db *VerticaDBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	// Vertica has projections instead of indexes
+	return nil, ErrNotImplementation
+}
+
+func (db *VerticaDBRepository) DescribeCommentsBySchema(ctx context.Context, schemaName string) ([]*CommentDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+    SELECT object_schema, object_name, child_object, comment
+      FROM v_catalog.comments
+     WHERE object_schema = ?
+       AND object_type IN ('TABLE', 'VIEW', 'COLUMN')
+    `, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return parseComments(rows)
+}
+
 func (db *VerticaDBRepository) DescribeForeignKeysBySchema(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
 	return nil, fmt.Errorf("describe foreign keys is not supported")
 }
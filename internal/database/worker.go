@@ -2,42 +2,103 @@ package database
 
 import (
 	"context"
+	"errors"
 	"log"
+	"maps"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
+// defaultMaxLoadedSchemas bounds the schemas loaded on first reference, the
+// default schema is not counted. A document rarely refers to more than a few
+// schemas, and the bound keeps the cache small on warehouses with thousands
+// of schemas. It is changed with SetMaxLoadedSchemas.
+const defaultMaxLoadedSchemas = 8
+
+// errConnectionChanged is returned by the work started for a connection which
+// has been replaced by another one.
+var errConnectionChanged = errors.New("database connection was changed")
+
+// cacheSnapshot is a cache published by the worker. Neither the snapshot nor
+// the cache is modified after publishing, a change publishes a copy with the
+// next generation.
+type cacheSnapshot struct {
+	cache      *DBCache
+	generation uint64
+}
+
+// workerSession is the connection the cache is generated for. The work
+// started for a session is canceled when another connection replaces it.
+type workerSession struct {
+	repo DBRepository
+	// cacheFile is the path the cache is saved to, empty when the cache is
+	// not persisted
+	cacheFile string
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
 type Worker struct {
-	dbRepo  DBRepository
-	dbCache *DBCache
+	snapshot atomic.Pointer[cacheSnapshot]
+
+	// lock guards the fields below and the publishing of snapshots
+	lock    sync.Mutex
+	session *workerSession
+	// loadedSchemas is the LRU list of the schemas loaded on first reference,
+	// the most recently used is the last
+	loadedSchemas    []string
+	maxLoadedSchemas int
 
-	done   chan struct{}
-	update chan struct{}
-	lock   sync.Mutex
+	done chan struct{}
+	save chan struct{}
 }
 
 func NewWorker() *Worker {
 	return &Worker{
-		done:   make(chan struct{}, 1),
-		update: make(chan struct{}, 1),
+		maxLoadedSchemas: defaultMaxLoadedSchemas,
+		done:             make(chan struct{}, 1),
+		save:             make(chan struct{}, 1),
 	}
 }
 
+// SetMaxLoadedSchemas sets how many schemas loaded on first reference are
+// kept, n of 0 or less is the default. A lower bound takes effect on the next
+// change of the cache.
+func (w *Worker) SetMaxLoadedSchemas(n int) {
+	if n <= 0 {
+		n = defaultMaxLoadedSchemas
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.maxLoadedSchemas = n
+}
+
+// Cache returns the current snapshot of the cache. It is safe to call
+// concurrently, the returned cache is never modified.
 func (w *Worker) Cache() *DBCache {
-	return w.dbCache
+	cache, _ := w.Snapshot()
+	return cache
 }
 
-func (w *Worker) setCache(c *DBCache) {
-	w.lock.Lock()
-	defer w.lock.Unlock()
-	w.dbCache = c
+// Snapshot returns the current cache with its generation, the generation is
+// increased whenever another cache is published.
+func (w *Worker) Snapshot() (*DBCache, uint64) {
+	snapshot := w.snapshot.Load()
+	if snapshot == nil {
+		return nil, 0
+	}
+	return snapshot.cache, snapshot.generation
 }
 
-func (w *Worker) setColumnCache(col map[string][]*ColumnDesc) {
-	w.lock.Lock()
-	defer w.lock.Unlock()
-	if w.dbCache != nil {
-		w.dbCache.ColumnsWithParent = col
+// publish replaces the snapshot, the lock must be held.
+func (w *Worker) publish(cache *DBCache) {
+	var generation uint64
+	if cur := w.snapshot.Load(); cur != nil {
+		generation = cur.generation
 	}
+	w.snapshot.Store(&cacheSnapshot{cache: cache, generation: generation + 1})
 }
 
 func (w *Worker) Start() {
@@ -48,43 +109,352 @@ func (w *Worker) Start() {
 			case <-w.done:
 				log.Println("db worker: done")
 				return
-			case <-w.update:
-				generator := NewDBCacheUpdater(w.dbRepo)
-				col, err := generator.GenerateDBCacheSecondary(context.Background())
-				if err != nil {
-					log.Println(err)
-				}
-				w.setColumnCache(col)
-				log.Println("db worker: Update db cache secondary complete")
+			case <-w.save:
+				w.saveCache()
 			}
 		}
 	}()
 }
 
 func (w *Worker) Stop() {
+	w.lock.Lock()
+	if w.session != nil {
+		w.session.cancel()
+	}
+	w.lock.Unlock()
 	close(w.done)
 }
 
+// startSession binds the worker to the connection, the work in flight for the
+// previous connection is canceled.
+func (w *Worker) startSession(repo DBRepository, cacheFile string) *workerSession {
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &workerSession{
+		repo:      repo,
+		cacheFile: cacheFile,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.session != nil {
+		w.session.cancel()
+	}
+	w.session = session
+	return session
+}
+
+func (w *Worker) currentSession() *workerSession {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.session
+}
+
+// sessionContext returns the context canceled with either ctx or the session.
+func sessionContext(ctx context.Context, session *workerSession) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(session.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// replace publishes the cache generated for the session as a whole, the
+// schemas loaded in the cache start the LRU list.
+func (w *Worker) replace(session *workerSession, cache *DBCache) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.session != session {
+		return errConnectionChanged
+	}
+	w.loadedSchemas = cache.loadedSchemas()
+	w.publish(w.evictSchemas(cache))
+	return nil
+}
+
+// update publishes the change of the current cache made for the session.
+func (w *Worker) update(session *workerSession, change func(cur *DBCache) *DBCache) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.session != session {
+		return errConnectionChanged
+	}
+	cur := w.Cache()
+	if cur == nil {
+		return nil
+	}
+	w.publish(w.evictSchemas(change(cur)))
+	return nil
+}
+
+// evictSchemas drops the least recently used schemas over the bound, the lock
+// must be held.
+func (w *Worker) evictSchemas(cache *DBCache) *DBCache {
+	if n := len(w.loadedSchemas) - w.maxLoadedSchemas; n > 0 {
+		cache = cache.withoutSchemas(w.loadedSchemas[:n]...)
+		w.loadedSchemas = append([]string{}, w.loadedSchemas[n:]...)
+	}
+	return cache
+}
+
 func (w *Worker) ReCache(ctx context.Context, repo DBRepository) error {
-	w.dbRepo = repo
-	if err := w.updateAllCache(ctx); err != nil {
+	return w.reCache(ctx, w.startSession(repo, ""))
+}
+
+func (w *Worker) reCache(ctx context.Context, session *workerSession) error {
+	ctx, cancel := sessionContext(ctx, session)
+	defer cancel()
+	cache, err := NewDBCacheUpdater(session.repo).GenerateDBCachePrimary(ctx)
+	if err != nil {
 		return err
 	}
-	w.updateAdditionalCache()
+	if err := w.replace(session, cache); err != nil {
+		return err
+	}
+	log.Println("db worker: Update db cache primary complete")
+	w.requestSave()
 	return nil
 }
 
-func (w *Worker) updateAllCache(ctx context.Context) error {
-	generator := NewDBCacheUpdater(w.dbRepo)
+// ReCacheWithFile is ReCache for the cache persisted in cacheFile. When the
+// file exists, the saved cache is used immediately and the new cache is
+// generated in the background, then swapped in and saved. Otherwise the cache
+// is generated as in ReCache and saved.
+func (w *Worker) ReCacheWithFile(ctx context.Context, repo DBRepository, cacheFile string) error {
+	session := w.startSession(repo, cacheFile)
+	cache, err := LoadDBCache(cacheFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Println("cannot load schema cache,", err)
+		}
+		return w.reCache(ctx, session)
+	}
+	if err := w.replace(session, cache); err != nil {
+		return err
+	}
+	log.Println("db worker: Load db cache from", cacheFile)
+	go func() {
+		if err := w.refresh(session.ctx, session); err != nil {
+			log.Println("db worker: cannot refresh db cache,", err)
+		}
+	}()
+	return nil
+}
+
+// Refresh regenerates the primary cache and the schemas loaded so far before
+// swapping, the current cache stays complete until then.
+func (w *Worker) Refresh(ctx context.Context) error {
+	session := w.currentSession()
+	if session == nil {
+		return errors.New("database connection is not open")
+	}
+	return w.refresh(ctx, session)
+}
+
+func (w *Worker) refresh(ctx context.Context, session *workerSession) error {
+	ctx, cancel := sessionContext(ctx, session)
+	defer cancel()
+
+	w.lock.Lock()
+	schemaNames := append([]string{}, w.loadedSchemas...)
+	w.lock.Unlock()
+
+	generator := NewDBCacheUpdater(session.repo)
 	cache, err := generator.GenerateDBCachePrimary(ctx)
 	if err != nil {
 		return err
 	}
-	w.setCache(cache)
-	log.Println("db worker: Update db cache primary complete")
+	for _, schemaName := range schemaNames {
+		dbName, ok := cache.Database(schemaName)
+		if !ok {
+			continue
+		}
+		sc, err := generator.GenerateSchemaCache(ctx, dbName)
+		if err != nil {
+			log.Println("db worker: cannot refresh schema,", err)
+			continue
+		}
+		cache = cache.withSchema(sc)
+	}
+	if err := w.replace(session, cache); err != nil {
+		return err
+	}
+	log.Println("db worker: Refresh db cache complete")
+	w.requestSave()
+	return nil
+}
+
+// RefreshTables re-describes the tables changed by DDL. The schema of each
+// table is described again, but only the entries of the tables are replaced.
+// A schema other than the default one which is not loaded yet is loaded as a
+// whole, and counted in the LRU list as loadSchema does.
+func (w *Worker) RefreshTables(ctx context.Context, targets []*DDLTarget) error {
+	session := w.currentSession()
+	cache := w.Cache()
+	if session == nil || cache == nil {
+		return nil
+	}
+	ctx, cancel := sessionContext(ctx, session)
+	defer cancel()
+
+	schemaTables := map[string][]string{}
+	schemaNames := []string{}
+	for _, target := range targets {
+		schemaName := target.Schema
+		if schemaName == "" {
+			schemaName = cache.defaultSchema
+		} else if dbName, ok := cache.Database(schemaName); ok {
+			schemaName = dbName
+		}
+		if _, ok := schemaTables[schemaName]; !ok {
+			schemaNames = append(schemaNames, schemaName)
+		}
+		schemaTables[schemaName] = append(schemaTables[schemaName], target.Name)
+	}
+
+	generator := NewDBCacheUpdater(session.repo)
+	schemaCaches := make([]*SchemaCache, len(schemaNames))
+	for i, schemaName := range schemaNames {
+		sc, err := generator.GenerateSchemaCache(ctx, schemaName)
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(schemaName, cache.defaultSchema) {
+			sc.ForeignKeys, err = generator.genForeignKeysCache(ctx, schemaName)
+			if err != nil {
+				log.Println("cannot load foreign keys,", err)
+			}
+		}
+		schemaCaches[i] = sc
+	}
+	// a view may be created or dropped
+	views, err := generator.genViewCache(ctx)
+	if err != nil {
+		log.Println("cannot load views,", err)
+	}
+
+	err = w.update(session, func(next *DBCache) *DBCache {
+		for i, schemaName := range schemaNames {
+			if !strings.EqualFold(schemaName, next.defaultSchema) && !w.touchLoadedSchema(schemaName) {
+				w.loadedSchemas = append(w.loadedSchemas, strings.ToUpper(schemaName))
+				next = next.withSchema(schemaCaches[i])
+			}
+			next = next.withTables(schemaName, schemaTables[schemaName], schemaCaches[i])
+		}
+		if views != nil {
+			withViews := *next
+			withViews.SchemaViews = views
+			withViews.SchemaTables = maps.Clone(next.SchemaTables)
+			withViews.excludeViewsFromTables()
+			next = &withViews
+		}
+		return next
+	})
+	if err != nil {
+		return err
+	}
+	log.Println("db worker: Refresh tables", schemaTables)
+	w.requestSave()
+	return nil
+}
+
+// LoadSchemas loads the metadata of the schemas on first reference. The
+// default schema is always loaded, the others are kept up to the LRU bound.
+// Unknown schema names are ignored.
+func (w *Worker) LoadSchemas(ctx context.Context, schemaNames ...string) error {
+	for _, schemaName := range schemaNames {
+		if err := w.loadSchema(ctx, schemaName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Worker) loadSchema(ctx context.Context, schemaName string) error {
+	session := w.currentSession()
+	cache := w.Cache()
+	if session == nil || cache == nil {
+		return nil
+	}
+	dbName, ok := cache.Database(schemaName)
+	if !ok || strings.EqualFold(dbName, cache.defaultSchema) {
+		return nil
+	}
+	if w.touchSchema(dbName) {
+		return nil
+	}
+
+	ctx, cancel := sessionContext(ctx, session)
+	defer cancel()
+	sc, err := NewDBCacheUpdater(session.repo).GenerateSchemaCache(ctx, dbName)
+	if err != nil {
+		if session.ctx.Err() != nil {
+			// the connection was switched while loading
+			return nil
+		}
+		return err
+	}
+
+	key := strings.ToUpper(dbName)
+	err = w.update(session, func(cur *DBCache) *DBCache {
+		for _, loaded := range w.loadedSchemas {
+			if loaded == key {
+				// loaded concurrently
+				return cur
+			}
+		}
+		w.loadedSchemas = append(w.loadedSchemas, key)
+		return cur.withSchema(sc)
+	})
+	if errors.Is(err, errConnectionChanged) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	log.Println("db worker: Load schema", dbName)
+	w.requestSave()
 	return nil
 }
 
-func (w *Worker) updateAdditionalCache() {
-	w.update <- struct{}{}
+// touchSchema marks the schema as most recently used, it reports whether the
+// schema is loaded.
+func (w *Worker) touchSchema(schemaName string) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.touchLoadedSchema(schemaName)
+}
+
+// touchLoadedSchema is touchSchema with the lock held.
+func (w *Worker) touchLoadedSchema(schemaName string) bool {
+	key := strings.ToUpper(schemaName)
+	for i, loaded := range w.loadedSchemas {
+		if loaded == key {
+			w.loadedSchemas = append(append(w.loadedSchemas[:i:i], w.loadedSchemas[i+1:]...), key)
+			return true
+		}
+	}
+	return false
+}
+
+// requestSave saves the cache in the worker goroutine, a pending request
+// covers the later ones.
+func (w *Worker) requestSave() {
+	select {
+	case w.save <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Worker) saveCache() {
+	w.lock.Lock()
+	session, cache := w.session, w.Cache()
+	w.lock.Unlock()
+	if session == nil || session.cacheFile == "" || cache == nil {
+		return
+	}
+	if err := SaveDBCache(session.cacheFile, cache); err != nil {
+		log.Println("cannot save schema cache,", err)
+	}
 }
@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWorkerLoadSchemas(t *testing.T) {
+	loads := []string{}
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	repo.MockDescribeDatabaseTableBySchema = func(ctx context.Context, schemaName string) ([]*ColumnDesc, error) {
+		loads = append(loads, schemaName)
+		return []*ColumnDesc{
+			{ColumnBase: ColumnBase{Schema: schemaName, Table: "t", Name: "id"}, Type: "int"},
+		}, nil
+	}
+
+	worker := NewWorker()
+	worker.maxLoadedSchemas = 2
+	if err := worker.ReCache(context.Background(), repo); err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]string{"world"}, loads); d != "" {
+		t.Errorf("only the default schema must be loaded eagerly (- want, + got):\n%s", d)
+	}
+
+	ctx := context.Background()
+	// the default schema and unknown schemas are not loaded
+	if err := worker.LoadSchemas(ctx, "WORLD", "unknown", "mysql", "sys"); err != nil {
+		t.Fatal(err)
+	}
+	// mysql is used again, sys is the least recently used
+	if err := worker.LoadSchemas(ctx, "mysql", "information_schema"); err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]string{"world", "mysql", "sys", "information_schema"}, loads); d != "" {
+		t.Errorf("unmatched loads (- want, + got):\n%s", d)
+	}
+	if d := cmp.Diff([]string{"INFORMATION_SCHEMA", "MYSQL"}, worker.Cache().loadedSchemas()); d != "" {
+		t.Errorf("unmatched loaded schemas (- want, + got):\n%s", d)
+	}
+	if _, ok := worker.Cache().ColumnDatabase("sys", "t"); ok {
+		t.Error("evicted schema must be removed")
+	}
+	if _, ok := worker.Cache().ColumnDatabase("world", "t"); !ok {
+		t.Error("default schema must be kept")
+	}
+
+	// reloaded after the eviction
+	if err := worker.LoadSchemas(ctx, "sys"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := worker.Cache().ColumnDatabase("sys", "t"); !ok {
+		t.Error("schema must be loaded again")
+	}
+}
+
+func TestWorkerRefreshTables(t *testing.T) {
+	tables := map[string][]string{"world": {"city", "country"}}
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	repo.MockDatabaseTables = func(ctx context.Context) (map[string][]string, error) {
+		return tables, nil
+	}
+	repo.MockDescribeDatabaseTableBySchema = func(ctx context.Context, schemaName string) ([]*ColumnDesc, error) {
+		cols := []*ColumnDesc{}
+		for _, table := range tables[schemaName] {
+			cols = append(cols, &ColumnDesc{ColumnBase: ColumnBase{Schema: schemaName, Table: table, Name: "id"}})
+		}
+		return cols, nil
+	}
+	worker := NewWorker()
+	if err := worker.ReCache(context.Background(), repo); err != nil {
+		t.Fatal(err)
+	}
+	before := worker.Cache()
+
+	tables = map[string][]string{"world": {"country", "town"}}
+	targets, _ := DDLTargets("ALTER TABLE city RENAME TO town")
+	if err := worker.RefreshTables(context.Background(), targets); err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]string{"country", "town"}, worker.Cache().SortedTables()); d != "" {
+		t.Errorf("unmatched tables (- want, + got):\n%s", d)
+	}
+	if _, ok := worker.Cache().ColumnDescs("city"); ok {
+		t.Error("renamed table must be removed")
+	}
+	if _, ok := worker.Cache().ColumnDescs("town"); !ok {
+		t.Error("new table must be described")
+	}
+	// the previous cache is not modified
+	if d := cmp.Diff([]string{"city", "country"}, before.SortedTables()); d != "" {
+		t.Errorf("previous cache is modified (- want, + got):\n%s", d)
+	}
+}
+
+func TestWorkerRefreshTablesLoadsSchema(t *testing.T) {
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	repo.MockDescribeDatabaseTableBySchema = func(ctx context.Context, schemaName string) ([]*ColumnDesc, error) {
+		return []*ColumnDesc{
+			{ColumnBase: ColumnBase{Schema: schemaName, Table: "t", Name: "id"}, Type: "int"},
+		}, nil
+	}
+	worker := NewWorker()
+	worker.SetMaxLoadedSchemas(1)
+	ctx := context.Background()
+	if err := worker.ReCache(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+	if err := worker.LoadSchemas(ctx, "mysql"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the schema changed by DDL is loaded through the LRU list
+	targets, _ := DDLTargets("ALTER TABLE sys.t ADD COLUMN name text")
+	if err := worker.RefreshTables(ctx, targets); err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]string{"SYS"}, worker.Cache().loadedSchemas()); d != "" {
+		t.Errorf("unmatched loaded schemas (- want, + got):\n%s", d)
+	}
+	if _, ok := worker.Cache().ColumnDatabase("mysql", "t"); ok {
+		t.Error("evicted schema must be removed")
+	}
+	if _, ok := worker.Cache().ColumnDatabase("sys", "t"); !ok {
+		t.Error("refreshed schema must be loaded")
+	}
+}
+
+func TestWorkerConcurrentReadAndReCache(t *testing.T) {
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	describe := repo.MockDescribeDatabaseTableBySchema
+	repo.MockDescribeDatabaseTableBySchema = func(ctx context.Context, schemaName string) ([]*ColumnDesc, error) {
+		if schemaName != "world" {
+			return []*ColumnDesc{{ColumnBase: ColumnBase{Schema: schemaName, Table: "t", Name: "id"}}}, nil
+		}
+		return describe(ctx, schemaName)
+	}
+	worker := NewWorker()
+	worker.maxLoadedSchemas = 1
+	worker.Start()
+	defer worker.Stop()
+	ctx := context.Background()
+	if err := worker.ReCache(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last uint64
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				cache, generation := worker.Snapshot()
+				if generation < last {
+					t.Errorf("generation went back from %d to %d", last, generation)
+					return
+				}
+				last = generation
+				for _, table := range cache.SortedTables() {
+					cols, _ := cache.ColumnDescs(table)
+					_ = TableDoc(table, cache.TableComment("world", table), cols, cache.ColumnIndexes(&ColumnDesc{}))
+				}
+				cache.SortedViews()
+				cache.SortedSequences()
+				cache.ColumnDatabase("sys", "t")
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		if err := worker.ReCache(ctx, repo); err != nil {
+			t.Fatal(err)
+		}
+		if err := worker.LoadSchemas(ctx, "sys", "mysql"); err != nil {
+			t.Fatal(err)
+		}
+		if err := worker.RefreshTables(ctx, []*DDLTarget{{Name: "city"}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := worker.Refresh(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestWorkerCancelReCacheOnConnectionChange(t *testing.T) {
+	started := make(chan struct{})
+	slow := NewMockDBRepository(nil).(*MockDBRepository)
+	slow.MockDescribeDatabaseTableBySchema = func(ctx context.Context, schemaName string) ([]*ColumnDesc, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	fast := NewMockDBRepository(nil).(*MockDBRepository)
+	fast.MockDatabaseTables = func(ctx context.Context) (map[string][]string, error) {
+		return map[string][]string{"world": {"town"}}, nil
+	}
+
+	worker := NewWorker()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- worker.ReCache(context.Background(), slow)
+	}()
+	<-started
+	if err := worker.ReCache(context.Background(), fast); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("the previous recache must be canceled, got %v", err)
+	}
+	if d := cmp.Diff([]string{"town"}, worker.Cache().SortedTables()); d != "" {
+		t.Errorf("unmatched tables (- want, + got):\n%s", d)
+	}
+}
@@ -20,17 +20,19 @@ func (s *Server) handleTextDocumentCompletion(ctx context.Context, conn *jsonrpc
 		return nil, err
 	}
 
-	f, ok := s.files[params.TextDocument.URI]
+	f, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	c := completer.NewCompleter(s.worker.Cache())
-	if s.dbConn != nil {
-		c.Driver = s.dbConn.Driver
-	} else {
-		c.Driver = ""
+	ss, err := s.documentSession(ctx, params.TextDocument.URI)
+	if err != nil {
+		return nil, err
 	}
+	defer s.releaseSession(ss)
+	loadReferencedSchemas(ctx, ss.worker, f.Text)
+	c := completer.NewCompleter(ss.worker.Cache())
+	c.Driver = ss.driver
 	completionItems, err := c.Complete(f.Text, params, s.getConfig().LowercaseKeywords)
 	if err != nil {
 		return nil, err
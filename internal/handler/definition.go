@@ -25,12 +25,17 @@ func (s *Server) handleDefinition(ctx context.Context, conn *jsonrpc2.Conn, req
 		return nil, err
 	}
 
-	f, ok := s.files[params.TextDocument.URI]
+	f, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	return definition(params.TextDocument.URI, f.Text, params, s.worker.Cache())
+	ss, err := s.documentSession(ctx, params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseSession(ss)
+	return definition(params.TextDocument.URI, f.Text, params, ss.worker.Cache())
 }
 
 func definition(url, text string, params lsp.DefinitionParams, dbCache *database.DBCache) (lsp.Definition, error) {
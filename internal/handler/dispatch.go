@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// concurrentMethods are the read-only requests, they are served concurrently
+// so that completion does not wait for a long-running command such as
+// executeQuery.
+var concurrentMethods = map[string]bool{
+	"textDocument/completion":     true,
+	"textDocument/hover":          true,
+	"textDocument/definition":     true,
+	"textDocument/typeDefinition": true,
+	"textDocument/signatureHelp":  true,
+}
+
+// documentMethods update the document store. They are served as soon as they
+// are received so that the read-only requests see the latest text even while
+// a command is running. The work changing the connection is scheduled on
+// the requests served in order with schedule.
+var documentMethods = map[string]bool{
+	"textDocument/didOpen":   true,
+	"textDocument/didChange": true,
+	"textDocument/didSave":   true,
+	"textDocument/didClose":  true,
+}
+
+// Handler returns the handler serving the connection to the client. The
+// document notifications and the read-only requests are served concurrently
+// with the other requests, which are served one by one in the order received.
+func (s *Server) Handler() jsonrpc2.Handler {
+	return newDispatcher(jsonrpc2.HandlerWithError(s.Handle))
+}
+
+type dispatcher struct {
+	handler jsonrpc2.Handler
+
+	mu     sync.Mutex
+	queues map[*jsonrpc2.Conn]chan func()
+}
+
+func newDispatcher(handler jsonrpc2.Handler) *dispatcher {
+	return &dispatcher{
+		handler: handler,
+		queues:  make(map[*jsonrpc2.Conn]chan func()),
+	}
+}
+
+func (d *dispatcher) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	switch {
+	case documentMethods[req.Method]:
+		ctx = context.WithValue(ctx, scheduleKey{}, func(fn func()) {
+			d.queue(ctx, conn) <- fn
+		})
+		d.handler.Handle(ctx, conn, req)
+	case concurrentMethods[req.Method]:
+		go d.handler.Handle(ctx, conn, req)
+	default:
+		// blocks reading the next message while the queue is full
+		d.queue(ctx, conn) <- func() {
+			d.handler.Handle(ctx, conn, req)
+		}
+	}
+}
+
+type scheduleKey struct{}
+
+// schedule runs fn after the requests served in order which are received
+// before, so that the document notifications change the connection in
+// order with them. fn runs at once when the request is not dispatched, such
+// as when Handle is called directly.
+func schedule(ctx context.Context, fn func()) {
+	if enqueue, ok := ctx.Value(scheduleKey{}).(func(func())); ok {
+		enqueue(fn)
+		return
+	}
+	fn()
+}
+
+// queue returns the queue of the requests served in order for the
+// connection, the queue is served until the connection is closed.
+func (d *dispatcher) queue(ctx context.Context, conn *jsonrpc2.Conn) chan<- func() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if q, ok := d.queues[conn]; ok {
+		return q
+	}
+	q := make(chan func(), 64)
+	d.queues[conn] = q
+	go func() {
+		for {
+			select {
+			case fn := <-q:
+				fn()
+			case <-conn.DisconnectNotify():
+				d.mu.Lock()
+				delete(d.queues, conn)
+				d.mu.Unlock()
+				return
+			}
+		}
+	}()
+	return q
+}
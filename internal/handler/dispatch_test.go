@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func newDispatcherTestConn(t *testing.T, handleFunc func(context.Context, *jsonrpc2.Conn, *jsonrpc2.Request) (interface{}, error)) *jsonrpc2.Conn {
+	t.Helper()
+	ctx := context.Background()
+	client, serverPipe := net.Pipe()
+	connServer := jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(serverPipe, jsonrpc2.VSCodeObjectCodec{}), newDispatcher(jsonrpc2.HandlerWithError(handleFunc)))
+	conn := jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(client, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(func(context.Context, *jsonrpc2.Conn, *jsonrpc2.Request) (interface{}, error) {
+		return nil, nil
+	}))
+	t.Cleanup(func() {
+		conn.Close()
+		connServer.Close()
+	})
+	return conn
+}
+
+func TestDispatcherServeReadOnlyDuringCommand(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	conn := newDispatcherTestConn(t, func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		if req.Method == "workspace/executeCommand" {
+			<-release
+			return "done", nil
+		}
+		return req.Method, nil
+	})
+
+	commandDone := make(chan error, 1)
+	go func() {
+		var got string
+		commandDone <- conn.Call(ctx, "workspace/executeCommand", nil, &got)
+	}()
+
+	for _, method := range []string{"textDocument/didChange", "textDocument/completion", "textDocument/hover", "textDocument/definition"} {
+		callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		var got string
+		if err := conn.Call(callCtx, method, nil, &got); err != nil {
+			cancel()
+			t.Fatalf("%s is blocked by the command, %+v", method, err)
+		}
+		cancel()
+		if got != method {
+			t.Errorf("unexpected result %q, expected %q", got, method)
+		}
+	}
+
+	select {
+	case <-commandDone:
+		t.Fatal("the command finished before released")
+	default:
+	}
+	close(release)
+	if err := <-commandDone; err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+}
+
+func TestDispatcherServeCommandsInOrder(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu     sync.Mutex
+		served []int
+	)
+	conn := newDispatcherTestConn(t, func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		served = append(served, len(served))
+		return nil, nil
+	})
+
+	const n = 20
+	for i := 0; i < n; i++ {
+		if err := conn.Notify(ctx, "workspace/executeCommand", nil); err != nil {
+			t.Fatal("conn.Notify workspace/executeCommand:", err)
+		}
+	}
+	// the request is queued after the notifications
+	if err := conn.Call(ctx, "workspace/executeCommand", nil, nil); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(served) != n+1 {
+		t.Errorf("unexpected served requests %d, expected %d", len(served), n+1)
+	}
+}
+
+func TestDocumentStoreVersion(t *testing.T) {
+	store := newDocumentStore()
+	uri := "file:///test.sql"
+	store.open(uri, "sql", 1, "SELECT 1")
+
+	opened, _ := store.get(uri)
+	if err := store.update(uri, 3, "SELECT 3"); err != nil {
+		t.Fatal(err)
+	}
+	// a stale change is ignored
+	if err := store.update(uri, 2, "SELECT 2"); err != nil {
+		t.Fatal(err)
+	}
+	f, ok := store.get(uri)
+	if !ok {
+		t.Fatalf("not found opened file. URI:%s", uri)
+	}
+	if f.Text != "SELECT 3" || f.Version != 3 {
+		t.Errorf("unexpected file %+v", f)
+	}
+	// a reader keeps the version it got
+	if opened.Text != "SELECT 1" || opened.Version != 1 {
+		t.Errorf("the opened version is modified, %+v", opened)
+	}
+
+	// didSave without a version keeps the version
+	if err := store.update(uri, 0, "SELECT 4"); err != nil {
+		t.Fatal(err)
+	}
+	if f, _ := store.get(uri); f.Text != "SELECT 4" || f.Version != 3 {
+		t.Errorf("unexpected file %+v", f)
+	}
+
+	store.close(uri)
+	if _, ok := store.get(uri); ok {
+		t.Errorf("found closed file. URI:%s", uri)
+	}
+	if err := store.update(uri, 5, "SELECT 5"); err == nil {
+		t.Error("expected an error for a closed file")
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+)
+
+type File struct {
+	LanguageID string
+	Text       string
+	// Version is the version sent by the client, it increases after each
+	// change of the document.
+	Version int
+}
+
+// documentStore holds the open documents. The requests served concurrently
+// read the documents while the notifications update them, so a File is never
+// modified once stored, it is replaced by the next version instead.
+type documentStore struct {
+	mu    sync.RWMutex
+	files map[string]*File
+}
+
+func newDocumentStore() *documentStore {
+	return &documentStore{
+		files: make(map[string]*File),
+	}
+}
+
+func (d *documentStore) open(uri, languageID string, version int, text string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.files[uri] = &File{
+		LanguageID: languageID,
+		Text:       text,
+		Version:    version,
+	}
+}
+
+// update replaces the text of the document. A change older than the stored
+// version is ignored, a zero version keeps the stored version.
+func (d *documentStore) update(uri string, version int, text string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f, ok := d.files[uri]
+	if !ok {
+		return fmt.Errorf("document not found: %v", uri)
+	}
+	if version == 0 {
+		version = f.Version
+	}
+	if version < f.Version {
+		return nil
+	}
+	d.files[uri] = &File{
+		LanguageID: f.LanguageID,
+		Text:       text,
+		Version:    version,
+	}
+	return nil
+}
+
+func (d *documentStore) close(uri string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.files, uri)
+}
+
+func (d *documentStore) get(uri string) (*File, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	f, ok := d.files[uri]
+	return f, ok
+}
+
+// all returns the open documents keyed by the URI.
+func (d *documentStore) all() map[string]*File {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return maps.Clone(d.files)
+}
@@ -8,25 +8,40 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/sourcegraph/jsonrpc2"
 	"github.com/yaamai/sqls/ast"
+	"github.com/yaamai/sqls/ast/astutil"
 	"github.com/yaamai/sqls/internal/database"
 	"github.com/yaamai/sqls/internal/lsp"
 	"github.com/yaamai/sqls/parser"
+	"github.com/yaamai/sqls/token"
 )
 
 const (
 	CommandExecuteQuery     = "executeQuery"
+	CommandExplainQuery     = "explainQuery"
+	CommandExportQuery      = "exportQuery"
+	CommandImportCSV        = "importCSV"
+	CommandCopyTable        = "copyTable"
+	CommandDiffSchema       = "diffSchema"
+	CommandGenerateERD      = "generateERDiagram"
+	CommandShowCreateTable  = "showCreateTable"
+	CommandRefreshSchema    = "refreshSchemaCache"
 	CommandShowDatabases    = "showDatabases"
 	CommandShowSchemas      = "showSchemas"
 	CommandShowConnections  = "showConnections"
 	CommandSwitchDatabase   = "switchDatabase"
 	CommandSwitchConnection = "switchConnections"
 	CommandShowTables       = "showTables"
+	CommandBindConnection   = "bindConnection"
 )
 
 func (s *Server) handleTextDocumentCodeAction(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
@@ -45,6 +60,11 @@ func (s *Server) handleTextDocumentCodeAction(ctx context.Context, conn *jsonrpc
 			Command:   CommandExecuteQuery,
 			Arguments: []interface{}{params.TextDocument.URI},
 		},
+		{
+			Title:     "Explain Query",
+			Command:   CommandExplainQuery,
+			Arguments: []interface{}{params.TextDocument.URI},
+		},
 		{
 			Title:     "Show Databases",
 			Command:   CommandShowDatabases,
@@ -75,6 +95,21 @@ func (s *Server) handleTextDocumentCodeAction(ctx context.Context, conn *jsonrpc
 			Command:   CommandShowTables,
 			Arguments: []interface{}{},
 		},
+		{
+			Title:     "Bind Connection",
+			Command:   CommandBindConnection,
+			Arguments: []interface{}{params.TextDocument.URI},
+		},
+		{
+			Title:     "Refresh Schema Cache",
+			Command:   CommandRefreshSchema,
+			Arguments: []interface{}{uriFlag + params.TextDocument.URI},
+		},
+		{
+			Title:     "Generate ER Diagram",
+			Command:   CommandGenerateERD,
+			Arguments: []interface{}{uriFlag + params.TextDocument.URI},
+		},
 	}
 	return commands, nil
 }
@@ -91,7 +126,23 @@ func (s *Server) handleWorkspaceExecuteCommand(ctx context.Context, conn *jsonrp
 
 	switch params.Command {
 	case CommandExecuteQuery:
-		return s.executeQuery(ctx, params)
+		return s.executeQuery(ctx, conn, params)
+	case CommandExplainQuery:
+		return s.explainQuery(ctx, params)
+	case CommandExportQuery:
+		return s.exportQuery(ctx, params)
+	case CommandImportCSV:
+		return s.importCSV(ctx, conn, params)
+	case CommandCopyTable:
+		return s.copyTable(ctx, conn, params)
+	case CommandDiffSchema:
+		return s.diffSchema(ctx, params)
+	case CommandGenerateERD:
+		return s.generateERDiagram(ctx, params)
+	case CommandShowCreateTable:
+		return s.showCreateTable(ctx, params)
+	case CommandRefreshSchema:
+		return s.refreshSchemaCache(ctx, params)
 	case CommandShowDatabases:
 		return s.showDatabases(ctx, params)
 	case CommandShowSchemas:
@@ -104,15 +155,14 @@ func (s *Server) handleWorkspaceExecuteCommand(ctx context.Context, conn *jsonrp
 		return s.switchConnections(ctx, params)
 	case CommandShowTables:
 		return s.showTables(ctx, params)
+	case CommandBindConnection:
+		return s.bindConnection(ctx, params)
 	}
 	return nil, fmt.Errorf("unsupported command: %v", params.Command)
 }
 
-func (s *Server) executeQuery(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+func (s *Server) executeQuery(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
 	// parse execute command arguments
-	if s.dbConn == nil {
-		return nil, errors.New("database connection is not open")
-	}
 	if len(params.Arguments) == 0 {
 		return nil, fmt.Errorf("required arguments were not provided: <File URI>")
 	}
@@ -120,10 +170,19 @@ func (s *Server) executeQuery(ctx context.Context, params lsp.ExecuteCommandPara
 	if !ok {
 		return nil, fmt.Errorf("specify the file uri as a string")
 	}
-	f, ok := s.files[uri]
+	f, ok := s.files.get(uri)
 	if !ok {
 		return nil, fmt.Errorf("document not found, %q", uri)
 	}
+	ss, err := s.documentSession(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseSession(ss)
+	repo, err := ss.repository()
+	if err != nil {
+		return nil, err
+	}
 
 	showVertical := false
 	if len(params.Arguments) > 1 {
@@ -150,6 +209,10 @@ func (s *Server) executeQuery(ctx context.Context, params lsp.ExecuteCommandPara
 	if err != nil {
 		return nil, err
 	}
+	formatter, err := database.NewValueFormatter(s.getConfig().ResultFormat)
+	if err != nil {
+		return nil, err
+	}
 
 	// execute statements
 	buf := new(bytes.Buffer)
@@ -159,23 +222,602 @@ func (s *Server) executeQuery(ctx context.Context, params lsp.ExecuteCommandPara
 			continue
 		}
 
+		var (
+			res       string
+			resParams *lsp.QueryResultParams
+		)
 		if _, isQuery := database.QueryExecType(query, ""); isQuery {
-			res, err := s.query(ctx, query, showVertical)
-			if err != nil {
-				return nil, err
-			}
-			fmt.Fprintln(buf, res)
+			res, resParams, err = s.query(ctx, repo, query, showVertical, formatter)
 		} else {
-			res, err := s.exec(ctx, query, showVertical)
-			if err != nil {
-				return nil, err
+			res, resParams, err = s.exec(ctx, repo, query, showVertical)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(buf, res)
+		if database.IsDDL(query) {
+			refreshSchemaAfterDDL(ctx, ss.worker, query)
+		}
+
+		resParams.URI = uri
+		if err := lsp.NotifyQueryResult(ctx, conn, resParams); err != nil {
+			log.Println("send query result", err.Error())
+		}
+	}
+	return buf.String(), nil
+}
+
+// refreshSchemaAfterDDL re-describes the tables changed by the DDL so that new
+// columns are completed immediately. The whole cache is refreshed in the
+// background for DDL on other objects.
+func refreshSchemaAfterDDL(ctx context.Context, worker *database.Worker, query string) {
+	targets, ok := database.DDLTargets(query)
+	if !ok {
+		go func() {
+			if err := worker.Refresh(context.Background()); err != nil {
+				log.Println("cannot refresh schema cache,", err)
 			}
-			fmt.Fprintln(buf, res)
+		}()
+		return
+	}
+	if err := worker.RefreshTables(ctx, targets); err != nil {
+		log.Println("cannot refresh schema cache,", err)
+	}
+}
+
+func (s *Server) refreshSchemaCache(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	ss, _, err := s.commandSession(ctx, params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseSession(ss)
+	if ss.conn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	if err := ss.worker.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *Server) explainQuery(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) == 0 {
+		return nil, fmt.Errorf("required arguments were not provided: <File URI>")
+	}
+	uri, ok := params.Arguments[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the file uri as a string")
+	}
+	f, ok := s.files.get(uri)
+	if !ok {
+		return nil, fmt.Errorf("document not found, %q", uri)
+	}
+
+	query, err := targetQuery(f.Text, params.Range)
+	if err != nil {
+		return nil, err
+	}
+
+	ss, err := s.documentSession(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseSession(ss)
+	repo, err := ss.repository()
+	if err != nil {
+		return nil, err
+	}
+	plan, err := repo.Explain(ctx, query)
+	if err != nil {
+		if errors.Is(err, database.ErrNotImplementation) {
+			return nil, fmt.Errorf("explain is not supported by %s", repo.Driver())
 		}
+		return nil, err
+	}
+	return database.PlanDoc(plan), nil
+}
+
+func (s *Server) exportQuery(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) < 2 {
+		return nil, fmt.Errorf("required arguments were not provided: <File URI> <Output Path>")
+	}
+	uri, ok := params.Arguments[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the file uri as a string")
+	}
+	outPath, ok := params.Arguments[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the output path as a string")
+	}
+	f, ok := s.files.get(uri)
+	if !ok {
+		return nil, fmt.Errorf("document not found, %q", uri)
+	}
+
+	opt := &database.ExportOption{
+		ResultFormat: s.getConfig().ResultFormat,
 	}
+	for _, arg := range params.Arguments[2:] {
+		flag, ok := arg.(string)
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(flag, "-format="):
+			opt.Format = database.ExportFormat(strings.TrimPrefix(flag, "-format="))
+		case strings.HasPrefix(flag, "-table="):
+			opt.Table = strings.TrimPrefix(flag, "-table=")
+		}
+	}
+	if opt.Format == "" {
+		if opt.Format, err = database.ExportFormatFromPath(outPath); err != nil {
+			return nil, err
+		}
+	}
+
+	query, err := targetQuery(f.Text, params.Range)
+	if err != nil {
+		return nil, err
+	}
+	ss, err := s.documentSession(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseSession(ss)
+	repo, err := ss.repository()
+	if err != nil {
+		return nil, err
+	}
+	opt.Driver = ss.cfg.Driver
+
+	start := time.Now()
+	rows, err := repo.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// written to a temporary file renamed on success, so that a failed
+	// export leaves no partial file at the output path. The temporary file
+	// is only readable by the user, as the exported rows may be sensitive.
+	out, err := os.CreateTemp(filepath.Dir(outPath), filepath.Base(outPath)+".*")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create export file, %w", err)
+	}
+	defer os.Remove(out.Name())
+	count, err := database.ExportRows(rows, out, opt)
+	if err != nil {
+		out.Close()
+		return nil, fmt.Errorf("failed export, %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("cannot write export file, %w", err)
+	}
+	if err := os.Rename(out.Name(), outPath); err != nil {
+		return nil, fmt.Errorf("cannot write export file, %w", err)
+	}
+	return fmt.Sprintf("%d rows exported to %s (%s)", count, outPath, time.Since(start).Round(time.Millisecond)), nil
+}
+
+func (s *Server) importCSV(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	ss, args, err := s.commandSession(ctx, params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseSession(ss)
+	if ss.conn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	if len(args) < 2 {
+		return nil, fmt.Errorf("required arguments were not provided: <CSV Path> <Table Name>")
+	}
+	csvPath, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the csv path as a string")
+	}
+	table, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the table name as a string")
+	}
+	batchSize, err := batchSizeFlag(args[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	dbCache := ss.worker.Cache()
+	if dbCache == nil {
+		return nil, errors.New("database cache is not ready")
+	}
+	var columns []*database.ColumnDesc
+	if schemaName, tableName, found := strings.Cut(table, "."); found {
+		columns, ok = dbCache.ColumnDatabase(schemaName, tableName)
+	} else {
+		columns, ok = dbCache.ColumnDescs(table)
+	}
+	if !ok {
+		return nil, fmt.Errorf("table not found, %q", table)
+	}
+
+	file, err := os.Open(csvPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open csv, %w", err)
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("cannot open csv, %w", err)
+	}
+	reader := &countingReader{r: file}
+
+	progress := beginWorkDoneProgress(ctx, conn, params.WorkDoneToken, "Import "+filepath.Base(csvPath))
+	defer progress.end("")
+
+	res, err := database.ImportCSV(ctx, ss.conn.Conn, reader, &database.ImportOption{
+		Driver:    ss.cfg.Driver,
+		Table:     table,
+		Columns:   columns,
+		BatchSize: batchSize,
+		Progress: func(processed int64) {
+			var percentage *int
+			if stat.Size() > 0 {
+				p := int(reader.n * 100 / stat.Size())
+				percentage = &p
+			}
+			progress.report(fmt.Sprintf("%d rows processed", processed), percentage)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed import, %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "%d rows imported into %s, %d rows failed", res.Inserted, table, len(res.Errors))
+	fmt.Fprintln(buf, "")
+	writeImportErrors(buf, res.Errors, "line")
 	return buf.String(), nil
 }
 
+func (s *Server) copyTable(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) < 4 {
+		return nil, fmt.Errorf("required arguments were not provided: <Source Connection> <Source Table or Query> <Destination Connection> <Destination Table>")
+	}
+	args := make([]string, 4)
+	for i := range args {
+		arg, ok := params.Arguments[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("specify the arguments as strings")
+		}
+		args[i] = arg
+	}
+	srcCfg, err := s.findConnection(args[0])
+	if err != nil {
+		return nil, err
+	}
+	dstCfg, err := s.findConnection(args[2])
+	if err != nil {
+		return nil, err
+	}
+	batchSize, err := batchSizeFlag(params.Arguments[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	// a single word is a table name, anything else is a query
+	query := strings.TrimSuffix(strings.TrimSpace(args[1]), ";")
+	if !strings.ContainsAny(query, " \t\r\n") {
+		query = "SELECT * FROM " + query
+	}
+
+	srcConn, srcRepo, err := openRepository(srcCfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect source, %w", err)
+	}
+	defer srcConn.Close()
+	dstConn, dstRepo, err := openRepository(dstCfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect destination, %w", err)
+	}
+	defer dstConn.Close()
+	table := args[3]
+	columns, err := describeTable(ctx, dstRepo, table)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := srcRepo.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	progress := beginWorkDoneProgress(ctx, conn, params.WorkDoneToken, "Copy to "+table)
+	defer progress.end("")
+	res, err := database.CopyRows(ctx, rows, dstConn.Conn, &database.ImportOption{
+		Driver:    dstCfg.Driver,
+		Table:     table,
+		Columns:   columns,
+		BatchSize: batchSize,
+		Progress: func(processed int64) {
+			progress.report(fmt.Sprintf("%d rows processed", processed), nil)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed copy, %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "%d rows copied into %s, %d rows failed", res.Inserted, table, len(res.Errors))
+	fmt.Fprintln(buf, "")
+	writeImportErrors(buf, res.Errors, "row")
+	return buf.String(), nil
+}
+
+func (s *Server) diffSchema(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) < 2 {
+		return nil, fmt.Errorf("required arguments were not provided: <Source Connection> <Target Connection>")
+	}
+	var cfgs [2]*database.DBConfig
+	for i := range cfgs {
+		arg, ok := params.Arguments[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("specify the connections as strings")
+		}
+		if cfgs[i], err = s.findConnection(arg); err != nil {
+			return nil, err
+		}
+	}
+	var schemas [2]string
+	for _, arg := range params.Arguments[2:] {
+		flag, ok := arg.(string)
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(flag, "-source-schema="):
+			schemas[0] = strings.TrimPrefix(flag, "-source-schema=")
+		case strings.HasPrefix(flag, "-target-schema="):
+			schemas[1] = strings.TrimPrefix(flag, "-target-schema=")
+		}
+	}
+
+	var snapshots [2]*database.SchemaSnapshot
+	for i, cfg := range cfgs {
+		conn, repo, err := openRepository(cfg)
+		if err != nil {
+			return nil, err
+		}
+		snapshots[i], err = database.NewSchemaSnapshot(ctx, repo, schemas[i])
+		conn.Close()
+		if err != nil {
+			return nil, err
+		}
+		// the driver of the config also tells the MySQL variant
+		snapshots[i].Driver = cfg.Driver
+	}
+
+	diff := database.DiffSchema(snapshots[0], snapshots[1])
+	buf := new(bytes.Buffer)
+	fmt.Fprint(buf, diff.Report())
+	if !diff.Empty() {
+		fmt.Fprintln(buf, "")
+		for _, stmt := range diff.DDL() {
+			fmt.Fprintln(buf, stmt)
+		}
+	}
+	return buf.String(), nil
+}
+
+func (s *Server) generateERDiagram(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	ss, args, err := s.commandSession(ctx, params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseSession(ss)
+	format := database.ERDiagramFormatMermaid
+	var schemaName string
+	tableNames := []string{}
+	for _, arg := range args {
+		str, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("specify the table names as strings")
+		}
+		switch {
+		case strings.HasPrefix(str, "-format="):
+			format = database.ERDiagramFormat(strings.TrimPrefix(str, "-format="))
+		case strings.HasPrefix(str, "-schema="):
+			schemaName = strings.TrimPrefix(str, "-schema=")
+		default:
+			tableNames = append(tableNames, str)
+		}
+	}
+
+	dbCache := ss.worker.Cache()
+	if dbCache == nil {
+		return nil, errors.New("database cache is not ready")
+	}
+	// the driver of the config also tells the MySQL variant
+	driver := ss.driver
+	if ss.conn != nil {
+		driver = ss.cfg.Driver
+	}
+
+	// the cache has the foreign keys of the default schema only
+	var fks []*database.ForeignKey
+	if schemaName != "" {
+		if _, ok := dbCache.Database(schemaName); !ok {
+			return nil, fmt.Errorf("schema not found, %q", schemaName)
+		}
+		if err := ss.worker.LoadSchemas(ctx, schemaName); err != nil {
+			return nil, err
+		}
+		dbCache = ss.worker.Cache()
+		if repo, err := ss.repository(); err == nil {
+			fks, err = repo.DescribeForeignKeysBySchema(ctx, schemaName)
+			if err != nil && !errors.Is(err, database.ErrNotImplementation) {
+				return nil, err
+			}
+		}
+	}
+
+	diagram, err := database.NewERDiagram(dbCache, driver, schemaName, tableNames, fks)
+	if err != nil {
+		return nil, err
+	}
+	return diagram.Render(format)
+}
+
+func openRepository(cfg *database.DBConfig) (*database.DBConnection, database.DBRepository, error) {
+	conn, err := database.Open(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	repo, err := database.CreateRepository(cfg.Driver, conn.Conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, repo, nil
+}
+
+func (s *Server) showCreateTable(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	ss, args, err := s.commandSession(ctx, params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseSession(ss)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("required arguments were not provided: <Table Name>")
+	}
+	table, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the table name as a string")
+	}
+
+	repo, err := ss.repository()
+	if err != nil {
+		return nil, err
+	}
+	schemaName, tableName, err := splitTableName(ctx, repo, table)
+	if err != nil {
+		return nil, err
+	}
+	ddl, err := repo.ShowCreateTable(ctx, schemaName, tableName)
+	if err != nil {
+		if errors.Is(err, database.ErrNotImplementation) {
+			return nil, fmt.Errorf("show create table is not supported by %s", ss.cfg.Driver)
+		}
+		return nil, err
+	}
+	return ddl, nil
+}
+
+// findConnection returns the connection config by the alias or the 1-origin index.
+func (s *Server) findConnection(aliasOrIndex string) (*database.DBConfig, error) {
+	return s.getConfig().Connection(aliasOrIndex)
+}
+
+// splitTableName splits a table name optionally qualified by the schema.
+// The current schema is used when the schema is omitted.
+func splitTableName(ctx context.Context, repo database.DBRepository, table string) (string, string, error) {
+	schemaName, tableName, found := strings.Cut(table, ".")
+	if found {
+		return schemaName, tableName, nil
+	}
+	current, err := repo.CurrentSchema(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return current, table, nil
+}
+
+// describeTable returns the columns of a table, optionally qualified by the schema.
+func describeTable(ctx context.Context, repo database.DBRepository, table string) ([]*database.ColumnDesc, error) {
+	schemaName, tableName, err := splitTableName(ctx, repo, table)
+	if err != nil {
+		return nil, err
+	}
+	descs, err := repo.DescribeDatabaseTableBySchema(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	columns := []*database.ColumnDesc{}
+	for _, desc := range descs {
+		if strings.EqualFold(desc.Table, tableName) {
+			columns = append(columns, desc)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table not found, %q", table)
+	}
+	return columns, nil
+}
+
+func batchSizeFlag(args []interface{}) (int, error) {
+	batchSize := database.DefaultImportBatchSize
+	for _, arg := range args {
+		flag, ok := arg.(string)
+		if !ok || !strings.HasPrefix(flag, "-batch-size=") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(flag, "-batch-size="))
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("specify the batch size as a positive number")
+		}
+		batchSize = n
+	}
+	return batchSize, nil
+}
+
+const maxReportedImportErrors = 100
+
+func writeImportErrors(w io.Writer, importErrs []*database.ImportError, unit string) {
+	for i, importErr := range importErrs {
+		if i == maxReportedImportErrors {
+			fmt.Fprintf(w, "... and %d more", len(importErrs)-i)
+			fmt.Fprintln(w, "")
+			return
+		}
+		fmt.Fprintf(w, "%s %d: %s", unit, importErr.Line, importErr.Err)
+		fmt.Fprintln(w, "")
+	}
+}
+
+// countingReader counts the bytes read to report the progress of a file.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// targetQuery returns the statement under the cursor, or the selected
+// statement when rng is not empty, without the trailing semicolon.
+func targetQuery(text string, rng *lsp.Range) (string, error) {
+	pos := token.Pos{}
+	if rng != nil {
+		if rng.Start == rng.End {
+			pos = token.Pos{Line: rng.Start.Line, Col: rng.Start.Character}
+		} else {
+			text = extractRangeText(
+				text,
+				rng.Start.Line,
+				rng.Start.Character,
+				rng.End.Line,
+				rng.End.Character,
+			)
+		}
+	}
+	stmt, err := getStatementAt(text, pos)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSpace(stmt.String()), ";"), nil
+}
+
 func extractRangeText(text string, startLine, startChar, endLine, endChar int) string {
 	writer := bytes.NewBufferString("")
 	scanner := bufio.NewScanner(strings.NewReader(text))
@@ -203,22 +845,38 @@ func extractRangeText(text string, startLine, startChar, endLine, endChar int) s
 	return writer.String()
 }
 
-func (s *Server) query(ctx context.Context, query string, vertical bool) (string, error) {
-	repo, err := s.newDBRepository(ctx)
-	if err != nil {
-		return "", err
-	}
+func (s *Server) query(ctx context.Context, repo database.DBRepository, query string, vertical bool, formatter *database.ValueFormatter) (string, *lsp.QueryResultParams, error) {
+	start := time.Now()
 	rows, err := repo.Query(ctx, query)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	columns, err := database.Columns(rows)
+	defer rows.Close()
+	columnTypes, err := database.ColumnTypes(rows)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	stringRows, err := database.ScanRows(rows, len(columns))
+	valueRows, err := database.ScanRowValues(rows, len(columnTypes))
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	elapsed := time.Since(start)
+
+	columns := make([]string, len(columnTypes))
+	resultColumns := make([]*lsp.QueryResultColumn, len(columnTypes))
+	for i, colType := range columnTypes {
+		columns[i] = colType.Name
+		resultColumns[i] = queryResultColumn(colType)
+	}
+	stringRows := make([][]string, len(valueRows))
+	resultRows := make([][]interface{}, len(valueRows))
+	for i, valueRow := range valueRows {
+		if stringRows[i], err = formatter.RowStrings(valueRow, columnTypes); err != nil {
+			return "", nil, err
+		}
+		if resultRows[i], err = formatter.RowJSONValues(valueRow, columnTypes); err != nil {
+			return "", nil, err
+		}
 	}
 
 	buf := new(bytes.Buffer)
@@ -240,28 +898,57 @@ func (s *Server) query(ctx context.Context, query string, vertical bool) (string
 	fmt.Fprintf(buf, "%d rows in set", len(stringRows))
 	fmt.Fprintln(buf, "")
 	fmt.Fprintln(buf, "")
-	return buf.String(), nil
-}
 
-func (s *Server) exec(ctx context.Context, query string, vertical bool) (string, error) {
-	repo, err := s.newDBRepository(ctx)
-	if err != nil {
-		return "", err
+	resParams := &lsp.QueryResultParams{
+		Query:         query,
+		Columns:       resultColumns,
+		Rows:          resultRows,
+		ExecutionTime: durationMillis(elapsed),
 	}
+	return buf.String(), resParams, nil
+}
+
+func (s *Server) exec(ctx context.Context, repo database.DBRepository, query string, vertical bool) (string, *lsp.QueryResultParams, error) {
+	start := time.Now()
 	result, err := repo.Exec(ctx, query)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
+	elapsed := time.Since(start)
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	buf := new(bytes.Buffer)
 	fmt.Fprintf(buf, "Query OK, %d row affected", rowsAffected)
 	fmt.Fprintln(buf, "")
 	fmt.Fprintln(buf, "")
-	return buf.String(), nil
+
+	resParams := &lsp.QueryResultParams{
+		Query:         query,
+		Columns:       []*lsp.QueryResultColumn{},
+		Rows:          [][]interface{}{},
+		RowsAffected:  rowsAffected,
+		ExecutionTime: durationMillis(elapsed),
+	}
+	return buf.String(), resParams, nil
+}
+
+func queryResultColumn(colType *database.ColumnType) *lsp.QueryResultColumn {
+	col := &lsp.QueryResultColumn{
+		Name:         colType.Name,
+		DatabaseType: colType.DatabaseType,
+	}
+	if colType.NullableKnown {
+		nullable := colType.Nullable
+		col.Nullable = &nullable
+	}
+	return col
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
 }
 
 func (s *Server) showDatabases(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
@@ -419,6 +1106,32 @@ func getStatements(text string) ([]*ast.Statement, error) {
 	return stmts, nil
 }
 
+// getStatementAt returns the non-empty statement enclosing pos.
+// The first non-empty statement is returned if no statement encloses pos.
+func getStatementAt(text string, pos token.Pos) (*ast.Statement, error) {
+	stmts, err := getStatements(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var first *ast.Statement
+	for _, stmt := range stmts {
+		if strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(stmt.String()), ";")) == "" {
+			continue
+		}
+		if astutil.IsEnclose(stmt, pos) {
+			return stmt, nil
+		}
+		if first == nil {
+			first = stmt
+		}
+	}
+	if first == nil {
+		return nil, errors.New("statement not found")
+	}
+	return first, nil
+}
+
 type verticalTableWriter struct {
 	writer       io.Writer
 	headers      []string
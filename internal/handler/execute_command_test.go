@@ -1,11 +1,23 @@
 package handler
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/jsonrpc2"
 
 	"github.com/yaamai/sqls/internal/config"
 	"github.com/yaamai/sqls/internal/database"
 	"github.com/yaamai/sqls/internal/lsp"
+	"github.com/yaamai/sqls/token"
 )
 
 func Test_executeQuery(t *testing.T) {
@@ -55,6 +67,565 @@ func Test_executeQuery(t *testing.T) {
 	// pass error
 }
 
+func Test_executeQueryNotifyQueryResult(t *testing.T) {
+	results := make(chan *lsp.QueryResultParams, 1)
+	clientHandler := func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		if req.Method == "sqls/queryResult" {
+			var params lsp.QueryResultParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			results <- &params
+		}
+		return nil, nil
+	}
+	tx := newTestContext()
+	tx.clientHandler = jsonrpc2.HandlerWithError(clientHandler)
+	tx.setupConnection(t, &database.DBConfig{Driver: "sqlite3", DataSourceName: ":memory:"})
+	defer tx.tearDown()
+
+	uri := "file:///test.sql"
+	tx.textDocumentDidOpen(t, uri, "SELECT 1 AS id, 'foo' AS name, NULL AS note")
+
+	executeCommandParams := lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{uri},
+	}
+	var got interface{}
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", executeCommandParams, &got); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+
+	select {
+	case res := <-results:
+		if res.URI != uri {
+			t.Errorf("unexpected uri %q", res.URI)
+		}
+		gotNames := []string{}
+		for _, col := range res.Columns {
+			gotNames = append(gotNames, col.Name)
+		}
+		if diff := cmp.Diff([]string{"id", "name", "note"}, gotNames); diff != "" {
+			t.Errorf("unmatch columns (- want, + got):\n%s", diff)
+		}
+		wantRows := [][]interface{}{{float64(1), "foo", nil}}
+		if diff := cmp.Diff(wantRows, res.Rows); diff != "" {
+			t.Errorf("unmatch rows (- want, + got):\n%s", diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("query result notification was not received")
+	}
+}
+
+func Test_explainQuery(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	didChangeConfigurationParams := lsp.DidChangeConfigurationParams{
+		Settings: struct {
+			SQLS *config.Config "json:\"sqls\""
+		}{
+			SQLS: &config.Config{
+				Connections: []*database.DBConfig{
+					{
+						Driver:         "mock",
+						DataSourceName: "",
+					},
+				},
+			},
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "workspace/didChangeConfiguration", didChangeConfigurationParams, nil); err != nil {
+		t.Fatal("conn.Call workspace/didChangeConfiguration:", err)
+	}
+
+	uri := "file:///test.sql"
+	didOpenParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:        uri,
+			LanguageID: "sql",
+			Text:       "SELECT * FROM city JOIN country ON city.CountryCode = country.Code;",
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didOpen:", err)
+	}
+
+	executeCommandParams := lsp.ExecuteCommandParams{
+		Command:   CommandExplainQuery,
+		Arguments: []interface{}{uri},
+	}
+	var got string
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", executeCommandParams, &got); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	want := `Hash Join (cost=24.5 rows=4079)
+-> Seq Scan on city (cost=10.79 rows=4079)
+-> Seq Scan on country (cost=7.39 rows=239)
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unmatched plan (- want, + got):\n%s", diff)
+	}
+}
+
+func Test_exportQuery(t *testing.T) {
+	tx := newTestContext()
+	tx.setupConnection(t, &database.DBConfig{Driver: "sqlite3", DataSourceName: ":memory:"})
+	defer tx.tearDown()
+
+	uri := "file:///test.sql"
+	tx.textDocumentDidOpen(t, uri, "SELECT 1 AS id, 'foo' AS name;\nSELECT 2 AS id, 'bar' AS name;")
+
+	outPath := filepath.Join(t.TempDir(), "out.csv")
+	executeCommandParams := lsp.ExecuteCommandParams{
+		Command:   CommandExportQuery,
+		Arguments: []interface{}{uri, outPath},
+		Range: &lsp.Range{
+			Start: lsp.Position{Line: 1, Character: 3},
+			End:   lsp.Position{Line: 1, Character: 3},
+		},
+	}
+	var got string
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", executeCommandParams, &got); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	if !strings.HasPrefix(got, "1 rows exported to "+outPath) {
+		t.Errorf("unexpected result %q", got)
+	}
+	b, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("id,name\n2,bar\n", string(b)); diff != "" {
+		t.Errorf("unmatched export (- want, + got):\n%s", diff)
+	}
+	if info, err := os.Stat(outPath); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		t.Errorf("export file is readable by others, %v", info.Mode().Perm())
+	}
+
+	// a failed export leaves no file
+	failDir := t.TempDir()
+	executeCommandParams.Arguments = []interface{}{uri, filepath.Join(failDir, "out.sql")}
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", executeCommandParams, &got); err == nil {
+		t.Fatal("expected error for INSERT statements without the table name")
+	}
+	if entries, err := os.ReadDir(failDir); err != nil || len(entries) != 0 {
+		t.Errorf("files are left after the failed export, %v %v", entries, err)
+	}
+}
+
+func Test_importCSV(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	csvPath := filepath.Join(dir, "city.csv")
+	if err := os.WriteFile(csvPath, []byte("id,name\n1,Kabul\nx,Qandahar\n3,Herat\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := newTestContext()
+	tx.setupConnection(t, &database.DBConfig{Driver: "sqlite3", DataSourceName: dbPath})
+	defer tx.tearDown()
+
+	executeCommandParams := lsp.ExecuteCommandParams{
+		Command:   CommandImportCSV,
+		Arguments: []interface{}{csvPath, "city"},
+	}
+	var got string
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", executeCommandParams, &got); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	want := "2 rows imported into city, 1 rows failed\nline 3: column id, strconv.ParseInt: parsing \"x\": invalid syntax\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unmatched result (- want, + got):\n%s", diff)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM city").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("unexpected row count %d", count)
+	}
+}
+
+func Test_copyTable(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src.db")
+	dstPath := filepath.Join(dir, "dst.db")
+	createSQLiteDB(t, srcPath,
+		"CREATE TABLE city (id INTEGER, name TEXT, district TEXT)",
+		"INSERT INTO city VALUES (1, 'Kabul', 'Kabol'), (2, 'Qandahar', 'Qandahar')",
+	)
+	createSQLiteDB(t, dstPath, "CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT)")
+
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+	didChangeConfigurationParams := lsp.DidChangeConfigurationParams{
+		Settings: struct {
+			SQLS *config.Config "json:\"sqls\""
+		}{
+			SQLS: &config.Config{
+				Connections: []*database.DBConfig{
+					{Alias: "src", Driver: "sqlite3", DataSourceName: srcPath},
+					{Alias: "dst", Driver: "sqlite3", DataSourceName: dstPath},
+				},
+			},
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "workspace/didChangeConfiguration", didChangeConfigurationParams, nil); err != nil {
+		t.Fatal("conn.Call workspace/didChangeConfiguration:", err)
+	}
+
+	executeCommandParams := lsp.ExecuteCommandParams{
+		Command:   CommandCopyTable,
+		Arguments: []interface{}{"src", "SELECT * FROM city WHERE id = 2", "dst", "city"},
+	}
+	var got string
+	if err := tx.conn.Call(ctx, "workspace/executeCommand", executeCommandParams, &got); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	if want := "1 rows copied into city, 0 rows failed\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	db, err := sql.Open("sqlite3", dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var name string
+	if err := db.QueryRow("SELECT name FROM city WHERE id = 2").Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if name != "Qandahar" {
+		t.Errorf("unexpected name %q", name)
+	}
+}
+
+func Test_diffSchema(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src.db")
+	dstPath := filepath.Join(dir, "dst.db")
+	createSQLiteDB(t, srcPath, "CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT, district TEXT)")
+	createSQLiteDB(t, dstPath, "CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT)")
+
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+	didChangeConfigurationParams := lsp.DidChangeConfigurationParams{
+		Settings: struct {
+			SQLS *config.Config "json:\"sqls\""
+		}{
+			SQLS: &config.Config{
+				Connections: []*database.DBConfig{
+					{Alias: "staging", Driver: "sqlite3", DataSourceName: srcPath},
+					{Alias: "local", Driver: "sqlite3", DataSourceName: dstPath},
+				},
+			},
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "workspace/didChangeConfiguration", didChangeConfigurationParams, nil); err != nil {
+		t.Fatal("conn.Call workspace/didChangeConfiguration:", err)
+	}
+
+	executeCommandParams := lsp.ExecuteCommandParams{
+		Command:   CommandDiffSchema,
+		Arguments: []interface{}{"staging", "local"},
+	}
+	var got string
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", executeCommandParams, &got); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	want := "~ table city\n  + column district TEXT\n\nALTER TABLE city ADD COLUMN district TEXT;\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unmatched result (- want, + got):\n%s", diff)
+	}
+}
+
+func Test_showCreateTable(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "world.db")
+	createSQLiteDB(t, dbPath, "CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT)")
+
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+	didChangeConfigurationParams := lsp.DidChangeConfigurationParams{
+		Settings: struct {
+			SQLS *config.Config "json:\"sqls\""
+		}{
+			SQLS: &config.Config{
+				Connections: []*database.DBConfig{
+					{Driver: "sqlite3", DataSourceName: dbPath},
+				},
+			},
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "workspace/didChangeConfiguration", didChangeConfigurationParams, nil); err != nil {
+		t.Fatal("conn.Call workspace/didChangeConfiguration:", err)
+	}
+
+	executeCommandParams := lsp.ExecuteCommandParams{
+		Command:   CommandShowCreateTable,
+		Arguments: []interface{}{"city"},
+	}
+	var got string
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", executeCommandParams, &got); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	want := "CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT);"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unmatched result (- want, + got):\n%s", diff)
+	}
+}
+
+func Test_generateERDiagram(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "world.db")
+	createSQLiteDB(t, dbPath,
+		"CREATE TABLE country (code TEXT PRIMARY KEY, name TEXT)",
+		"CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT, country_code TEXT NOT NULL REFERENCES country(code))",
+	)
+
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+	didChangeConfigurationParams := lsp.DidChangeConfigurationParams{
+		Settings: struct {
+			SQLS *config.Config "json:\"sqls\""
+		}{
+			SQLS: &config.Config{
+				Connections: []*database.DBConfig{
+					{Driver: "sqlite3", DataSourceName: dbPath},
+				},
+			},
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "workspace/didChangeConfiguration", didChangeConfigurationParams, nil); err != nil {
+		t.Fatal("conn.Call workspace/didChangeConfiguration:", err)
+	}
+
+	executeCommandParams := lsp.ExecuteCommandParams{
+		Command:   CommandGenerateERD,
+		Arguments: []interface{}{},
+	}
+	var got string
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", executeCommandParams, &got); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	want := `erDiagram
+    city {
+        INTEGER id PK
+        TEXT name
+        TEXT country_code FK
+    }
+    country {
+        TEXT code PK
+        TEXT name
+    }
+    country ||--o{ city : "country_code"
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unmatched mermaid (- want, + got):\n%s", diff)
+	}
+
+	executeCommandParams.Arguments = []interface{}{"-format=dot", "city"}
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", executeCommandParams, &got); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	if !strings.HasPrefix(got, "digraph ") || strings.Contains(got, `"country" [`) {
+		t.Errorf("unexpected dot, %s", got)
+	}
+}
+
+func Test_executeQueryRefreshSchemaAfterDDL(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "world.db")
+	createSQLiteDB(t, dbPath, "CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT)")
+
+	tx := newTestContext()
+	tx.setupConnection(t, &database.DBConfig{Driver: "sqlite3", DataSourceName: dbPath})
+	defer tx.tearDown()
+
+	complete := func(uri string, col int) []string {
+		completionParams := lsp.CompletionParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+				Position:     lsp.Position{Line: 0, Character: col},
+			},
+		}
+		var items []lsp.CompletionItem
+		if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &items); err != nil {
+			t.Fatal("conn.Call textDocument/completion:", err)
+		}
+		labels := []string{}
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+	contains := func(labels []string, label string) bool {
+		for _, l := range labels {
+			if l == label {
+				return true
+			}
+		}
+		return false
+	}
+
+	tx.textDocumentDidOpen(t, "file:///select.sql", "SELECT  FROM city")
+	if got := complete("file:///select.sql", 7); contains(got, "population") {
+		t.Fatalf("unexpected column before DDL, %v", got)
+	}
+
+	tx.textDocumentDidOpen(t, "file:///ddl.sql", "ALTER TABLE city ADD COLUMN population INTEGER")
+	executeCommandParams := lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{"file:///ddl.sql"},
+	}
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", executeCommandParams, nil); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	if got := complete("file:///select.sql", 7); !contains(got, "population") {
+		t.Errorf("added column is not completed after DDL, %v", got)
+	}
+
+	// changed outside of the server, visible after the refresh command
+	createSQLiteDB(t, dbPath, "CREATE TABLE country (code TEXT, continent TEXT)")
+	tx.textDocumentDidOpen(t, "file:///country.sql", "SELECT  FROM country")
+	if got := complete("file:///country.sql", 7); contains(got, "continent") {
+		t.Fatalf("unexpected column before refresh, %v", got)
+	}
+	refreshParams := lsp.ExecuteCommandParams{Command: CommandRefreshSchema}
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", refreshParams, nil); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	if got := complete("file:///country.sql", 7); !contains(got, "continent") {
+		t.Errorf("column is not completed after refresh, %v", got)
+	}
+}
+
+func Test_executeQuerySchemaFile(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "schema.yml")
+	schemaFile := `
+driver: mysql
+defaultSchema: world
+schemas:
+  - name: world
+    tables:
+      - name: city
+        columns:
+          - {name: id, type: int, primaryKey: true}
+          - {name: name, type: varchar(35)}
+queries:
+  - query: SELECT id, name FROM city
+    columns: [id, name]
+    rows: [[1, Kabul], [2, Qandahar]]
+`
+	if err := os.WriteFile(schemaPath, []byte(schemaFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := newTestContext()
+	tx.setupConnection(t, &database.DBConfig{Driver: "schemafile", DataSourceName: schemaPath})
+	defer tx.tearDown()
+
+	uri := "file:///city.sql"
+	tx.textDocumentDidOpen(t, uri, "SELECT id, name FROM city")
+
+	completionParams := lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+			Position:     lsp.Position{Line: 0, Character: 7},
+		},
+	}
+	var items []lsp.CompletionItem
+	if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &items); err != nil {
+		t.Fatal("conn.Call textDocument/completion:", err)
+	}
+	found := false
+	for _, item := range items {
+		if item.Label == "name" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("column of the schema file is not completed, %v", items)
+	}
+
+	executeCommandParams := lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{uri},
+	}
+	var got string
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", executeCommandParams, &got); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	if !strings.Contains(got, "Kabul") || !strings.Contains(got, "Qandahar") {
+		t.Errorf("canned result is not returned, %s", got)
+	}
+}
+
+func createSQLiteDB(t *testing.T, path string, queries ...string) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, q := range queries {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_getStatementAt(t *testing.T) {
+	text := "SELECT 1;\nSELECT 2;\n\nSELECT 3"
+	tests := []struct {
+		name string
+		pos  token.Pos
+		want string
+	}{
+		{
+			name: "first statement",
+			pos:  token.Pos{Line: 0, Col: 3},
+			want: "SELECT 1;",
+		},
+		{
+			name: "second statement",
+			pos:  token.Pos{Line: 1, Col: 3},
+			want: "SELECT 2;",
+		},
+		{
+			name: "last statement without semicolon",
+			pos:  token.Pos{Line: 3, Col: 8},
+			want: "SELECT 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := getStatementAt(text, tt.pos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(stmt.String()); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_extractRangeText(t *testing.T) {
 	type args struct {
 		text      string
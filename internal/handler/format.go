@@ -20,7 +20,7 @@ func (s *Server) handleTextDocumentFormatting(ctx context.Context, conn *jsonrpc
 		return nil, err
 	}
 
-	f, ok := s.files[params.TextDocument.URI]
+	f, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
@@ -45,7 +45,7 @@ func (s *Server) handleTextDocumentRangeFormatting(ctx context.Context, conn *js
 		return nil, err
 	}
 
-	_, ok := s.files[params.TextDocument.URI]
+	_, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"runtime"
+	"sync"
 
 	"github.com/sourcegraph/jsonrpc2"
 
@@ -24,12 +25,21 @@ type Server struct {
 	DefaultFileCfg  *config.Config
 	WSCfg           *config.Config
 
+	// mu guards the connection and the workspace config. They are only
+	// changed by the requests served in order, the requests served
+	// concurrently read them with connection and getConfig.
+	mu     sync.RWMutex
 	dbConn *database.DBConnection
 
 	curDBCfg           *database.DBConfig
 	curDBName          string
 	curConnectionIndex int
 
+	// offlineSchema is the config of the schema read from the DDL files,
+	// which is used while no connection is configured
+	offlineSchema *database.OfflineSchemaConfig
+	rootPath      string
+
 	// The initOptionDBConfig is an optional param
 	// sent by the client as part of the LSP InitializationOptions
 	// payload. If non-nil, the server will ignore all
@@ -37,12 +47,23 @@ type Server struct {
 	initOptionDBConfig *database.DBConfig
 
 	worker *database.Worker
-	files  map[string]*File
-}
-
-type File struct {
-	LanguageID string
-	Text       string
+	files  *documentStore
+
+	// bindings are the connection aliases bound to the documents by
+	// bindConnection, and sessions are the connections opened for the
+	// documents bound to a connection other than the one of the server.
+	bindingMu sync.RWMutex
+	bindings  map[string]string
+	sessionMu sync.Mutex
+	sessions  map[string]*session
+
+	// projectCfg is the project config file found from the workspace root
+	// or the opened documents, it is merged over the user config.
+	// mergedCfg is the merge over mergedBase.
+	projectMu  sync.Mutex
+	projectCfg *config.ProjectConfig
+	mergedBase *config.Config
+	mergedCfg  *config.Config
 }
 
 func NewServer() *Server {
@@ -50,8 +71,10 @@ func NewServer() *Server {
 	worker.Start()
 
 	return &Server{
-		files:  make(map[string]*File),
-		worker: worker,
+		files:    newDocumentStore(),
+		worker:   worker,
+		bindings: map[string]string{},
+		sessions: map[string]*session{},
 	}
 }
 
@@ -69,6 +92,7 @@ func panicf(r interface{}, format string, v ...interface{}) error {
 }
 
 func (s *Server) Stop() error {
+	s.closeSessions()
 	if err := s.dbConn.Close(); err != nil {
 		return err
 	}
@@ -167,7 +191,17 @@ func (s *Server) handleInitialize(ctx context.Context, conn *jsonrpc2.Conn, req
 		},
 	}
 
+	if err := config.ExpandEnv(params.InitializationOptions.ConnectionConfig); err != nil {
+		return nil, err
+	}
 	s.initOptionDBConfig = params.InitializationOptions.ConnectionConfig
+	s.rootPath = params.RootPath
+	if params.RootURI != "" {
+		s.rootPath = uriToPath(params.RootURI)
+	}
+	if s.rootPath != "" {
+		s.discoverProjectConfig(s.rootPath)
+	}
 
 	// Initialize database database connection
 	// NOTE: If no connection is found at this point, it is possible that the connection settings are sent to workspace config, so don't make an error
@@ -213,12 +247,12 @@ func (s *Server) handleTextDocumentDidOpen(ctx context.Context, conn *jsonrpc2.C
 		return nil, err
 	}
 
-	if err := s.openFile(params.TextDocument.URI, params.TextDocument.LanguageID); err != nil {
-		return nil, err
-	}
-	if err := s.updateFile(params.TextDocument.URI, params.TextDocument.Text); err != nil {
+	if err := s.openFile(params.TextDocument.URI, params.TextDocument.LanguageID, params.TextDocument.Version, params.TextDocument.Text); err != nil {
 		return nil, err
 	}
+	schedule(ctx, func() {
+		s.discoverDocumentProjectConfig(ctx, params.TextDocument.URI)
+	})
 	return nil, nil
 }
 
@@ -232,7 +266,7 @@ func (s *Server) handleTextDocumentDidChange(ctx context.Context, conn *jsonrpc2
 		return nil, err
 	}
 
-	if err := s.updateFile(params.TextDocument.URI, params.ContentChanges[0].Text); err != nil {
+	if err := s.updateFile(params.TextDocument.URI, params.TextDocument.Version, params.ContentChanges[0].Text); err != nil {
 		return nil, err
 	}
 	return nil, nil
@@ -249,13 +283,17 @@ func (s *Server) handleTextDocumentDidSave(ctx context.Context, conn *jsonrpc2.C
 	}
 
 	if params.Text != "" {
-		err = s.updateFile(params.TextDocument.URI, params.Text)
+		err = s.updateFile(params.TextDocument.URI, 0, params.Text)
 	} else {
 		err = s.saveFile(params.TextDocument.URI)
 	}
 	if err != nil {
 		return nil, err
 	}
+	schedule(ctx, func() {
+		s.reloadProjectConfig(ctx, params.TextDocument.URI)
+		s.reloadOfflineSchema(ctx, params.TextDocument.URI)
+	})
 	return nil, nil
 }
 
@@ -272,30 +310,25 @@ func (s *Server) handleTextDocumentDidClose(ctx context.Context, conn *jsonrpc2.
 	if err := s.closeFile(params.TextDocument.URI); err != nil {
 		return nil, err
 	}
+	s.bindingMu.Lock()
+	delete(s.bindings, params.TextDocument.URI)
+	s.bindingMu.Unlock()
+	s.releaseSessions()
 	return nil, nil
 }
 
-func (s *Server) openFile(uri string, languageID string) error {
-	f := &File{
-		Text:       "",
-		LanguageID: languageID,
-	}
-	s.files[uri] = f
+func (s *Server) openFile(uri string, languageID string, version int, text string) error {
+	s.files.open(uri, languageID, version, text)
 	return nil
 }
 
 func (s *Server) closeFile(uri string) error {
-	delete(s.files, uri)
+	s.files.close(uri)
 	return nil
 }
 
-func (s *Server) updateFile(uri string, text string) error {
-	f, ok := s.files[uri]
-	if !ok {
-		return fmt.Errorf("document not found: %v", uri)
-	}
-	f.Text = text
-	return nil
+func (s *Server) updateFile(uri string, version int, text string) error {
+	return s.files.update(uri, version, text)
 }
 
 func (s *Server) saveFile(uri string) error {
@@ -308,7 +341,14 @@ func (s *Server) handleWorkspaceDidChangeConfiguration(ctx context.Context, conn
 	if err := json.Unmarshal(*req.Params, &params); err != nil {
 		return nil, err
 	}
+	if err := config.ExpandEnv(params.Settings.SQLS); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
 	s.WSCfg = params.Settings.SQLS
+	s.mu.Unlock()
+	// the aliases of the bound documents may point to other connections
+	s.closeSessions()
 
 	// Skip database connection
 	if s.dbConn != nil {
@@ -335,23 +375,30 @@ func (s *Server) handleWorkspaceDidChangeConfiguration(ctx context.Context, conn
 }
 
 func (s *Server) reconnectionDB(ctx context.Context) error {
-	if err := s.dbConn.Close(); err != nil {
+	dbConn, _ := s.connection()
+	if err := dbConn.Close(); err != nil {
 		return err
 	}
 
 	dbConn, err := s.newDBConnection(ctx)
+	if errors.Is(err, ErrNoConnection) {
+		if cfg := s.getConfig().OfflineSchema; cfg != nil {
+			return s.loadOfflineSchema(ctx, cfg)
+		}
+	}
 	if err != nil {
 		return err
 	}
+	s.mu.Lock()
 	s.dbConn = dbConn
+	s.offlineSchema = nil
+	s.mu.Unlock()
 	dbRepo, err := s.newDBRepository(ctx)
 	if err != nil {
 		return err
 	}
-	if err := s.worker.ReCache(ctx, dbRepo); err != nil {
-		return err
-	}
-	return nil
+	s.worker.SetMaxLoadedSchemas(s.getConfig().MaxLoadedSchemas)
+	return reCache(ctx, s.worker, s.curDBCfg, dbRepo)
 }
 
 func (s *Server) newDBConnection(ctx context.Context) (*database.DBConnection, error) {
@@ -369,7 +416,9 @@ func (s *Server) newDBConnection(ctx context.Context) (*database.DBConnection, e
 	if s.curDBName != "" {
 		connCfg.DBName = s.curDBName
 	}
+	s.mu.Lock()
 	s.curDBCfg = connCfg
+	s.mu.Unlock()
 
 	// Connect database
 	conn, err := database.Open(connCfg)
@@ -380,13 +429,25 @@ func (s *Server) newDBConnection(ctx context.Context) (*database.DBConnection, e
 }
 
 func (s *Server) newDBRepository(ctx context.Context) (database.DBRepository, error) {
-	repo, err := database.CreateRepository(s.curDBCfg.Driver, s.dbConn.Conn)
+	dbConn, connCfg := s.connection()
+	if dbConn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	repo, err := database.CreateRepository(connCfg.Driver, dbConn.Conn)
 	if err != nil {
 		return nil, err
 	}
 	return repo, nil
 }
 
+// connection returns the current database connection and its config, the
+// connection is nil before connecting.
+func (s *Server) connection() (*database.DBConnection, *database.DBConfig) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dbConn, s.curDBCfg
+}
+
 func (s *Server) topConnection() *database.DBConfig {
 	// if the init config is set, ignore all other connection configs
 	if s.initOptionDBConfig != nil {
@@ -409,18 +470,22 @@ func (s *Server) getConnection(index int) *database.DBConfig {
 }
 
 func (s *Server) getConfig() *config.Config {
+	s.mu.RLock()
 	var cfg *config.Config
+	var isDefault bool
 	switch {
 	case validConfig(s.SpecificFileCfg):
-		cfg = s.SpecificFileCfg
+		// the specified config file is used as is
+		s.mu.RUnlock()
+		return s.SpecificFileCfg
 	case validConfig(s.WSCfg):
 		cfg = s.WSCfg
 	case validConfig(s.DefaultFileCfg):
 		cfg = s.DefaultFileCfg
-	default:
-		cfg = config.NewConfig()
+		isDefault = true
 	}
-	return cfg
+	s.mu.RUnlock()
+	return s.mergeProjectConfig(cfg, isDefault)
 }
 
 func validConfig(cfg *config.Config) bool {
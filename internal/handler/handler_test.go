@@ -5,28 +5,47 @@ import (
 	"errors"
 	"log"
 	"net"
+	"os"
 	"reflect"
 	"testing"
 
 	"github.com/sourcegraph/jsonrpc2"
 
 	"github.com/yaamai/sqls/internal/config"
+	"github.com/yaamai/sqls/internal/database"
 	"github.com/yaamai/sqls/internal/lsp"
 )
 
 const testFileURI = "file:///Users/octref/Code/css-test/test.sql"
 
+func TestMain(m *testing.M) {
+	// keep the schema cache files of the test connections out of the user cache
+	cacheDir, err := os.MkdirTemp("", "sqls-handler-test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Setenv("XDG_CACHE_HOME", cacheDir)
+	code := m.Run()
+	os.RemoveAll(cacheDir)
+	os.Exit(code)
+}
+
 type TestContext struct {
 	h          jsonrpc2.Handler
 	conn       *jsonrpc2.Conn
 	connServer *jsonrpc2.Conn
 	server     *Server
 	ctx        context.Context
+
+	// initParams are sent by initialize, and clientHandler serves the
+	// notifications sent to the client such as sqls/queryResult.
+	initParams    lsp.InitializeParams
+	clientHandler jsonrpc2.Handler
 }
 
 func newTestContext() *TestContext {
 	server := NewServer()
-	handler := jsonrpc2.HandlerWithError(server.Handle)
+	handler := server.Handler()
 	ctx := context.Background()
 	return &TestContext{
 		h:      handler,
@@ -40,6 +59,14 @@ func (tx *TestContext) setup(t *testing.T) {
 	tx.initServer(t)
 }
 
+// setupConnection sets up the server connected with the connection config
+// of the initialization options.
+func (tx *TestContext) setupConnection(t *testing.T, cfg *database.DBConfig) {
+	t.Helper()
+	tx.initParams.InitializationOptions.ConnectionConfig = cfg
+	tx.initServer(t)
+}
+
 func (tx *TestContext) tearDown() {
 	if tx.conn != nil {
 		if err := tx.conn.Close(); err != nil {
@@ -61,14 +88,15 @@ func (tx *TestContext) initServer(t *testing.T) {
 
 	// Prepare the server and client connection.
 	client, server := net.Pipe()
+	clientHandler := tx.clientHandler
+	if clientHandler == nil {
+		clientHandler = tx.h
+	}
 	tx.connServer = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(server, jsonrpc2.VSCodeObjectCodec{}), tx.h)
-	tx.conn = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(client, jsonrpc2.VSCodeObjectCodec{}), tx.h)
+	tx.conn = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(client, jsonrpc2.VSCodeObjectCodec{}), clientHandler)
 
 	// Initialize Language Server
-	params := lsp.InitializeParams{
-		InitializationOptions: lsp.InitializeOptions{},
-	}
-	if err := tx.conn.Call(tx.ctx, "initialize", params, nil); err != nil {
+	if err := tx.conn.Call(tx.ctx, "initialize", tx.initParams, nil); err != nil {
 		t.Fatal("conn.Call initialize:", err)
 	}
 }
@@ -86,10 +114,18 @@ func (tx *TestContext) addWorkspaceConfig(t *testing.T, cfg *config.Config) {
 	}
 }
 
+// waitScheduled waits for the work scheduled by the document notifications,
+// the request is served in order after it.
+func (tx *TestContext) waitScheduled(t *testing.T) {
+	if err := tx.conn.Call(tx.ctx, "initialized", nil, nil); err != nil {
+		t.Fatal("conn.Call initialized:", err)
+	}
+}
+
 func (tx *TestContext) textDocumentDidOpen(t *testing.T, uri, input string) {
 	didOpenParams := lsp.DidOpenTextDocumentParams{
 		TextDocument: lsp.TextDocumentItem{
-			URI:        testFileURI,
+			URI:        uri,
 			LanguageID: "sql",
 			Version:    0,
 			Text:       input,
@@ -203,14 +239,14 @@ func TestFileWatch(t *testing.T) {
 	if err := tx.conn.Call(tx.ctx, "textDocument/didClose", didCloseParams, nil); err != nil {
 		t.Fatal("conn.Call textDocument/didClose:", err)
 	}
-	_, ok := tx.server.files[didCloseParams.TextDocument.URI]
+	_, ok := tx.server.files.get(didCloseParams.TextDocument.URI)
 	if ok {
 		t.Errorf("found opened file. URI:%s", didCloseParams.TextDocument.URI)
 	}
 }
 
 func (tx *TestContext) testFile(t *testing.T, uri, text string) {
-	f, ok := tx.server.files[uri]
+	f, ok := tx.server.files.get(uri)
 	if !ok {
 		t.Errorf("not found opened file. URI:%s", uri)
 	}
@@ -28,12 +28,19 @@ func (s *Server) handleTextDocumentHover(ctx context.Context, conn *jsonrpc2.Con
 		return nil, err
 	}
 
-	f, ok := s.files[params.TextDocument.URI]
+	f, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	res, err := hover(f.Text, params, s.worker.Cache())
+	ss, err := s.documentSession(ctx, params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseSession(ss)
+	loadReferencedSchemas(ctx, ss.worker, f.Text)
+	dbCache := ss.worker.Cache()
+	res, err := hover(f.Text, params, dbCache, tableDDLLookupOf(ss, dbCache))
 	if err != nil {
 		if errors.Is(ErrNoHover, err) {
 			return nil, nil
@@ -43,7 +50,27 @@ func (s *Server) handleTextDocumentHover(ctx context.Context, conn *jsonrpc2.Con
 	return res, nil
 }
 
-func hover(text string, params lsp.HoverParams, dbCache *database.DBCache) (*lsp.Hover, error) {
+// tableDDLLookup returns the CREATE statement of a table, it is optional for hover.
+type tableDDLLookup func(schemaName, tableName string) (string, bool)
+
+// tableDDLLookupOf builds the CREATE statement from the cached columns and
+// indexes, hover does not wait for the database. The showCreateTable command
+// shows the statement of the database.
+func tableDDLLookupOf(ss *session, dbCache *database.DBCache) tableDDLLookup {
+	if ss.conn == nil || dbCache == nil {
+		return nil
+	}
+	return func(schemaName, tableName string) (string, bool) {
+		cols, ok := dbCache.ColumnDatabase(schemaName, tableName)
+		if !ok || len(cols) == 0 {
+			return "", false
+		}
+		indexes, _ := dbCache.IndexesByDBName(schemaName, tableName)
+		return database.TableDDL(schemaName, cols, indexes, ss.driver), true
+	}
+}
+
+func hover(text string, params lsp.HoverParams, dbCache *database.DBCache, ddlLookup tableDDLLookup) (*lsp.Hover, error) {
 	if dbCache == nil {
 		return nil, nil
 	}
@@ -76,6 +103,7 @@ func hover(text string, params lsp.HoverParams, dbCache *database.DBCache) (*lsp
 	if err != nil {
 		return nil, err
 	}
+	hoverEnv.tableDDL = ddlLookup
 
 	// Check hover type
 	ctx := getHoverTypes(nodeWalker, hoverEnv)
@@ -107,6 +135,11 @@ func hover(text string, params lsp.HoverParams, dbCache *database.DBCache) (*lsp
 		// example "c[i]ty"
 		hoverContent = hoverContentFromIdent(ctx, ident.NoQuoteString(), dbCache, hoverEnv)
 	}
+	if hoverContent == nil {
+		// The identifier may be a stored function or procedure
+		// example "CALL m[y]_proc()"
+		hoverContent = routineHoverInfo(ident, memIdent, dbCache)
+	}
 	if hoverContent == nil {
 		return nil, ErrNoHover
 	}
@@ -136,6 +169,7 @@ type hoverEnvironment struct {
 	aliases    []ast.Node
 	tables     []*parseutil.TableInfo
 	subQueries []*parseutil.SubQueryInfo
+	tableDDL   tableDDLLookup
 }
 
 func (e *hoverEnvironment) getTableRealName(aliasName string) (string, bool) {
@@ -250,10 +284,13 @@ func hoverContentFromIdent(ctx *hoverContext, identName string, dbCache *databas
 				tableName = table.Name
 			}
 		}
+		if view, ok := dbCache.View(tableName); ok {
+			return viewHoverInfo(view, dbCache)
+		}
 		// find table
 		cols, ok := dbCache.ColumnDescs(tableName)
 		if ok {
-			return tableHoverInfo(tableName, cols)
+			return tableHoverInfo(tableName, cols, dbCache, hoverEnv)
 		}
 	}
 	if hoverTypeIs(ctx.types, hoverTypeSubQueryColumn) {
@@ -278,9 +315,12 @@ func hoverContentFromParentIdent(ctx *hoverContext, identName string, dbCache *d
 		if ok {
 			tableName = realName
 		}
+		if view, ok := dbCache.View(tableName); ok {
+			return viewHoverInfo(view, dbCache)
+		}
 		columns, ok := dbCache.ColumnDescs(tableName)
 		if ok {
-			return tableHoverInfo(tableName, columns)
+			return tableHoverInfo(tableName, columns, dbCache, hoverEnv)
 		}
 	case parentTypeSubQuery:
 		subQueryName := identName
@@ -298,9 +338,12 @@ func hoverContentFromChildIdent(ctx *hoverContext, identName string, dbCache *da
 	case parentTypeNone:
 		return nil
 	case parentTypeSchema:
+		if view, ok := dbCache.ViewByDBName(ctx.parent.Name, identName); ok {
+			return viewHoverInfo(view, dbCache)
+		}
 		columns, ok := dbCache.ColumnDescs(identName)
 		if ok {
-			return tableHoverInfo(identName, columns)
+			return tableHoverInfo(identName, columns, dbCache, hoverEnv)
 		}
 	case parentTypeTable:
 		tableName := ctx.parent.Name
@@ -329,10 +372,52 @@ func columnHoverInfo(tableName, colName string, colDesc *database.ColumnDesc) *l
 	}
 }
 
-func tableHoverInfo(tableName string, cols []*database.ColumnDesc) *lsp.MarkupContent {
+func tableHoverInfo(tableName string, cols []*database.ColumnDesc, dbCache *database.DBCache, hoverEnv *hoverEnvironment) *lsp.MarkupContent {
+	var (
+		indexes []*database.IndexDesc
+		comment string
+	)
+	if len(cols) > 0 {
+		indexes, _ = dbCache.IndexesByDBName(cols[0].Schema, cols[0].Table)
+		comment = dbCache.TableComment(cols[0].Schema, cols[0].Table)
+	}
+	doc := database.TableDoc(tableName, comment, cols, indexes)
+	if hoverEnv.tableDDL != nil && len(cols) > 0 {
+		if ddl, ok := hoverEnv.tableDDL(cols[0].Schema, cols[0].Table); ok {
+			doc += "\n```sql\n" + ddl + "\n```\n"
+		}
+	}
+	return &lsp.MarkupContent{
+		Kind:  lsp.Markdown,
+		Value: doc,
+	}
+}
+
+func viewHoverInfo(view *database.ViewDesc, dbCache *database.DBCache) *lsp.MarkupContent {
+	cols, _ := dbCache.ColumnDatabase(view.Schema, view.Name)
+	return &lsp.MarkupContent{
+		Kind:  lsp.Markdown,
+		Value: database.ViewDoc(view, cols),
+	}
+}
+
+func routineHoverInfo(ident *ast.Identifier, memIdent *ast.MemberIdentifier, dbCache *database.DBCache) *lsp.MarkupContent {
+	var (
+		routines []*database.RoutineDesc
+		ok       bool
+	)
+	switch {
+	case memIdent != nil && memIdent.ChildTok != nil && (ident == nil || ident.NoQuoteString() == memIdent.ChildTok.NoQuoteString()):
+		routines, ok = dbCache.RoutinesByDBName(memIdent.ParentTok.NoQuoteString(), memIdent.ChildTok.NoQuoteString())
+	case ident != nil && memIdent == nil:
+		routines, ok = dbCache.Routines(ident.NoQuoteString())
+	}
+	if !ok {
+		return nil
+	}
 	return &lsp.MarkupContent{
 		Kind:  lsp.Markdown,
-		Value: database.TableDoc(tableName, cols),
+		Value: database.RoutineDoc(routines),
 	}
 }
 
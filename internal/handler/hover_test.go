@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -9,6 +11,11 @@ import (
 	"github.com/yaamai/sqls/internal/lsp"
 )
 
+// cityTableHover is the hover of the city table, the CREATE statement is
+// built from the cache.
+const cityTableHover = "# `city` table\n\n\n| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |\n| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |\n| `ID` | `int(11)` | `PRI` | `<null>` | auto_increment |\n| `Name` | `char(35)` | `` | `-` |  |\n| `CountryCode` | `char(3)` | `MUL` | `-` |  |\n| `District` | `char(20)` | `` | `-` |  |\n| `Population` | `int(11)` | `` | `-` |  |\n" +
+	"\n```sql\nCREATE TABLE world.city (\n  ID int(11) NOT NULL,\n  Name char(35) NOT NULL,\n  CountryCode char(3) NOT NULL,\n  District char(20) NOT NULL,\n  Population int(11) NOT NULL,\n  PRIMARY KEY (ID)\n);\n```\n"
+
 var hoverTestCases = []struct {
 	name   string
 	input  string
@@ -68,28 +75,28 @@ var hoverTestCases = []struct {
 	{
 		name:   "table ident head",
 		input:  "SELECT ID, Name FROM city",
-		output: "# `city` table\n\n\n| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |\n| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |\n| `ID` | `int(11)` | `PRI` | `<null>` | auto_increment |\n| `Name` | `char(35)` | `` | `-` |  |\n| `CountryCode` | `char(3)` | `MUL` | `-` |  |\n| `District` | `char(20)` | `` | `-` |  |\n| `Population` | `int(11)` | `` | `-` |  |\n",
+		output: cityTableHover,
 		line:   0,
 		col:    22,
 	},
 	{
 		name:   "table ident tail",
 		input:  "SELECT ID, Name FROM city",
-		output: "# `city` table\n\n\n| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |\n| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |\n| `ID` | `int(11)` | `PRI` | `<null>` | auto_increment |\n| `Name` | `char(35)` | `` | `-` |  |\n| `CountryCode` | `char(3)` | `MUL` | `-` |  |\n| `District` | `char(20)` | `` | `-` |  |\n| `Population` | `int(11)` | `` | `-` |  |\n",
+		output: cityTableHover,
 		line:   0,
 		col:    25,
 	},
 	{
 		name:   "select member ident parent head",
 		input:  "SELECT city.ID, city.Name FROM city",
-		output: "# `city` table\n\n\n| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |\n| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |\n| `ID` | `int(11)` | `PRI` | `<null>` | auto_increment |\n| `Name` | `char(35)` | `` | `-` |  |\n| `CountryCode` | `char(3)` | `MUL` | `-` |  |\n| `District` | `char(20)` | `` | `-` |  |\n| `Population` | `int(11)` | `` | `-` |  |\n",
+		output: cityTableHover,
 		line:   0,
 		col:    8,
 	},
 	{
 		name:   "select member ident parent tail",
 		input:  "SELECT city.ID, city.Name FROM city",
-		output: "# `city` table\n\n\n| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |\n| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |\n| `ID` | `int(11)` | `PRI` | `<null>` | auto_increment |\n| `Name` | `char(35)` | `` | `-` |  |\n| `CountryCode` | `char(3)` | `MUL` | `-` |  |\n| `District` | `char(20)` | `` | `-` |  |\n| `Population` | `int(11)` | `` | `-` |  |\n",
+		output: cityTableHover,
 		line:   0,
 		col:    20,
 	},
@@ -117,7 +124,7 @@ var hoverTestCases = []struct {
 	{
 		name:   "select aliased member ident parent",
 		input:  "SELECT ci.ID, ci.Name FROM city AS ci",
-		output: "# `city` table\n\n\n| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |\n| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |\n| `ID` | `int(11)` | `PRI` | `<null>` | auto_increment |\n| `Name` | `char(35)` | `` | `-` |  |\n| `CountryCode` | `char(3)` | `MUL` | `-` |  |\n| `District` | `char(20)` | `` | `-` |  |\n| `Population` | `int(11)` | `` | `-` |  |\n",
+		output: cityTableHover,
 		line:   0,
 		col:    8,
 	},
@@ -281,3 +288,113 @@ func TestHoverNoneDBConnection(t *testing.T) {
 		})
 	}
 }
+
+func TestHoverTableDDL(t *testing.T) {
+	ctx := context.Background()
+	dbCache, err := database.NewDBCacheUpdater(database.NewMockDBRepository(nil)).GenerateDBCachePrimary(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := func(schemaName, tableName string) (string, bool) {
+		if tableName != "city" {
+			return "", false
+		}
+		return "CREATE TABLE city (ID int(11));", true
+	}
+	params := lsp.HoverParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			Position: lsp.Position{Line: 0, Character: 22},
+		},
+	}
+	got, err := hover("SELECT ID, Name FROM city", params, dbCache, lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\n```sql\nCREATE TABLE city (ID int(11));\n```\n"
+	if !strings.HasSuffix(got.Contents.Value, want) {
+		t.Errorf("ddl is not found in hover contents, %q", got.Contents.Value)
+	}
+}
+
+func TestHoverView(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMockDBRepository(nil).(*database.MockDBRepository)
+	repo.MockSchemaViews = func(ctx context.Context) (map[string][]*database.ViewDesc, error) {
+		return map[string][]*database.ViewDesc{
+			"world": {{Schema: "world", Name: "big_city", Definition: "SELECT * FROM city WHERE Population > 1000000"}},
+		}, nil
+	}
+	dbCache, err := database.NewDBCacheUpdater(repo).GenerateDBCachePrimary(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		col   int
+	}{
+		{name: "view ident", input: "SELECT * FROM big_city", col: 17},
+		{name: "view member ident", input: "SELECT * FROM world.big_city", col: 23},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := lsp.HoverParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					Position: lsp.Position{Line: 0, Character: tt.col},
+				},
+			}
+			got, err := hover(tt.input, params, dbCache, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "# `big_city` view\n\n\n```sql\nSELECT * FROM city WHERE Population > 1000000\n```\n"
+			if diff := cmp.Diff(want, got.Contents.Value); diff != "" {
+				t.Errorf("unmatch hover contents (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHoverRoutine(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMockDBRepository(nil).(*database.MockDBRepository)
+	repo.MockSchemaRoutines = func(ctx context.Context) (map[string][]*database.RoutineDesc, error) {
+		return map[string][]*database.RoutineDesc{
+			"world": {{
+				Schema: "world", Name: "move_city", Kind: "PROCEDURE",
+				Params: []*database.RoutineParam{{Name: "city_id", Mode: "IN", Type: "int"}},
+			}},
+		}, nil
+	}
+	dbCache, err := database.NewDBCacheUpdater(repo).GenerateDBCachePrimary(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		col   int
+	}{
+		{name: "call", input: "CALL move_city(1)", col: 7},
+		{name: "exec member ident", input: "EXEC world.move_city 1", col: 14},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := lsp.HoverParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					Position: lsp.Position{Line: 0, Character: tt.col},
+				},
+			}
+			got, err := hover(tt.input, params, dbCache, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "`world`.`move_city` procedure\n\n```sql\nmove_city(city_id int)\n```\n"
+			if diff := cmp.Diff(want, got.Contents.Value); diff != "" {
+				t.Errorf("unmatch hover contents (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
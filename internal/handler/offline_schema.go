@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"path/filepath"
+	"slices"
+
+	"github.com/yaamai/sqls/internal/database"
+)
+
+// loadOfflineSchema builds the schema cache from the DDL files of the
+// workspace, it is used when no connection is configured.
+func (s *Server) loadOfflineSchema(ctx context.Context, cfg *database.OfflineSchemaConfig) error {
+	files, err := database.DDLFiles(s.rootPath, cfg.Paths)
+	if err != nil {
+		return err
+	}
+	schema, err := database.LoadDDLSchema(database.Coalesce(cfg.DefaultSchema, database.DefaultOfflineSchema), files)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.dbConn = nil
+	s.offlineSchema = cfg
+	s.mu.Unlock()
+	return s.worker.ReCache(ctx, database.NewDDLDBRepository(cfg.Driver, schema))
+}
+
+// reloadOfflineSchema rebuilds the offline schema when the saved document is
+// one of its DDL files.
+func (s *Server) reloadOfflineSchema(ctx context.Context, uri string) {
+	cfg := s.currentOfflineSchema()
+	if cfg == nil {
+		return
+	}
+	files, err := database.DDLFiles(s.rootPath, cfg.Paths)
+	if err != nil {
+		log.Println("cannot reload offline schema,", err)
+		return
+	}
+	if !slices.Contains(files, uriToPath(uri)) {
+		return
+	}
+	if err := s.loadOfflineSchema(ctx, cfg); err != nil {
+		log.Println("cannot reload offline schema,", err)
+	}
+}
+
+func (s *Server) currentOfflineSchema() *database.OfflineSchemaConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.offlineSchema
+}
+
+// uriToPath returns the file path of a file URI, other strings are returned
+// as is.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/yaamai/sqls/internal/config"
+	"github.com/yaamai/sqls/internal/database"
+	"github.com/yaamai/sqls/internal/lsp"
+)
+
+func Test_offlineSchema(t *testing.T) {
+	root := t.TempDir()
+	schemaFile := filepath.Join(root, "db", "schema.sql")
+	if err := os.MkdirAll(filepath.Dir(schemaFile), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(schemaFile, []byte("CREATE TABLE users (id int PRIMARY KEY, name text);"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := newTestContext()
+	tx.server.SpecificFileCfg = &config.Config{
+		OfflineSchema: &database.OfflineSchemaConfig{Paths: []string{"db"}, Driver: "postgresql"},
+	}
+	tx.initParams.RootURI = "file://" + filepath.ToSlash(root)
+	tx.setup(t)
+	defer tx.tearDown()
+
+	uri := "file:///select.sql"
+	tx.textDocumentDidOpen(t, uri, "SELECT  FROM users")
+	complete := func() []string {
+		completionParams := lsp.CompletionParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+				Position:     lsp.Position{Line: 0, Character: 7},
+			},
+		}
+		var items []lsp.CompletionItem
+		if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &items); err != nil {
+			t.Fatal("conn.Call textDocument/completion:", err)
+		}
+		labels := []string{}
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+	if got := complete(); !slices.Contains(got, "name") {
+		t.Fatalf("column of the offline schema is not completed, %v", got)
+	}
+
+	// saving the schema file rebuilds the schema
+	if err := os.WriteFile(schemaFile, []byte("CREATE TABLE users (id int PRIMARY KEY, name text, email text);"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	schemaURI := "file://" + filepath.ToSlash(schemaFile)
+	tx.textDocumentDidOpen(t, schemaURI, "")
+	didSaveParams := lsp.DidSaveTextDocumentParams{TextDocument: lsp.TextDocumentIdentifier{URI: schemaURI}}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didSave", didSaveParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didSave:", err)
+	}
+	tx.waitScheduled(t)
+	if got := complete(); !slices.Contains(got, "email") {
+		t.Errorf("column is not completed after saving the schema file, %v", got)
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"context"
+	"log"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/yaamai/sqls/internal/lsp"
+)
+
+// workDoneProgress reports the progress of a long running command with
+// $/progress notifications. Nothing is sent unless the client passed a workDoneToken.
+type workDoneProgress struct {
+	ctx   context.Context
+	conn  *jsonrpc2.Conn
+	token interface{}
+}
+
+func beginWorkDoneProgress(ctx context.Context, conn *jsonrpc2.Conn, token interface{}, title string) *workDoneProgress {
+	if token == nil {
+		return nil
+	}
+	p := &workDoneProgress{ctx: ctx, conn: conn, token: token}
+	p.notify(&lsp.WorkDoneProgressBegin{Kind: "begin", Title: title})
+	return p
+}
+
+func (p *workDoneProgress) report(message string, percentage *int) {
+	if p == nil {
+		return
+	}
+	p.notify(&lsp.WorkDoneProgressReport{Kind: "report", Message: message, Percentage: percentage})
+}
+
+func (p *workDoneProgress) end(message string) {
+	if p == nil {
+		return
+	}
+	p.notify(&lsp.WorkDoneProgressEnd{Kind: "end", Message: message})
+}
+
+func (p *workDoneProgress) notify(value interface{}) {
+	if err := lsp.NotifyProgress(p.ctx, p.conn, &lsp.ProgressParams{Token: p.token, Value: value}); err != nil {
+		log.Println("send progress", err.Error())
+	}
+}
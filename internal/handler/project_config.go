@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"path/filepath"
+
+	"github.com/yaamai/sqls/internal/config"
+)
+
+// discoverProjectConfig loads the project config file found from dir and its
+// parents, it reports whether a project config is found.
+func (s *Server) discoverProjectConfig(dir string) bool {
+	fp, ok := config.FindProjectConfig(dir)
+	if !ok {
+		return false
+	}
+	project, err := config.LoadProjectConfig(fp)
+	if err != nil {
+		log.Println("cannot read project config,", err)
+		return false
+	}
+	log.Println("use project config,", fp)
+	s.setProjectConfig(project)
+	return true
+}
+
+func (s *Server) setProjectConfig(project *config.ProjectConfig) {
+	s.projectMu.Lock()
+	defer s.projectMu.Unlock()
+	s.projectCfg = project
+	s.mergedBase = nil
+	s.mergedCfg = nil
+}
+
+func (s *Server) projectConfig() *config.ProjectConfig {
+	s.projectMu.Lock()
+	defer s.projectMu.Unlock()
+	return s.projectCfg
+}
+
+// mergeProjectConfig returns the project config merged over the user config,
+// base is nil if no user config is given. The merged config is cached while
+// the user config is the same, so that the connection configs keep their
+// identity across getConfig.
+//
+// The default config file is read without validation because a project config
+// may complete its connections, it is validated here when no project config
+// is merged over it.
+func (s *Server) mergeProjectConfig(base *config.Config, isDefault bool) *config.Config {
+	s.projectMu.Lock()
+	defer s.projectMu.Unlock()
+	if s.projectCfg == nil && (base == nil || !isDefault) {
+		if base == nil {
+			return config.NewConfig()
+		}
+		return base
+	}
+	if s.mergedCfg != nil && s.mergedBase == base {
+		return s.mergedCfg
+	}
+	var merged *config.Config
+	if s.projectCfg != nil {
+		var err error
+		if merged, err = s.projectCfg.Merge(base); err != nil {
+			log.Println("cannot merge project config,", err)
+			merged = nil
+		}
+	}
+	if merged == nil {
+		merged = base
+		if merged == nil {
+			merged = config.NewConfig()
+		} else if isDefault {
+			if err := merged.Validate(); err != nil {
+				log.Println("cannot use default config, failed validation,", err)
+				merged = config.NewConfig()
+			}
+		}
+	}
+	s.mergedBase = base
+	s.mergedCfg = merged
+	return merged
+}
+
+// discoverDocumentProjectConfig looks for the project config from the
+// directory of the opened document when the workspace has no root, and
+// connects with it if no connection is open yet.
+func (s *Server) discoverDocumentProjectConfig(ctx context.Context, uri string) {
+	if s.rootPath != "" || s.projectConfig() != nil {
+		return
+	}
+	path := uriToPath(uri)
+	if path == uri {
+		return
+	}
+	if !s.discoverProjectConfig(filepath.Dir(path)) {
+		return
+	}
+	s.connectProjectConfig(ctx)
+}
+
+// reloadProjectConfig reads the project config again when the saved document
+// is the project config file.
+func (s *Server) reloadProjectConfig(ctx context.Context, uri string) {
+	project := s.projectConfig()
+	if project == nil || uriToPath(uri) != project.Path {
+		return
+	}
+	reloaded, err := config.LoadProjectConfig(project.Path)
+	if err != nil {
+		log.Println("cannot reload project config,", err)
+		return
+	}
+	s.setProjectConfig(reloaded)
+	s.connectProjectConfig(ctx)
+}
+
+func (s *Server) connectProjectConfig(ctx context.Context) {
+	// the aliases of the bound documents may point to other connections
+	s.closeSessions()
+	if dbConn, _ := s.connection(); dbConn != nil {
+		return
+	}
+	if err := s.reconnectionDB(ctx); err != nil && !errors.Is(err, ErrNoConnection) {
+		log.Println("cannot connect with project config,", err)
+	}
+}
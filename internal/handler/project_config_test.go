@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/yaamai/sqls/internal/config"
+	"github.com/yaamai/sqls/internal/database"
+	"github.com/yaamai/sqls/internal/lsp"
+)
+
+func Test_projectConfig(t *testing.T) {
+	root := t.TempDir()
+	dbPath := filepath.Join(root, "app.db")
+	createSQLiteDB(t, dbPath, "CREATE TABLE city (id INTEGER PRIMARY KEY, city_name TEXT)")
+	project := "lowercaseKeywords: true\nconnections:\n  - alias: app\n    driver: sqlite3\n    dataSourceName: " + dbPath + "\n"
+	if err := os.WriteFile(filepath.Join(root, config.ProjectConfigFileName), []byte(project), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "queries")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	// the project config is found from the document without a workspace root
+	uri := "file://" + filepath.ToSlash(filepath.Join(dir, "query.sql"))
+	didOpenParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: uri, LanguageID: "sql", Text: "SELECT  FROM city"},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didOpen:", err)
+	}
+	tx.waitScheduled(t)
+	completionParams := lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+			Position:     lsp.Position{Line: 0, Character: 7},
+		},
+	}
+	var items []lsp.CompletionItem
+	if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &items); err != nil {
+		t.Fatal("conn.Call textDocument/completion:", err)
+	}
+	labels := []string{}
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+	if !slices.Contains(labels, "city_name") || !tx.server.getConfig().LowercaseKeywords {
+		t.Errorf("project config is not used, %v", labels)
+	}
+
+	// the project config is merged over the workspace config
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{Alias: "personal", Driver: "sqlite3", DataSourceName: filepath.Join(root, "personal.db")},
+		},
+	})
+	cfg := tx.server.getConfig()
+	if !cfg.LowercaseKeywords || len(cfg.Connections) != 2 || cfg.Connections[0].Alias != "app" || cfg.Connections[1].Alias != "personal" {
+		t.Errorf("unexpected merged config %+v", cfg)
+	}
+	if cfg != tx.server.getConfig() {
+		t.Error("merged config is not cached")
+	}
+}
+
+func Test_invalidDefaultConfig(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.server.DefaultFileCfg = &config.Config{
+		Connections: []*database.DBConfig{
+			{Alias: "invalid", Driver: "unknown", DataSourceName: "unknown"},
+		},
+	}
+	// the default config is validated when no project config is merged over it
+	if cfg := tx.server.getConfig(); len(cfg.Connections) != 0 {
+		t.Errorf("invalid default config is used, %+v", cfg)
+	}
+}
@@ -24,7 +24,7 @@ func (s *Server) handleTextDocumentRename(ctx context.Context, conn *jsonrpc2.Co
 		return nil, err
 	}
 
-	f, ok := s.files[params.TextDocument.URI]
+	f, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/yaamai/sqls/dialect"
+	"github.com/yaamai/sqls/internal/database"
+	"github.com/yaamai/sqls/token"
+)
+
+// loadReferencedSchemas loads the schemas qualifying a name in the text, such
+// as "schema.table", which are not loaded on connection.
+func loadReferencedSchemas(ctx context.Context, worker *database.Worker, text string) {
+	dbCache := worker.Cache()
+	if dbCache == nil {
+		return
+	}
+	if err := worker.LoadSchemas(ctx, referencedSchemas(text, dbCache)...); err != nil {
+		log.Println("cannot load schema,", err)
+	}
+}
+
+func referencedSchemas(text string, dbCache *database.DBCache) []string {
+	// the names before an invalid token, such as an unterminated string
+	// while typing, are still used
+	tokenizer := token.NewTokenizer(strings.NewReader(text), &dialect.GenericSQLDialect{})
+	tokens := []*token.Token{}
+	for {
+		tok, err := tokenizer.NextToken()
+		if err != nil {
+			break
+		}
+		tokens = append(tokens, tok)
+	}
+	seen := map[string]struct{}{}
+	schemaNames := []string{}
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].Kind != token.SQLKeyword || tokens[i+1].Kind != token.Period {
+			continue
+		}
+		word, ok := tokens[i].Value.(*token.SQLWord)
+		if !ok {
+			continue
+		}
+		name := word.NoQuoteString()
+		if _, ok := dbCache.Database(name); !ok {
+			continue
+		}
+		if _, ok := seen[strings.ToUpper(name)]; ok {
+			continue
+		}
+		seen[strings.ToUpper(name)] = struct{}{}
+		schemaNames = append(schemaNames, name)
+	}
+	return schemaNames
+}
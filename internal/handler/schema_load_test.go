@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/yaamai/sqls/internal/database"
+)
+
+func Test_referencedSchemas(t *testing.T) {
+	dbCache, err := database.NewDBCacheUpdater(database.NewMockDBRepository(nil)).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"none", "SELECT * FROM city", []string{}},
+		{"qualified table", "SELECT * FROM sys.t JOIN mysql.user u ON 1 = 1", []string{"sys", "mysql"}},
+		{"completing", "SELECT * FROM `SYS`.", []string{"SYS"}},
+		{"duplicated", "SELECT sys.t.a FROM sys.t", []string{"sys"}},
+		{"unknown schema and alias", "SELECT c.ID FROM city c, nothing.t", []string{}},
+		{"unterminated string", "SELECT * FROM sys.t WHERE a = 'abc", []string{"sys"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := cmp.Diff(tt.want, referencedSchemas(tt.input, dbCache)); d != "" {
+				t.Errorf("unmatched schemas (- want, + got):\n%s", d)
+			}
+		})
+	}
+}
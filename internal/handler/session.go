@@ -0,0 +1,359 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/yaamai/sqls/dialect"
+	"github.com/yaamai/sqls/internal/database"
+	"github.com/yaamai/sqls/internal/lsp"
+)
+
+// connectionHeaderPrefix starts the comment binding a document to a
+// connection, such as "-- sqls:connection=alias".
+const connectionHeaderPrefix = "sqls:connection="
+
+// session is the connection and the schema cache used by a document. The
+// documents not bound to a connection share the connection of the server.
+type session struct {
+	// conn is nil while no connection is open, such as for the offline schema
+	conn   *database.DBConnection
+	cfg    *database.DBConfig
+	worker *database.Worker
+	// driver is the dialect used for completion
+	driver dialect.DatabaseDriver
+
+	// ready is closed when a bound session is opened, err is the error of
+	// opening it
+	ready chan struct{}
+	err   error
+	// refs counts the requests using a bound session, a session removed
+	// from the sessions of the server is closed when no request uses it.
+	// They are guarded by sessionMu.
+	refs    int
+	removed bool
+}
+
+func (ss *session) repository() (database.DBRepository, error) {
+	if ss.conn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	return database.CreateRepository(ss.cfg.Driver, ss.conn.Conn)
+}
+
+// close closes the connection of a bound session, the session of the server
+// is closed by reconnectionDB and Stop.
+func (ss *session) close() {
+	ss.worker.Stop()
+	if err := ss.conn.Close(); err != nil {
+		log.Println("cannot close connection,", err)
+	}
+}
+
+// defaultSession returns the session of the server.
+func (s *Server) defaultSession() *session {
+	dbConn, connCfg := s.connection()
+	ss := &session{conn: dbConn, cfg: connCfg, worker: s.worker}
+	if dbConn != nil {
+		ss.driver = dbConn.Driver
+	} else if offlineSchema := s.currentOfflineSchema(); offlineSchema != nil {
+		ss.driver = offlineSchema.Driver
+	}
+	return ss
+}
+
+// documentSession returns the session of the connection bound to the
+// document. The connection is opened and cached on first use, the request
+// releases the session with releaseSession when done with it.
+func (s *Server) documentSession(ctx context.Context, uri string) (*session, error) {
+	alias := s.documentConnection(uri)
+	if alias == "" {
+		return s.defaultSession(), nil
+	}
+	cfg, err := s.findConnection(alias)
+	if err != nil {
+		return nil, err
+	}
+	if def := s.defaultSession(); def.conn != nil && sameConnection(def.cfg, cfg) {
+		return def, nil
+	}
+	return s.boundSession(ctx, cfg)
+}
+
+// uriFlag gives the document of the commands not taking the document as the
+// first argument, they use the connection of the document.
+const uriFlag = "-uri="
+
+// commandSession returns the session of the document given by the -uri= flag
+// of the command arguments, or the session of the server without the flag.
+// The other arguments are returned.
+func (s *Server) commandSession(ctx context.Context, args []interface{}) (*session, []interface{}, error) {
+	rest := []interface{}{}
+	var uri string
+	for _, arg := range args {
+		if str, ok := arg.(string); ok && strings.HasPrefix(str, uriFlag) {
+			uri = strings.TrimPrefix(str, uriFlag)
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	if uri == "" {
+		return s.defaultSession(), rest, nil
+	}
+	ss, err := s.documentSession(ctx, uri)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ss, rest, nil
+}
+
+// documentConnection returns the alias of the connection bound to the
+// document by bindConnection or by the connection header, it is empty for
+// the connection of the server.
+func (s *Server) documentConnection(uri string) string {
+	s.bindingMu.RLock()
+	alias, ok := s.bindings[uri]
+	s.bindingMu.RUnlock()
+	if ok {
+		return alias
+	}
+	if f, ok := s.files.get(uri); ok {
+		return connectionHeader(f.Text)
+	}
+	return ""
+}
+
+// boundSession returns the session of the connection, opening it on first
+// use. The connection is opened without sessionMu, so that a slow connection
+// does not block the sessions of the other documents; the documents using
+// the same connection meanwhile wait for it to be ready.
+func (s *Server) boundSession(ctx context.Context, cfg *database.DBConfig) (*session, error) {
+	key := sessionKey(cfg)
+	s.sessionMu.Lock()
+	ss, ok := s.sessions[key]
+	if !ok {
+		ss = &session{cfg: cfg, ready: make(chan struct{})}
+		s.sessions[key] = ss
+	}
+	ss.refs++
+	s.sessionMu.Unlock()
+
+	if ok {
+		select {
+		case <-ss.ready:
+		case <-ctx.Done():
+			s.releaseSession(ss)
+			return nil, ctx.Err()
+		}
+		if ss.err != nil {
+			s.releaseSession(ss)
+			return nil, ss.err
+		}
+		return ss, nil
+	}
+
+	err := ss.open(ctx, s.getConfig().MaxLoadedSchemas)
+	s.sessionMu.Lock()
+	if err != nil && s.sessions[key] == ss {
+		delete(s.sessions, key)
+		ss.removed = true
+	}
+	ss.err = err
+	close(ss.ready)
+	s.sessionMu.Unlock()
+	if err != nil {
+		s.releaseSession(ss)
+		return nil, err
+	}
+	log.Println("open connection for documents,", database.Coalesce(cfg.Alias, string(cfg.Driver)))
+	return ss, nil
+}
+
+// releaseSession ends the use of the session by a request. A bound session
+// removed while in use is closed by its last request, the session of the
+// server is not counted.
+func (s *Server) releaseSession(ss *session) {
+	if ss.ready == nil {
+		return
+	}
+	s.sessionMu.Lock()
+	ss.refs--
+	closing := ss.removed && ss.refs == 0 && ss.err == nil
+	s.sessionMu.Unlock()
+	if closing {
+		ss.close()
+	}
+}
+
+// removeSession removes the session from the sessions of the server, it
+// returns whether the session is to be closed now. A session in use is
+// closed by releaseSession. sessionMu must be held.
+func (s *Server) removeSession(key string, ss *session) bool {
+	delete(s.sessions, key)
+	ss.removed = true
+	// the session opening is in use by boundSession
+	return ss.refs == 0 && ss.err == nil
+}
+
+// open opens the connection of the session and caches its schema.
+func (ss *session) open(ctx context.Context, maxLoadedSchemas int) error {
+	conn, err := database.Open(ss.cfg)
+	if err != nil {
+		return err
+	}
+	repo, err := database.CreateRepository(ss.cfg.Driver, conn.Conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	worker := database.NewWorker()
+	worker.SetMaxLoadedSchemas(maxLoadedSchemas)
+	worker.Start()
+	if err := reCache(ctx, worker, ss.cfg, repo); err != nil {
+		worker.Stop()
+		conn.Close()
+		return err
+	}
+	ss.conn, ss.worker, ss.driver = conn, worker, conn.Driver
+	return nil
+}
+
+// reCache rebuilds the cache of the worker for the connection, the cache is
+// persisted if the connection allows it.
+func reCache(ctx context.Context, worker *database.Worker, cfg *database.DBConfig, repo database.DBRepository) error {
+	cacheFile, err := database.SchemaCacheFile(cfg)
+	if err != nil {
+		log.Println("cannot persist schema cache,", err)
+	}
+	if cacheFile != "" {
+		return worker.ReCacheWithFile(ctx, repo, cacheFile)
+	}
+	return worker.ReCache(ctx, repo)
+}
+
+// releaseSessions closes the bound sessions which no open document uses.
+func (s *Server) releaseSessions() {
+	used := map[string]struct{}{}
+	for uri := range s.files.all() {
+		alias := s.documentConnection(uri)
+		if alias == "" {
+			continue
+		}
+		if cfg, err := s.findConnection(alias); err == nil {
+			used[sessionKey(cfg)] = struct{}{}
+		}
+	}
+	closing := []*session{}
+	s.sessionMu.Lock()
+	for key, ss := range s.sessions {
+		if _, ok := used[key]; !ok && s.removeSession(key, ss) {
+			closing = append(closing, ss)
+		}
+	}
+	s.sessionMu.Unlock()
+	for _, ss := range closing {
+		ss.close()
+	}
+}
+
+// closeSessions closes all bound sessions, they are opened again on use.
+func (s *Server) closeSessions() {
+	closing := []*session{}
+	s.sessionMu.Lock()
+	for key, ss := range s.sessions {
+		if s.removeSession(key, ss) {
+			closing = append(closing, ss)
+		}
+	}
+	s.sessionMu.Unlock()
+	for _, ss := range closing {
+		ss.close()
+	}
+}
+
+// sessionKey identifies the connection by all of its settings, the
+// connections without an alias may differ in any of them such as the host.
+func sessionKey(cfg *database.DBConfig) string {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Sprintf("%#v", cfg)
+	}
+	return string(b)
+}
+
+func sameConnection(a, b *database.DBConfig) bool {
+	return a == b || sessionKey(a) == sessionKey(b)
+}
+
+// connectionHeader returns the alias of the "-- sqls:connection=alias"
+// comment in the comments at the top of the text.
+func connectionHeader(text string) string {
+	for text != "" {
+		var line string
+		line, text, _ = strings.Cut(text, "\n")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		comment, ok := strings.CutPrefix(line, "--")
+		if !ok {
+			return ""
+		}
+		if alias, ok := strings.CutPrefix(strings.TrimSpace(comment), connectionHeaderPrefix); ok {
+			return strings.TrimSpace(alias)
+		}
+	}
+	return ""
+}
+
+func (s *Server) bindConnection(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) == 0 {
+		return nil, fmt.Errorf("required arguments were not provided: <File URI> <Connection Alias>")
+	}
+	uri, ok := params.Arguments[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the file uri as a string")
+	}
+	var alias string
+	if len(params.Arguments) > 1 {
+		if alias, ok = params.Arguments[1].(string); !ok {
+			return nil, fmt.Errorf("specify the connection alias as a string")
+		}
+	}
+
+	// binding no connection unbinds the document
+	if alias == "" {
+		s.bindingMu.Lock()
+		delete(s.bindings, uri)
+		s.bindingMu.Unlock()
+		s.releaseSessions()
+		return nil, nil
+	}
+	if _, err := s.findConnection(alias); err != nil {
+		return nil, err
+	}
+	s.bindingMu.Lock()
+	prev, bound := s.bindings[uri]
+	s.bindings[uri] = alias
+	s.bindingMu.Unlock()
+
+	// connect now to report the error to the command
+	ss, err := s.documentSession(ctx, uri)
+	if err != nil {
+		s.bindingMu.Lock()
+		if bound {
+			s.bindings[uri] = prev
+		} else {
+			delete(s.bindings, uri)
+		}
+		s.bindingMu.Unlock()
+		return nil, err
+	}
+	s.releaseSession(ss)
+	s.releaseSessions()
+	return nil, nil
+}
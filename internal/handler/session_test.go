@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yaamai/sqls/internal/config"
+	"github.com/yaamai/sqls/internal/database"
+	"github.com/yaamai/sqls/internal/lsp"
+)
+
+func Test_documentConnection(t *testing.T) {
+	dir := t.TempDir()
+	cityPath := filepath.Join(dir, "city.db")
+	createSQLiteDB(t, cityPath, "CREATE TABLE city (id INTEGER PRIMARY KEY, city_name TEXT)", "INSERT INTO city VALUES (1, 'Kabul')")
+	countryPath := filepath.Join(dir, "country.db")
+	createSQLiteDB(t, countryPath, "CREATE TABLE country (code TEXT PRIMARY KEY, country_name TEXT)", "INSERT INTO country VALUES ('AFG', 'Afghanistan')")
+
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+	didChangeConfigurationParams := lsp.DidChangeConfigurationParams{
+		Settings: struct {
+			SQLS *config.Config "json:\"sqls\""
+		}{
+			SQLS: &config.Config{
+				Connections: []*database.DBConfig{
+					{Alias: "city", Driver: "sqlite3", DataSourceName: cityPath},
+					{Alias: "country", Driver: "sqlite3", DataSourceName: countryPath},
+				},
+			},
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "workspace/didChangeConfiguration", didChangeConfigurationParams, nil); err != nil {
+		t.Fatal("conn.Call workspace/didChangeConfiguration:", err)
+	}
+
+	openFile := func(uri, text string) {
+		t.Helper()
+		didOpenParams := lsp.DidOpenTextDocumentParams{
+			TextDocument: lsp.TextDocumentItem{URI: uri, LanguageID: "sql", Text: text},
+		}
+		if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+			t.Fatal("conn.Call textDocument/didOpen:", err)
+		}
+	}
+	complete := func(uri string, line int) []string {
+		t.Helper()
+		completionParams := lsp.CompletionParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+				Position:     lsp.Position{Line: line, Character: 7},
+			},
+		}
+		var items []lsp.CompletionItem
+		if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &items); err != nil {
+			t.Fatal("conn.Call textDocument/completion:", err)
+		}
+		labels := []string{}
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+	execute := func(command string, args ...interface{}) (string, error) {
+		var got string
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{Command: command, Arguments: args}, &got)
+		return got, err
+	}
+
+	cityURI := "file:///city.sql"
+	openFile(cityURI, "SELECT  FROM city")
+	countryURI := "file:///country.sql"
+	openFile(countryURI, "-- sqls:connection=country\nSELECT  FROM country")
+
+	// the documents use their own connections at the same time
+	if got := complete(cityURI, 0); !slices.Contains(got, "city_name") || slices.Contains(got, "country_name") {
+		t.Errorf("unexpected completion for the server connection, %v", got)
+	}
+	if got := complete(countryURI, 1); !slices.Contains(got, "country_name") || slices.Contains(got, "city_name") {
+		t.Errorf("unexpected completion for the header connection, %v", got)
+	}
+	openFile("file:///query.sql", "-- sqls:connection=country\nSELECT country_name FROM country")
+	got, err := execute(CommandExecuteQuery, "file:///query.sql")
+	if err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	if !strings.Contains(got, "Afghanistan") {
+		t.Errorf("query is not executed on the header connection, %s", got)
+	}
+
+	// the commands given the document use its connection
+	got, err = execute(CommandShowCreateTable, "country", uriFlag+countryURI)
+	if err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	if !strings.Contains(got, "country_name") {
+		t.Errorf("unexpected CREATE statement of the header connection, %s", got)
+	}
+	if _, err := execute(CommandShowCreateTable, "country"); err == nil {
+		t.Error("expected an error for the table missing in the server connection")
+	}
+	got, err = execute(CommandGenerateERD, uriFlag+countryURI)
+	if err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	if !strings.Contains(got, "country_name") || strings.Contains(got, "city_name") {
+		t.Errorf("unexpected diagram of the header connection, %s", got)
+	}
+	if _, err := execute(CommandRefreshSchema, uriFlag+countryURI); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+
+	// the command binding overrides the header
+	if _, err := execute(CommandBindConnection, cityURI, "country"); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	if got := complete(cityURI, 0); !slices.Contains(got, "country") || slices.Contains(got, "city") {
+		t.Errorf("bound connection is not used, %v", got)
+	}
+	if _, err := execute(CommandBindConnection, cityURI); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	if got := complete(cityURI, 0); !slices.Contains(got, "city_name") {
+		t.Errorf("server connection is not used after unbinding, %v", got)
+	}
+	if _, err := execute(CommandBindConnection, cityURI, "missing"); err == nil {
+		t.Error("expected an error for the unknown alias")
+	}
+}
+
+func Test_connectionHeader(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "-- sqls:connection=pg\nSELECT 1", want: "pg"},
+		{text: "\n-- migration\n--sqls:connection= mysql \nSELECT 1", want: "mysql"},
+		{text: "SELECT 1\n-- sqls:connection=pg", want: ""},
+		{text: "-- other comment", want: ""},
+		{text: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := connectionHeader(tt.text); got != tt.want {
+			t.Errorf("connectionHeader(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func Test_sessionKey(t *testing.T) {
+	newCfg := func(host string) *database.DBConfig {
+		return &database.DBConfig{Driver: "postgresql", Proto: "tcp", User: "app", Host: host, Port: 5432, DBName: "app"}
+	}
+	primary, replica := newCfg("primary.example.com"), newCfg("replica.example.com")
+	if sessionKey(primary) == sessionKey(replica) || sameConnection(primary, replica) {
+		t.Error("the connections to different hosts have the same session")
+	}
+	if !sameConnection(primary, newCfg("primary.example.com")) {
+		t.Error("the same connection settings have different sessions")
+	}
+}
+
+func Test_boundSessionOpening(t *testing.T) {
+	dir := t.TempDir()
+	cityPath := filepath.Join(dir, "city.db")
+	createSQLiteDB(t, cityPath, "CREATE TABLE city (id INTEGER PRIMARY KEY, city_name TEXT)")
+
+	s := NewServer()
+	defer s.Stop()
+	// a connection still opening, such as an unreachable server
+	slow := &database.DBConfig{Alias: "slow", Driver: "postgresql", Host: "unreachable.example.com"}
+	opening := &session{cfg: slow, ready: make(chan struct{}), refs: 1}
+	s.sessions[sessionKey(slow)] = opening
+
+	city := &database.DBConfig{Alias: "city", Driver: "sqlite3", DataSourceName: cityPath}
+	ss, err := s.boundSession(context.Background(), city)
+	if err != nil {
+		t.Fatal("boundSession:", err)
+	}
+	if ss.conn == nil {
+		t.Fatal("the session is not opened")
+	}
+	s.releaseSession(ss)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.boundSession(ctx, slow); !errors.Is(err, context.Canceled) {
+		t.Errorf("boundSession of the opening connection = %v, want %v", err, context.Canceled)
+	}
+
+	s.closeSessions()
+	if len(s.sessions) != 0 {
+		t.Errorf("sessions are left, %d", len(s.sessions))
+	}
+}
+
+func Test_releaseSessionInUse(t *testing.T) {
+	dir := t.TempDir()
+	cityPath := filepath.Join(dir, "city.db")
+	createSQLiteDB(t, cityPath, "CREATE TABLE city (id INTEGER PRIMARY KEY, city_name TEXT)")
+
+	s := NewServer()
+	defer s.Stop()
+	city := &database.DBConfig{Alias: "city", Driver: "sqlite3", DataSourceName: cityPath}
+	ss, err := s.boundSession(context.Background(), city)
+	if err != nil {
+		t.Fatal("boundSession:", err)
+	}
+
+	// no document uses the connection, but a request still does
+	s.releaseSessions()
+	if len(s.sessions) != 0 {
+		t.Errorf("sessions are left, %d", len(s.sessions))
+	}
+	if err := ss.conn.Conn.Ping(); err != nil {
+		t.Fatal("the session in use is closed:", err)
+	}
+
+	s.releaseSession(ss)
+	if err := ss.conn.Conn.Ping(); err == nil {
+		t.Error("the released session is not closed")
+	}
+}
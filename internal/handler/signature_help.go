@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/sourcegraph/jsonrpc2"
+	"github.com/yaamai/sqls/ast"
+	"github.com/yaamai/sqls/ast/astutil"
 	"github.com/yaamai/sqls/internal/database"
 	"github.com/yaamai/sqls/internal/lsp"
 	"github.com/yaamai/sqls/parser"
@@ -23,12 +26,17 @@ func (s *Server) handleTextDocumentSignatureHelp(ctx context.Context, conn *json
 		return nil, err
 	}
 
-	f, ok := s.files[params.TextDocument.URI]
+	f, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	res, err := SignatureHelp(f.Text, params, s.worker.Cache())
+	ss, err := s.documentSession(ctx, params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseSession(ss)
+	res, err := SignatureHelp(f.Text, params, ss.worker.Cache())
 	if err != nil {
 		return nil, err
 	}
@@ -96,9 +104,187 @@ func SignatureHelp(text string, params lsp.SignatureHelpParams, dbCache *databas
 		}
 		return sh, nil
 	default:
-		// pass
-		return nil, nil
+		return routineSignatureHelp(parsed, pos, dbCache), nil
+	}
+}
+
+// routineCall is a call of a stored function or procedure enclosing the cursor.
+type routineCall struct {
+	schema    string
+	name      string
+	activeArg int
+}
+
+// routineSignatureHelp shows the parameters of the stored function or
+// procedure called at the cursor, in either "name(args)" or "EXEC name args" form.
+func routineSignatureHelp(parsed ast.TokenList, pos token.Pos, dbCache *database.DBCache) *lsp.SignatureHelp {
+	call := findRoutineCall(parsed, pos)
+	if call == nil {
+		return nil
+	}
+	var (
+		routines []*database.RoutineDesc
+		ok       bool
+	)
+	if call.schema != "" {
+		routines, ok = dbCache.RoutinesByDBName(call.schema, call.name)
+	} else {
+		routines, ok = dbCache.Routines(call.name)
+	}
+	if !ok {
+		return nil
+	}
+
+	sh := &lsp.SignatureHelp{
+		ActiveParameter: float64(call.activeArg),
+	}
+	activeSignature := -1
+	for i, routine := range routines {
+		params := []lsp.ParameterInformation{}
+		for _, p := range routine.Params {
+			params = append(params, lsp.ParameterInformation{
+				Label:         p.String(),
+				Documentation: p.Type,
+			})
+		}
+		if activeSignature < 0 && call.activeArg < len(params) {
+			activeSignature = i
+		}
+		sh.Signatures = append(sh.Signatures, lsp.SignatureInformation{
+			Label:         routine.Signature(),
+			Documentation: fmt.Sprintf("%s.%s %s", routine.Schema, routine.Name, strings.ToLower(routine.Kind)),
+			Parameters:    params,
+		})
+	}
+	if activeSignature > 0 {
+		sh.ActiveSignature = float64(activeSignature)
+	}
+	return sh
+}
+
+func findRoutineCall(parsed ast.TokenList, pos token.Pos) *routineCall {
+	for _, stmt := range parsed.GetTokens() {
+		list, ok := stmt.(ast.TokenList)
+		if !ok || !astutil.IsEnclose(stmt, pos) {
+			continue
+		}
+		if call := findParenthesisCall(list, pos); call != nil {
+			return call
+		}
+		return findExecCall(list, pos)
+	}
+	return nil
+}
+
+// findParenthesisCall finds the innermost "name(args)" enclosing the cursor.
+func findParenthesisCall(list ast.TokenList, pos token.Pos) *routineCall {
+	toks := list.GetTokens()
+	for i, tok := range toks {
+		paren, ok := tok.(*ast.Parenthesis)
+		if ok && i > 0 && insideParenthesis(paren, pos) {
+			if call := findParenthesisCall(paren, pos); call != nil {
+				return call
+			}
+			schema, name, ok := routineName(toks, i-1)
+			if !ok {
+				return nil
+			}
+			return &routineCall{
+				schema:    schema,
+				name:      name,
+				activeArg: countArgSeparators(paren.GetTokens()[1:], pos),
+			}
+		}
+		if child, ok := tok.(ast.TokenList); ok && astutil.IsEnclose(tok, pos) {
+			if call := findParenthesisCall(child, pos); call != nil {
+				return call
+			}
+		}
+	}
+	return nil
+}
+
+// routineName returns the routine name at toks[i], optionally qualified by
+// a "schema." member identifier before it.
+func routineName(toks []ast.Node, i int) (string, string, bool) {
+	switch v := toks[i].(type) {
+	case *ast.Identifier:
+		schema := ""
+		if i > 0 {
+			if mi, ok := toks[i-1].(*ast.MemberIdentifier); ok && mi.ChildTok == nil {
+				schema = mi.ParentTok.NoQuoteString()
+			}
+		}
+		return schema, v.NoQuoteString(), true
+	case *ast.MemberIdentifier:
+		if v.ChildTok == nil {
+			return "", "", false
+		}
+		return v.ParentTok.NoQuoteString(), v.ChildTok.NoQuoteString(), true
+	}
+	return "", "", false
+}
+
+// insideParenthesis reports whether the cursor is after "(" and not after
+// the closing ")".
+func insideParenthesis(paren *ast.Parenthesis, pos token.Pos) bool {
+	if token.ComparePos(pos, paren.Pos()) <= 0 {
+		return false
+	}
+	toks := paren.GetTokens()
+	if len(toks) > 1 && toks[len(toks)-1].String() == ")" {
+		return token.ComparePos(pos, paren.End()) < 0
+	}
+	return token.ComparePos(pos, paren.End()) <= 0
+}
+
+// findExecCall finds "EXEC name args" or "CALL name args" without parenthesis.
+func findExecCall(stmt ast.TokenList, pos token.Pos) *routineCall {
+	toks := []ast.Node{}
+	for _, tok := range stmt.GetTokens() {
+		if strings.TrimSpace(tok.String()) != "" {
+			toks = append(toks, tok)
+		}
+	}
+	if len(toks) < 2 {
+		return nil
+	}
+	switch strings.ToUpper(toks[0].String()) {
+	case "EXEC", "EXECUTE", "CALL":
+	default:
+		return nil
+	}
+	schema, name, ok := routineName(toks, 1)
+	if !ok || token.ComparePos(pos, toks[1].End()) <= 0 {
+		return nil
+	}
+	return &routineCall{
+		schema:    schema,
+		name:      name,
+		activeArg: countArgSeparators(toks[2:], pos),
+	}
+}
+
+// countArgSeparators counts the commas before the cursor, ignoring the
+// commas of nested parenthesis.
+func countArgSeparators(nodes []ast.Node, pos token.Pos) int {
+	count := 0
+	for _, node := range nodes {
+		if token.ComparePos(node.Pos(), pos) >= 0 {
+			break
+		}
+		switch v := node.(type) {
+		case *ast.Parenthesis:
+			continue
+		case ast.TokenList:
+			count += countArgSeparators(v.GetTokens(), pos)
+		default:
+			if node.String() == "," {
+				count++
+			}
+		}
 	}
+	return count
 }
 
 type signatureHelpType int
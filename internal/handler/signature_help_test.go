@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -183,3 +184,113 @@ func TestSignatureHelpNoneDBConnection(t *testing.T) {
 		})
 	}
 }
+
+func TestRoutineSignatureHelp(t *testing.T) {
+	repo := database.NewMockDBRepository(nil).(*database.MockDBRepository)
+	repo.MockSchemaRoutines = func(ctx context.Context) (map[string][]*database.RoutineDesc, error) {
+		return map[string][]*database.RoutineDesc{
+			"world": {
+				{
+					Schema: "world", Name: "city_population", Kind: "FUNCTION", ReturnType: "int",
+					Params: []*database.RoutineParam{{Name: "city_id", Mode: "IN", Type: "int"}, {Name: "year", Mode: "IN", Type: "int"}},
+				},
+				{
+					Schema: "world", Name: "move_city", Kind: "PROCEDURE",
+					Params: []*database.RoutineParam{{Name: "city_id", Mode: "IN", Type: "int"}, {Name: "moved", Mode: "OUT", Type: "boolean"}},
+				},
+			},
+		}, nil
+	}
+	dbCache, err := database.NewDBCacheUpdater(repo).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	functionSignature := lsp.SignatureInformation{
+		Label:         "city_population(city_id int, year int) RETURNS int",
+		Documentation: "world.city_population function",
+		Parameters: []lsp.ParameterInformation{
+			{Label: "city_id int", Documentation: "int"},
+			{Label: "year int", Documentation: "int"},
+		},
+	}
+	procedureSignature := lsp.SignatureInformation{
+		Label:         "move_city(city_id int, OUT moved boolean)",
+		Documentation: "world.move_city procedure",
+		Parameters: []lsp.ParameterInformation{
+			{Label: "city_id int", Documentation: "int"},
+			{Label: "OUT moved boolean", Documentation: "boolean"},
+		},
+	}
+	tests := []struct {
+		name  string
+		input string
+		col   int
+		want  *lsp.SignatureHelp
+	}{
+		{
+			name:  "function first argument",
+			input: "SELECT city_population(1, 2020) FROM city",
+			col:   23,
+			want:  &lsp.SignatureHelp{Signatures: []lsp.SignatureInformation{functionSignature}, ActiveParameter: 0},
+		},
+		{
+			name:  "function second argument",
+			input: "SELECT city_population(1, 2020) FROM city",
+			col:   26,
+			want:  &lsp.SignatureHelp{Signatures: []lsp.SignatureInformation{functionSignature}, ActiveParameter: 1},
+		},
+		{
+			name:  "nested call",
+			input: "SELECT city_population(abs(1), ",
+			col:   31,
+			want:  &lsp.SignatureHelp{Signatures: []lsp.SignatureInformation{functionSignature}, ActiveParameter: 1},
+		},
+		{
+			name:  "schema qualified",
+			input: "SELECT world.city_population(1, ",
+			col:   32,
+			want:  &lsp.SignatureHelp{Signatures: []lsp.SignatureInformation{functionSignature}, ActiveParameter: 1},
+		},
+		{
+			name:  "call",
+			input: "CALL move_city(1, @moved)",
+			col:   18,
+			want:  &lsp.SignatureHelp{Signatures: []lsp.SignatureInformation{procedureSignature}, ActiveParameter: 1},
+		},
+		{
+			name:  "exec",
+			input: "EXEC move_city 1, ",
+			col:   18,
+			want:  &lsp.SignatureHelp{Signatures: []lsp.SignatureInformation{procedureSignature}, ActiveParameter: 1},
+		},
+		{
+			name:  "after closing parenthesis",
+			input: "SELECT city_population(1, 2020) FROM city",
+			col:   31,
+			want:  nil,
+		},
+		{
+			name:  "unknown function",
+			input: "SELECT abs(1)",
+			col:   11,
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := lsp.SignatureHelpParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					Position: lsp.Position{Line: 0, Character: tt.col},
+				},
+			}
+			got, err := SignatureHelp(tt.input, params, dbCache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -59,3 +59,11 @@ func (m *Messenger) ShowError(ctx context.Context, message string) error {
 	}
 	return m.conn.Notify(ctx, "window/showMessage", params)
 }
+
+func NotifyQueryResult(ctx context.Context, conn *jsonrpc2.Conn, params *QueryResultParams) error {
+	return conn.Notify(ctx, "sqls/queryResult", params)
+}
+
+func NotifyProgress(ctx context.Context, conn *jsonrpc2.Conn, params *ProgressParams) error {
+	return conn.Notify(ctx, "$/progress", params)
+}
@@ -305,6 +305,50 @@ type ExecuteCommandParams struct {
 	Range *Range `json:"range,omitempty"`
 }
 
+// sqls specific notification sent for each statement run by executeQuery
+
+type QueryResultParams struct {
+	URI           string               `json:"uri"`
+	Query         string               `json:"query"`
+	Columns       []*QueryResultColumn `json:"columns"`
+	Rows          [][]interface{}      `json:"rows"`
+	RowsAffected  int64                `json:"rowsAffected"`
+	ExecutionTime float64              `json:"executionTime"` // milliseconds
+}
+
+type QueryResultColumn struct {
+	Name         string `json:"name"`
+	DatabaseType string `json:"databaseType"`
+	Nullable     *bool  `json:"nullable,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/specification-3-17/#workDoneProgress
+
+type ProgressParams struct {
+	Token interface{} `json:"token"`
+	Value interface{} `json:"value"`
+}
+
+type WorkDoneProgressBegin struct {
+	Kind        string `json:"kind"` // begin
+	Title       string `json:"title"`
+	Cancellable bool   `json:"cancellable,omitempty"`
+	Message     string `json:"message,omitempty"`
+	Percentage  *int   `json:"percentage,omitempty"`
+}
+
+type WorkDoneProgressReport struct {
+	Kind        string `json:"kind"` // report
+	Cancellable bool   `json:"cancellable,omitempty"`
+	Message     string `json:"message,omitempty"`
+	Percentage  *int   `json:"percentage,omitempty"`
+}
+
+type WorkDoneProgressEnd struct {
+	Kind    string `json:"kind"` // end
+	Message string `json:"message,omitempty"`
+}
+
 // https://microsoft.github.io/language-server-protocol/specifications/specification-3-14/#workspace_didChangeConfiguration
 
 type DidChangeConfigurationParams struct {
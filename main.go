@@ -15,6 +15,7 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/yaamai/sqls/internal/config"
+	"github.com/yaamai/sqls/internal/database"
 	"github.com/yaamai/sqls/internal/handler"
 )
 
@@ -67,6 +68,40 @@ func realMain() error {
 					return openEditor(editorEnv, config.YamlConfigPath)
 				},
 			},
+			{
+				Name:  "schema",
+				Usage: "database schema tools",
+				Subcommands: cli.Commands{
+					{
+						Name:  "dump",
+						Usage: "write the schemas, tables, columns and foreign keys of a connection to stdout",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "config",
+								Aliases: []string{"c"},
+								Usage:   "Specifies an alternative per-user configuration file.",
+							},
+							&cli.StringFlag{
+								Name:  "connection",
+								Usage: "Alias or 1-origin index of the connection. The first connection is used by default.",
+							},
+							&cli.StringFlag{
+								Name:    "format",
+								Aliases: []string{"f"},
+								Value:   string(database.SchemaDumpFormatYAML),
+								Usage:   "Output format, json, yaml or markdown.",
+							},
+							&cli.StringSliceFlag{
+								Name:  "schema",
+								Usage: "Schema to dump, all schemas by default. It can be repeated.",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return dumpSchema(c)
+						},
+					},
+				},
+			},
 		},
 		Action: func(c *cli.Context) error {
 			return serve(c)
@@ -117,7 +152,7 @@ func serve(c *cli.Context) error {
 			log.Println(err)
 		}
 	}()
-	h := jsonrpc2.HandlerWithError(server.Handle)
+	h := server.Handler()
 
 	// Load specific config
 	if configFile != "" {
@@ -127,8 +162,9 @@ func serve(c *cli.Context) error {
 		}
 		server.SpecificFileCfg = cfg
 	} else {
-		// Load default config
-		cfg, err := config.GetDefaultConfig()
+		// Load default config, it is validated when no project config
+		// completes its connections
+		cfg, err := config.ReadDefaultConfig()
 		if err != nil && !errors.Is(config.ErrNotFoundConfig, err) {
 			return fmt.Errorf("cannot read default config, %w", err)
 		}
@@ -154,6 +190,79 @@ func serve(c *cli.Context) error {
 	return nil
 }
 
+func dumpSchema(c *cli.Context) error {
+	// the config flag of the global options is also accepted
+	var configFile string
+	for _, ctx := range c.Lineage() {
+		if configFile = ctx.String("config"); configFile != "" {
+			break
+		}
+	}
+	var cfg *config.Config
+	var err error
+	if configFile != "" {
+		cfg, err = config.GetConfig(configFile)
+		if err != nil {
+			return fmt.Errorf("cannot read specified config, %w", err)
+		}
+	} else {
+		cfg, err = config.ReadDefaultConfig()
+		if err != nil && !errors.Is(err, config.ErrNotFoundConfig) {
+			return fmt.Errorf("cannot read default config, %w", err)
+		}
+		// the project config of the current directory is merged over the default config
+		if fp, ok := config.FindProjectConfig("."); ok {
+			project, err := config.LoadProjectConfig(fp)
+			if err != nil {
+				return err
+			}
+			if cfg, err = project.Merge(cfg); err != nil {
+				return fmt.Errorf("cannot merge project config, %w", err)
+			}
+		} else if cfg == nil {
+			return fmt.Errorf("cannot read default config, %w", config.ErrNotFoundConfig)
+		} else if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("cannot read default config, failed validation, %w", err)
+		}
+	}
+
+	format, err := database.ParseSchemaDumpFormat(c.String("format"))
+	if err != nil {
+		return err
+	}
+
+	var connCfg *database.DBConfig
+	if alias := c.String("connection"); alias != "" {
+		connCfg, err = cfg.Connection(alias)
+		if err != nil {
+			return err
+		}
+	} else {
+		if len(cfg.Connections) == 0 {
+			return errors.New("no database connection config")
+		}
+		connCfg = cfg.Connections[0]
+	}
+	if err := connCfg.Validate(); err != nil {
+		return err
+	}
+
+	conn, err := database.Open(connCfg)
+	if err != nil {
+		return fmt.Errorf("cannot open database connection, %w", err)
+	}
+	defer conn.Close()
+	repo, err := database.CreateRepository(connCfg.Driver, conn.Conn)
+	if err != nil {
+		return err
+	}
+	f, err := database.DumpSchemaFile(c.Context, repo, c.StringSlice("schema")...)
+	if err != nil {
+		return err
+	}
+	return database.WriteSchemaFile(os.Stdout, f, format)
+}
+
 type stdrwc struct{}
 
 func (stdrwc) Read(p []byte) (int, error) {